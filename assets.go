@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -19,8 +20,8 @@ import (
 // directory set to dir (the repo's configured `path`); if dir is empty it falls
 // back to the directory versionista was invoked from. On success, stdout is
 // parsed as a newline-separated list of file paths to upload alongside the
-// release.
-func GenerateAssets(command, version, dir, sha string) (paths []string, err error) {
+// release. Cancelling ctx kills the command; the checkout is still restored.
+func GenerateAssets(ctx context.Context, command, version, dir, sha string) (paths []string, err error) {
 	shell := os.Getenv("SHELL")
 	if shell == "" {
 		shell = "/bin/sh"
@@ -54,7 +55,7 @@ func GenerateAssets(command, version, dir, sha string) (paths []string, err erro
 	// The -c script forwards its positional args to the command via "$@", so the
 	// version reaches the command as $1. The name after the script string becomes
 	// $0 (a conventional label), and version becomes $1.
-	cmd := exec.Command(shell, "-c", command+` "$@"`, "generate-assets", version)
+	cmd := exec.CommandContext(ctx, shell, "-c", command+` "$@"`, "generate-assets", version)
 	cmd.Dir = dir
 
 	var stdout, stderr bytes.Buffer
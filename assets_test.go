@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -42,7 +43,7 @@ func initTestRepo(t *testing.T) (dir, sha string) {
 func TestGenerateAssetsReturnsPaths(t *testing.T) {
 	dir, sha := initTestRepo(t)
 	// The command receives the version as $1 and echoes two paths built from it.
-	paths, err := GenerateAssets("printf 'dist/app-%s.tar.gz\\ndist/app-%s.zip\\n' \"$1\" \"$1\" #", "1.2.3", dir, sha)
+	paths, err := GenerateAssets(context.Background(), "printf 'dist/app-%s.tar.gz\\ndist/app-%s.zip\\n' \"$1\" \"$1\" #", "1.2.3", dir, sha)
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
@@ -63,7 +64,7 @@ func TestGenerateAssetsReturnsPaths(t *testing.T) {
 
 func TestGenerateAssetsIgnoresBlankLines(t *testing.T) {
 	dir, sha := initTestRepo(t)
-	paths, err := GenerateAssets("printf 'one\\n\\n  two  \\n'", "1.0.0", dir, sha)
+	paths, err := GenerateAssets(context.Background(), "printf 'one\\n\\n  two  \\n'", "1.0.0", dir, sha)
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
@@ -80,7 +81,7 @@ func TestGenerateAssetsIgnoresBlankLines(t *testing.T) {
 
 func TestGenerateAssetsAbsolutePathsKept(t *testing.T) {
 	dir, sha := initTestRepo(t)
-	paths, err := GenerateAssets("echo /tmp/absolute-$1.zip #", "1.0.0", dir, sha)
+	paths, err := GenerateAssets(context.Background(), "echo /tmp/absolute-$1.zip #", "1.0.0", dir, sha)
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
@@ -91,14 +92,14 @@ func TestGenerateAssetsAbsolutePathsKept(t *testing.T) {
 
 func TestGenerateAssetsFailureReturnsError(t *testing.T) {
 	dir, sha := initTestRepo(t)
-	if _, err := GenerateAssets("exit 1", "1.0.0", dir, sha); err == nil {
+	if _, err := GenerateAssets(context.Background(), "exit 1", "1.0.0", dir, sha); err == nil {
 		t.Fatal("Expected an error when the command exits non-zero")
 	}
 }
 
 func TestGenerateAssetsFailureIncludesCommandAndOutput(t *testing.T) {
 	dir, sha := initTestRepo(t)
-	_, err := GenerateAssets("echo boom-out; echo boom-err >&2; exit 3", "1.0.0", dir, sha)
+	_, err := GenerateAssets(context.Background(), "echo boom-out; echo boom-err >&2; exit 3", "1.0.0", dir, sha)
 	if err == nil {
 		t.Fatal("Expected an error when the command exits non-zero")
 	}
@@ -114,7 +115,7 @@ func TestGenerateAssetsRunsInConfiguredDir(t *testing.T) {
 	dir, sha := initTestRepo(t)
 	// `ls README` only succeeds if the command runs in dir. The trailing `#`
 	// comments out the appended version argument.
-	paths, err := GenerateAssets("ls README >/dev/null && echo README #", "1.0.0", dir, sha)
+	paths, err := GenerateAssets(context.Background(), "ls README >/dev/null && echo README #", "1.0.0", dir, sha)
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
@@ -150,7 +151,7 @@ func TestGenerateAssetsForwardsArgsToScript(t *testing.T) {
 	}
 	sha = strings.TrimSpace(string(shaOut))
 
-	paths, err := GenerateAssets("./release.sh", "v0.0.1", dir, sha)
+	paths, err := GenerateAssets(context.Background(), "./release.sh", "v0.0.1", dir, sha)
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
@@ -165,7 +166,7 @@ func TestGenerateAssetsRejectsDirtyTree(t *testing.T) {
 	if err := os.WriteFile(filepath.Join(dir, "dirty.txt"), []byte("x"), 0644); err != nil {
 		t.Fatal(err)
 	}
-	_, err := GenerateAssets("echo out", "1.0.0", dir, sha)
+	_, err := GenerateAssets(context.Background(), "echo out", "1.0.0", dir, sha)
 	if err == nil {
 		t.Fatal("Expected an error when the working tree is dirty")
 	}
@@ -176,7 +177,7 @@ func TestGenerateAssetsRejectsDirtyTree(t *testing.T) {
 
 func TestGenerateAssetsRejectsNonGitDir(t *testing.T) {
 	dir := t.TempDir()
-	if _, err := GenerateAssets("echo out", "1.0.0", dir, "HEAD"); err == nil {
+	if _, err := GenerateAssets(context.Background(), "echo out", "1.0.0", dir, "HEAD"); err == nil {
 		t.Fatal("Expected an error when the directory is not a git repository")
 	}
 }
@@ -204,7 +205,7 @@ func TestGenerateAssetsRestoresOriginalBranch(t *testing.T) {
 	git("commit", "-q", "-m", "second")
 
 	// Release the first (older) commit; afterwards we should be back on branch.
-	if _, err := GenerateAssets("echo out", "1.0.0", dir, firstSHA); err != nil {
+	if _, err := GenerateAssets(context.Background(), "echo out", "1.0.0", dir, firstSHA); err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
 
@@ -225,6 +226,21 @@ func gitIn(t *testing.T, dir string, args ...string) string {
 	return strings.TrimSpace(string(out))
 }
 
+func TestGenerateAssetsRestoresCheckoutWhenCancelled(t *testing.T) {
+	dir, firstSHA := initTestRepo(t)
+	branch := gitIn(t, dir, "rev-parse", "--abbrev-ref", "HEAD")
+	gitIn(t, dir, "commit", "-q", "--allow-empty", "-m", "second")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := GenerateAssets(ctx, "sleep 5; echo out", "1.0.0", dir, firstSHA); err == nil {
+		t.Fatal("Expected error for a cancelled context")
+	}
+	if got := gitIn(t, dir, "rev-parse", "--abbrev-ref", "HEAD"); got != branch {
+		t.Errorf("Expected to be restored to branch %q, got %q", branch, got)
+	}
+}
+
 // TestGenerateAssetsBuildsRemoteBranchTip is the regression test for the stale
 // local-branch bug: when the release ref is a BRANCH NAME and the remote is
 // ahead of the local branch, the build must run against the REMOTE tip (what is
@@ -269,7 +285,7 @@ func TestGenerateAssetsBuildsRemoteBranchTip(t *testing.T) {
 	// content to an output file so we can prove which commit was built.
 	outFile := filepath.Join(work, "built-content.txt")
 	cmd := "cp marker.txt " + outFile + " && echo " + outFile
-	if _, err := GenerateAssets(cmd, "1.0.0", work, branch); err != nil {
+	if _, err := GenerateAssets(context.Background(), cmd, "1.0.0", work, branch); err != nil {
 		t.Fatalf("GenerateAssets returned error: %v", err)
 	}
 
@@ -318,7 +334,7 @@ func TestGenerateAssetsExplicitSHANotRewritten(t *testing.T) {
 
 	outFile := filepath.Join(work, "built-content.txt")
 	cmd := "cp marker.txt " + outFile + " && echo " + outFile
-	if _, err := GenerateAssets(cmd, "1.0.0", work, firstSHA); err != nil {
+	if _, err := GenerateAssets(context.Background(), cmd, "1.0.0", work, firstSHA); err != nil {
 		t.Fatalf("GenerateAssets returned error: %v", err)
 	}
 
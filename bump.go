@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// BumpSuggestion is the version bump a set of changes calls for, with a short
+// explanation such as "minor: 3 feat PRs". Type is empty when nothing in the
+// changes suggests a release. Minimum is the bump demanded by PR labels alone,
+// which choosing a smaller bump should warn about.
+type BumpSuggestion struct {
+	Type    BumpType
+	Reason  string
+	Minimum BumpType
+}
+
+var (
+	// conventionalHeader matches a Conventional Commits subject such as
+	// "feat(api)!: drop v1", capturing the type and the breaking marker.
+	conventionalHeader = regexp.MustCompile(`^(\w+)(?:\([^)]*\))?(!)?:\s`)
+	breakingFooter     = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE:`)
+)
+
+// bumpRank orders bump types from least to most significant; anything that
+// isn't a semver bump ranks 0.
+func bumpRank(bump BumpType) int {
+	switch bump {
+	case BumpPatch:
+		return 1
+	case BumpMinor:
+		return 2
+	case BumpMajor:
+		return 3
+	}
+	return 0
+}
+
+// conventionalBump returns the bump a Conventional Commits subject and body
+// call for, and the kind of change that calls for it ("feat", "fix",
+// "breaking change", ...). It returns an empty bump for messages that don't
+// follow the convention or whose type (docs, chore, ...) doesn't release.
+func conventionalBump(subject, body string) (BumpType, string) {
+	match := conventionalHeader.FindStringSubmatch(strings.TrimSpace(subject))
+	if (match != nil && match[2] == "!") || breakingFooter.MatchString(body) {
+		return BumpMajor, "breaking change"
+	}
+	if match == nil {
+		return "", ""
+	}
+
+	kind := strings.ToLower(match[1])
+	switch kind {
+	case "feat":
+		return BumpMinor, kind
+	case "fix", "perf", "revert":
+		return BumpPatch, kind
+	}
+	return "", ""
+}
+
+// labelBump returns the largest bump labelBumps maps any of labels to, and
+// the label that calls for it. Labels are matched case-insensitively.
+func labelBump(labels []string, labelBumps map[string]BumpType) (BumpType, string) {
+	var bump BumpType
+	var reason string
+	for _, label := range labels {
+		if b := labelBumps[strings.ToLower(label)]; bumpRank(b) > bumpRank(bump) {
+			bump, reason = b, label
+		}
+	}
+	return bump, reason
+}
+
+// SuggestBump recommends a bump from the labels of the entries' PRs, mapped
+// to bumps by labelBumps (lower-case label to bump), and from the
+// Conventional Commits prefixes and BREAKING CHANGE footers in the PR titles
+// and descriptions and in the messages of commits. Merge and squash commits
+// of the entries' PRs are skipped so a PR isn't counted twice.
+func SuggestBump(entries []Entry, commits []Commit, labelBumps map[string]BumpType) BumpSuggestion {
+	var tally bumpTally
+	var minimum BumpType
+
+	prs := make(map[int]bool)
+	for _, entry := range entries {
+		// Direct commits are counted with the other commits below
+		if entry.SHA != "" {
+			continue
+		}
+		prs[entry.Number] = true
+		if bump, label := labelBump(entry.Labels, labelBumps); bump != "" {
+			tally.add(bump, label, "PR")
+			if bumpRank(bump) > bumpRank(minimum) {
+				minimum = bump
+			}
+		}
+		bump, kind := conventionalBump(entry.Title, entry.Description)
+		tally.add(bump, kind, "PR")
+	}
+
+	for _, commit := range commits {
+		number := commit.PullRequest
+		if number == 0 {
+			number, _ = ParsePRNumber(commit.Message)
+		}
+		if prs[number] {
+			continue
+		}
+		subject, body, _ := strings.Cut(commit.Message, "\n")
+		bump, kind := conventionalBump(subject, body)
+		tally.add(bump, kind, "commit")
+	}
+
+	suggestion := tally.suggestion()
+	suggestion.Minimum = minimum
+	return suggestion
+}
+
+// bumpTally counts the changes calling for each kind of bump, in the order
+// they were first seen, so the suggestion's reason reads naturally.
+type bumpTally struct {
+	top    BumpType
+	counts []bumpCount
+}
+
+type bumpCount struct {
+	bump  BumpType
+	kind  string
+	unit  string
+	count int
+}
+
+func (t *bumpTally) add(bump BumpType, kind, unit string) {
+	if bump == "" {
+		return
+	}
+	if bumpRank(bump) > bumpRank(t.top) {
+		t.top = bump
+	}
+	for i := range t.counts {
+		if t.counts[i].kind == kind && t.counts[i].unit == unit {
+			t.counts[i].count++
+			return
+		}
+	}
+	t.counts = append(t.counts, bumpCount{bump: bump, kind: kind, unit: unit, count: 1})
+}
+
+func (t *bumpTally) suggestion() BumpSuggestion {
+	if t.top == "" {
+		return BumpSuggestion{}
+	}
+
+	var reasons []string
+	for _, c := range t.counts {
+		if c.bump != t.top {
+			continue
+		}
+		unit := c.unit
+		if c.count != 1 {
+			unit += "s"
+		}
+		reasons = append(reasons, fmt.Sprintf("%d %s %s", c.count, c.kind, unit))
+	}
+	return BumpSuggestion{
+		Type:   t.top,
+		Reason: fmt.Sprintf("%s: %s", t.top, strings.Join(reasons, ", ")),
+	}
+}
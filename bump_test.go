@@ -0,0 +1,150 @@
+package main
+
+import "testing"
+
+func TestConventionalBump(t *testing.T) {
+	tests := []struct {
+		subject  string
+		body     string
+		wantBump BumpType
+		wantKind string
+	}{
+		{"feat: add export", "", BumpMinor, "feat"},
+		{"feat(api): add export", "", BumpMinor, "feat"},
+		{"fix: handle nil", "", BumpPatch, "fix"},
+		{"perf: cache lookups", "", BumpPatch, "perf"},
+		{"feat!: drop v1 API", "", BumpMajor, "breaking change"},
+		{"refactor(core)!: rename config", "", BumpMajor, "breaking change"},
+		{"fix: handle nil", "Details\n\nBREAKING CHANGE: nil is now an error", BumpMajor, "breaking change"},
+		{"Update deps", "BREAKING-CHANGE: requires Go 1.25", BumpMajor, "breaking change"},
+		{"docs: fix typo", "", "", ""},
+		{"chore: bump deps", "", "", ""},
+		{"Add export", "", "", ""},
+		{"Merge pull request #12 from org/feat: thing", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.subject, func(t *testing.T) {
+			bump, kind := conventionalBump(tt.subject, tt.body)
+			if bump != tt.wantBump || kind != tt.wantKind {
+				t.Errorf("Expected (%q, %q), got (%q, %q)", tt.wantBump, tt.wantKind, bump, kind)
+			}
+		})
+	}
+}
+
+func TestSuggestBump(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []Entry
+		commits []Commit
+		want    BumpSuggestion
+	}{
+		{
+			name: "feat PRs",
+			entries: []Entry{
+				{Number: 1, Title: "feat: add export"},
+				{Number: 2, Title: "fix: handle nil"},
+				{Number: 3, Title: "feat(ui): dark mode"},
+				{Number: 4, Title: "feat: search"},
+			},
+			want: BumpSuggestion{Type: BumpMinor, Reason: "minor: 3 feat PRs"},
+		},
+		{
+			name:    "breaking footer in a PR description",
+			entries: []Entry{{Number: 1, Title: "fix: handle nil", Description: "BREAKING CHANGE: nil is an error"}},
+			want:    BumpSuggestion{Type: BumpMajor, Reason: "major: 1 breaking change PR"},
+		},
+		{
+			name:    "commits of listed PRs aren't counted twice",
+			entries: []Entry{{Number: 7, Title: "fix: handle nil"}},
+			commits: []Commit{
+				{Message: "fix: handle nil (#7)"},
+				{Message: "Merge pull request #7 from org/fix\n\nfix: handle nil"},
+				{Message: "perf: faster startup"},
+			},
+			want: BumpSuggestion{Type: BumpPatch, Reason: "patch: 1 fix PR, 1 perf commit"},
+		},
+		{
+			name:    "rebased commits of a listed PR aren't counted",
+			entries: []Entry{{Number: 7, Title: "feat: add retries"}},
+			commits: []Commit{
+				{Message: "fix: retry on timeouts", PullRequest: 7},
+				{Message: "refactor: extract backoff", PullRequest: 7},
+			},
+			want: BumpSuggestion{Type: BumpMinor, Reason: "minor: 1 feat PR"},
+		},
+		{
+			name:    "direct commit with a breaking change",
+			entries: []Entry{{Number: 1, Title: "feat: add export"}},
+			commits: []Commit{{Message: "feat!: drop v1\n\nThe v1 API is gone."}},
+			want:    BumpSuggestion{Type: BumpMajor, Reason: "major: 1 breaking change commit"},
+		},
+		{
+			name:    "listed direct commits are counted as commits",
+			entries: []Entry{{SHA: "abc1234", Ref: "abc1234", Title: "feat: add export"}},
+			commits: []Commit{{SHA: "abc1234", Message: "feat: add export"}},
+			want:    BumpSuggestion{Type: BumpMinor, Reason: "minor: 1 feat commit"},
+		},
+		{
+			name:    "no conventional prefixes",
+			entries: []Entry{{Number: 1, Title: "Add export"}, {Number: 2, Title: "docs: readme"}},
+			want:    BumpSuggestion{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SuggestBump(tt.entries, tt.commits, nil); got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSuggestBumpFromLabels(t *testing.T) {
+	labelBumps := map[string]BumpType{"semver:major": BumpMajor, "breaking": BumpMajor, "semver:minor": BumpMinor}
+
+	tests := []struct {
+		name    string
+		entries []Entry
+		want    BumpSuggestion
+	}{
+		{
+			name: "label outranks prefixes",
+			entries: []Entry{
+				{Number: 1, Title: "fix: handle nil", Labels: []string{"Breaking"}},
+				{Number: 2, Title: "feat: add export"},
+			},
+			want: BumpSuggestion{Type: BumpMajor, Reason: "major: 1 Breaking PR", Minimum: BumpMajor},
+		},
+		{
+			name: "labels and prefixes agree",
+			entries: []Entry{
+				{Number: 1, Title: "Add export", Labels: []string{"semver:minor", "ui"}},
+				{Number: 2, Title: "feat: search"},
+			},
+			want: BumpSuggestion{Type: BumpMinor, Reason: "minor: 1 semver:minor PR, 1 feat PR", Minimum: BumpMinor},
+		},
+		{
+			name: "breaking change beyond the labels",
+			entries: []Entry{
+				{Number: 1, Title: "feat!: drop v1", Labels: []string{"semver:minor"}},
+			},
+			want: BumpSuggestion{Type: BumpMajor, Reason: "major: 1 breaking change PR", Minimum: BumpMinor},
+		},
+		{
+			name:    "unmapped labels",
+			entries: []Entry{{Number: 1, Title: "Add export", Labels: []string{"ui"}}},
+			want:    BumpSuggestion{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SuggestBump(tt.entries, nil, labelBumps); got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
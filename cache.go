@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// cacheTransport stores GitHub GET responses on disk together with their ETag
+// and revalidates them with If-None-Match. GitHub answers an unchanged
+// resource with 304 Not Modified, which doesn't count against the rate limit,
+// so re-running review and release on the same project is nearly free.
+//
+// Entries are keyed by URL, Accept header and Authorization header, so
+// different tokens never share cached responses. Failures to read or write the
+// cache are logged and otherwise ignored.
+type cacheTransport struct {
+	base   http.RoundTripper
+	dir    string
+	logger *Logger
+}
+
+func newCacheTransport(base http.RoundTripper, dir string, logger *Logger) *cacheTransport {
+	return &cacheTransport{
+		base:   base,
+		dir:    dir,
+		logger: logger,
+	}
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	path := t.entryPath(req)
+	cached, etag := t.load(path, req)
+
+	outgoing := req
+	if etag != "" {
+		outgoing = req.Clone(req.Context())
+		outgoing.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := t.base.RoundTrip(outgoing)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		t.logger.Debug("Cache hit for %s", req.URL.Path)
+		// Carry the fresh rate limit headers over so the client's budget
+		// tracking stays accurate.
+		for key, values := range resp.Header {
+			if strings.HasPrefix(key, "X-Ratelimit-") {
+				cached.Header[key] = values
+			}
+		}
+		drainAndClose(resp)
+		return cached, nil
+	}
+	if cached != nil {
+		cached.Body.Close()
+	}
+
+	if resp.StatusCode == http.StatusOK && resp.Header.Get("ETag") != "" {
+		return t.store(path, resp), nil
+	}
+	return resp, nil
+}
+
+// entryPath returns the cache file for req.
+func (t *cacheTransport) entryPath(req *http.Request) string {
+	h := sha256.New()
+	io.WriteString(h, req.URL.String())
+	io.WriteString(h, "\n"+req.Header.Get("Accept"))
+	io.WriteString(h, "\n"+req.Header.Get("Authorization"))
+	return filepath.Join(t.dir, hex.EncodeToString(h.Sum(nil)))
+}
+
+// load returns the cached response at path and its ETag, or nil when there is
+// no usable entry.
+func (t *cacheTransport) load(path string, req *http.Request) (*http.Response, string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			t.logger.Debug("Failed to read cache entry %s: %v", path, err)
+		}
+		return nil, ""
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), req)
+	if err != nil {
+		t.logger.Debug("Ignoring corrupt cache entry %s: %v", path, err)
+		return nil, ""
+	}
+	return resp, resp.Header.Get("ETag")
+}
+
+// store writes resp to path and returns an equivalent response whose body can
+// still be read by the caller.
+func (t *cacheTransport) store(path string, resp *http.Response) *http.Response {
+	dump, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		t.logger.Debug("Failed to cache %s: %v", resp.Request.URL.Path, err)
+		return resp
+	}
+	if err := writeFileAtomic(path, dump); err != nil {
+		t.logger.Debug("Failed to cache %s: %v", resp.Request.URL.Path, err)
+	}
+	return resp
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// into place, so concurrent readers never see a partial entry.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ClearCache removes the cached responses under dir, leaving any other files
+// alone. A missing directory is not an error.
+func ClearCache(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read cache directory %s: %w", dir, err)
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		if !isCacheEntry(entry) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil {
+			return removed, fmt.Errorf("failed to remove cache entry %s: %w", entry.Name(), err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// isCacheEntry reports whether entry is a file the cache wrote: a hex-encoded
+// SHA-256 key or a leftover temporary file.
+func isCacheEntry(entry os.DirEntry) bool {
+	if !entry.Type().IsRegular() {
+		return false
+	}
+	name := entry.Name()
+	if strings.HasPrefix(name, ".tmp-") {
+		return true
+	}
+	if len(name) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(name)
+	return err == nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newETagServer serves body with a fixed ETag, answering matching
+// If-None-Match requests with 304. It counts full and conditional responses.
+func newETagServer(t *testing.T, body string, compress bool) (server *httptest.Server, full, notModified *int) {
+	t.Helper()
+	full, notModified = new(int), new(int)
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		if r.Header.Get("If-None-Match") == `"abc"` {
+			*notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		*full++
+		w.Header().Set("ETag", `"abc"`)
+		w.Header().Set("Content-Type", "application/json")
+		if compress {
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(w)
+			gz.Write([]byte(body))
+			gz.Close()
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, full, notModified
+}
+
+func TestCacheTransportRevalidates(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		name := "plain"
+		if compress {
+			name = "gzip"
+		}
+		t.Run(name, func(t *testing.T) {
+			server, full, notModified := newETagServer(t, `{"number": 42}`, compress)
+			client := &http.Client{Transport: newCacheTransport(http.DefaultTransport, t.TempDir(), NewLogger())}
+
+			for i := 0; i < 3; i++ {
+				resp, err := client.Get(server.URL + "/repos/org/repo/pulls/42")
+				if err != nil {
+					t.Fatalf("request %d: expected no error, got: %v", i, err)
+				}
+				body, _ := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					t.Errorf("request %d: expected 200, got %d", i, resp.StatusCode)
+				}
+				if string(body) != `{"number": 42}` {
+					t.Errorf("request %d: unexpected body %q", i, body)
+				}
+			}
+
+			if *full != 1 || *notModified != 2 {
+				t.Errorf("Expected 1 full and 2 conditional responses, got %d and %d", *full, *notModified)
+			}
+		})
+	}
+}
+
+func TestCacheTransportKeysByAuthorization(t *testing.T) {
+	server, full, _ := newETagServer(t, `{}`, false)
+	client := &http.Client{Transport: newCacheTransport(http.DefaultTransport, t.TempDir(), NewLogger())}
+
+	for _, token := range []string{"Bearer one", "Bearer two"} {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		req.Header.Set("Authorization", token)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	if *full != 2 {
+		t.Errorf("Expected each token to get its own cache entry, got %d full responses", *full)
+	}
+}
+
+func TestCacheTransportSkipsNonGET(t *testing.T) {
+	dir := t.TempDir()
+	server, _, _ := newETagServer(t, `{}`, false)
+	client := &http.Client{Transport: newCacheTransport(http.DefaultTransport, dir, NewLogger())}
+
+	resp, err := client.Post(server.URL, "application/json", nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	resp.Body.Close()
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("Expected POST responses not to be cached, found %d entries", len(entries))
+	}
+}
+
+func TestClearCache(t *testing.T) {
+	dir := t.TempDir()
+	key := strings.Repeat("ab", sha256.Size)
+	for _, name := range []string{key, ".tmp-123", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "projects"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := ClearCache(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("Expected 2 entries removed, got %d", removed)
+	}
+	for _, name := range []string{key, ".tmp-123"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected cache entry %s to be removed", name)
+		}
+	}
+	for _, name := range []string{"notes.txt", "projects"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("Expected foreign entry %s to survive, got: %v", name, err)
+		}
+	}
+
+	if removed, err := ClearCache(filepath.Join(dir, "missing")); err != nil || removed != 0 {
+		t.Errorf("Expected missing directory to be a no-op, got %d, %v", removed, err)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver"
+)
+
+// DefaultCalVerFormat is used by repositories with `versioning: calver` that
+// don't set calver_format.
+const DefaultCalVerFormat = "YYYY.MM.MICRO"
+
+// calverUnits ranks the date tokens of a calendar versioning format from
+// coarsest to finest, so that later releases always get higher versions.
+var calverUnits = map[string]int{
+	"YYYY": 1, "YY": 1, "0Y": 1,
+	"MM": 2, "0M": 2,
+	"DD": 3, "0D": 3,
+}
+
+// CalVer is a calendar versioning format such as YYYY.MM.MICRO or YY.0M.DD.
+// Its one to three dot-separated tokens map to a version's major, minor and
+// patch numbers:
+//
+//   - YYYY is the full year (2026), YY the year since 2000 (26) and 0Y the
+//     same, zero-padded (06)
+//   - MM is the month (1 to 12) and 0M the same, zero-padded
+//   - DD is the day of the month and 0D the same, zero-padded
+//   - MICRO counts releases sharing the same date parts, starting at 0
+//
+// Zero-padded versions aren't valid semver, so calendar versions keep the
+// tag they were parsed from or rendered to in their Original().
+type CalVer struct {
+	Format string
+	tokens []string
+}
+
+// ParseCalVer checks that format starts with a year, that each date token is
+// finer than the one before it, and that MICRO, if present, comes last.
+func ParseCalVer(format string) (*CalVer, error) {
+	tokens := strings.Split(format, ".")
+	if len(tokens) > 3 {
+		return nil, fmt.Errorf("calver format %q has more than three parts", format)
+	}
+
+	unit := 0
+	for i, token := range tokens {
+		if token == "MICRO" {
+			if i != len(tokens)-1 || i == 0 {
+				return nil, fmt.Errorf("calver format %q must end with MICRO, after a date", format)
+			}
+			continue
+		}
+		next, ok := calverUnits[token]
+		if !ok {
+			return nil, fmt.Errorf("calver format %q: unknown token %q (expected YYYY, YY, 0Y, MM, 0M, DD, 0D or MICRO)", format, token)
+		}
+		if (i == 0 && next != 1) || next <= unit {
+			return nil, fmt.Errorf("calver format %q must go from year to day", format)
+		}
+		unit = next
+	}
+
+	return &CalVer{Format: format, tokens: tokens}, nil
+}
+
+// Next returns the version released on date after current. With MICRO, a
+// release on the same date parts as current increments its MICRO and any
+// other release starts at 0. Without it, there can only be one release per
+// date, and Next fails if current already has today's version.
+func (c *CalVer) Next(current *semver.Version, date time.Time) (*semver.Version, error) {
+	parts := make([]string, len(c.tokens))
+	sameDate := true
+	for i, token := range c.tokens {
+		if token == "MICRO" {
+			micro := int64(0)
+			if sameDate {
+				micro = versionPart(current, i) + 1
+			}
+			parts[i] = strconv.FormatInt(micro, 10)
+			continue
+		}
+		value := calverValue(token, date)
+		sameDate = sameDate && int64(value) == versionPart(current, i)
+		if strings.HasPrefix(token, "0") {
+			parts[i] = fmt.Sprintf("%02d", value)
+		} else {
+			parts[i] = strconv.Itoa(value)
+		}
+	}
+
+	tag := strings.Join(parts, ".")
+	next, err := semver.NewVersion(tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse calendar version %s: %w", tag, err)
+	}
+	if !next.GreaterThan(current) {
+		return nil, fmt.Errorf("calendar version %s for %s isn't newer than %s", tag, date.Format("2006-01-02"), current.Original())
+	}
+	return next, nil
+}
+
+func calverValue(token string, date time.Time) int {
+	switch token {
+	case "YYYY":
+		return date.Year()
+	case "YY", "0Y":
+		return date.Year() - 2000
+	case "MM", "0M":
+		return int(date.Month())
+	}
+	return date.Day()
+}
+
+// versionPart returns v's major, minor or patch number for i of 0, 1 or 2.
+func versionPart(v *semver.Version, i int) int64 {
+	switch i {
+	case 0:
+		return v.Major()
+	case 1:
+		return v.Minor()
+	}
+	return v.Patch()
+}
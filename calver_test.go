@@ -0,0 +1,124 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver"
+)
+
+func TestParseCalVer(t *testing.T) {
+	tests := []struct {
+		format      string
+		expectError bool
+	}{
+		{format: "YYYY.MM.MICRO"},
+		{format: "YY.0M.DD"},
+		{format: "YYYY.0M"},
+		{format: "0Y.MICRO"},
+		{format: "MM.YYYY.MICRO", expectError: true},
+		{format: "YYYY.DD.MM", expectError: true},
+		{format: "YYYY.MICRO.MM", expectError: true},
+		{format: "MICRO", expectError: true},
+		{format: "YYYY.WW.MICRO", expectError: true},
+		{format: "YYYY.MM.DD.MICRO", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			_, err := ParseCalVer(tt.format)
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Expected no error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestCalVerNext(t *testing.T) {
+	date := time.Date(2026, time.October, 5, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		format      string
+		current     string
+		expected    string
+		expectError bool
+	}{
+		{name: "first release", format: "YYYY.MM.MICRO", current: "0.0.0", expected: "2026.10.0"},
+		{name: "same month", format: "YYYY.MM.MICRO", current: "2026.10.0", expected: "2026.10.1"},
+		{name: "new month", format: "YYYY.MM.MICRO", current: "2026.9.4", expected: "2026.10.0"},
+		{name: "after a hotfix", format: "YYYY.MM.MICRO", current: "2026.10.1+fix", expected: "2026.10.2"},
+		{name: "switch from semver", format: "YYYY.MM.MICRO", current: "v1.4.2", expected: "2026.10.0"},
+		{name: "zero-padded", format: "YY.0M.0D", current: "26.09.30", expected: "26.10.05"},
+		{name: "short year", format: "0Y.MM", current: "0.0.0", expected: "26.10"},
+		{name: "already released today", format: "YY.0M.0D", current: "26.10.05", expectError: true},
+		{name: "newer than today", format: "YYYY.MM.MICRO", current: "2027.1.0", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calver, err := ParseCalVer(tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			current, err := ParseVersion(tt.current)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			next, err := calver.Next(current, date)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got %s", next.Original())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if next.Original() != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, next.Original())
+			}
+		})
+	}
+}
+
+func TestVersionSchemeFormatVersion(t *testing.T) {
+	calver, err := ParseCalVer("YY.0M.MICRO")
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheme := VersionScheme{CalVer: calver}
+
+	padded, _ := ParseVersion("26.01.3")
+	if got := scheme.FormatVersion(padded); got != "26.01.3" {
+		t.Errorf("Expected the calendar tag to keep its padding, got %s", got)
+	}
+	if got := scheme.DisplayVersion(padded); got != "26.01.3" {
+		t.Errorf("Expected 26.01.3 to be displayed as is, got %s", got)
+	}
+
+	// Releases from before the switch are still parsed
+	old, err := scheme.ParseTag("v1.4.2")
+	if err != nil {
+		t.Fatalf("Expected semver tags to parse, got: %v", err)
+	}
+	if got := scheme.DisplayVersion(old); got != "1.4.2" {
+		t.Errorf("Expected 1.4.2, got %s", got)
+	}
+
+	hotfix, err := CreateHotfixVersion(padded, "fix1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := scheme.FormatVersion(hotfix); got != "26.01.3+fix1" {
+		t.Errorf("Expected the hotfix tag to keep the padding, got %s", got)
+	}
+
+	if got := (VersionScheme{}).FormatVersion(semver.MustParse("1.2.3")); got != "v1.2.3" {
+		t.Errorf("Expected semver tags to be v-prefixed, got %s", got)
+	}
+}
@@ -1,19 +1,36 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
+
+	"github.com/spf13/viper"
 )
 
+// Entry is a pull request in the changelog, or with SHA set, a commit made
+// without one, whose Ref is then its short SHA.
 type Entry struct {
 	Number      int
+	SHA         string
+	Ref         string // forge-specific link text such as "#12" or "!12"
 	Date        string
 	Author      string
 	Title       string
 	Description string
 	Tickets     []string
+	Labels      []string
+}
+
+// DisplayRef returns the entry's Ref, or "#" and its number for entries made
+// without one.
+func (e Entry) DisplayRef() string {
+	if e.Ref == "" {
+		return fmt.Sprintf("#%d", e.Number)
+	}
+	return e.Ref
 }
 
 type Generator struct {
@@ -44,6 +61,7 @@ func (g *Generator) ExtractTickets(text string) []string {
 func ParsePRNumber(commitMessage string) (int, error) {
 	patterns := []string{
 		`\bpull request #(\d+)\b`,
+		`\bmerge request [\w./-]*!(\d+)\b`,
 		`\(#(\d+)\)`,
 		`(?:^|\s)#(\d+)\b`,
 	}
@@ -81,6 +99,11 @@ func normalizeTicket(ticket string) string {
 	return normalized
 }
 
+// jiraTicketURL links ticket in the JIRA instance of organization orgId.
+func jiraTicketURL(orgId, ticket string) string {
+	return fmt.Sprintf("https://%s.atlassian.net/browse/%s", orgId, normalizeTicket(ticket))
+}
+
 func BuildEntriesTableString(entries []Entry, jiraEnabled bool, jiraOrgId string) string {
 	var builder strings.Builder
 
@@ -103,8 +126,8 @@ func BuildEntriesTableString(entries []Entry, jiraEnabled bool, jiraOrgId string
 			titleCell = fmt.Sprintf("<details><summary>%s</summary><br>%s</details>", escapedTitle, escapedDescription)
 		}
 
-		line := fmt.Sprintf("| #%d | %s | %s | %s |",
-			entry.Number,
+		line := fmt.Sprintf("| %s | %s | %s | %s |",
+			entry.DisplayRef(),
 			escapeMarkdownTable(entry.Author),
 			titleCell,
 			entry.Date)
@@ -113,8 +136,7 @@ func BuildEntriesTableString(entries []Entry, jiraEnabled bool, jiraOrgId string
 			var ticketLinks []string
 			for _, ticket := range entry.Tickets {
 				normalizedTicket := normalizeTicket(ticket)
-				url := fmt.Sprintf("https://%s.atlassian.net/browse/%s", jiraOrgId, normalizedTicket)
-				ticketLinks = append(ticketLinks, fmt.Sprintf("[%s](%s)", normalizedTicket, url))
+				ticketLinks = append(ticketLinks, fmt.Sprintf("[%s](%s)", normalizedTicket, jiraTicketURL(jiraOrgId, ticket)))
 			}
 			line += fmt.Sprintf(" %s |", strings.Join(ticketLinks, ", "))
 		}
@@ -125,6 +147,239 @@ func BuildEntriesTableString(entries []Entry, jiraEnabled bool, jiraOrgId string
 	return builder.String()
 }
 
+// Categories sorts changelog entries into sections, each with its own table.
+// An entry goes in the first category it matches, and entries matching none
+// go in a final Other section. Entries with one of ExcludeLabels, or by one of
+// ExcludeAuthors, are left out of the notes.
+type Categories struct {
+	Sections       []Category
+	Other          string
+	ExcludeLabels  []string
+	ExcludeAuthors []string
+}
+
+// Category is a section of the release notes listing the entries with one of
+// Labels ("*" matches every entry) or whose title starts with one of
+// Prefixes, e.g. "feat" for "feat: ..." and "feat(api)!: ...", except those
+// with one of ExcludeLabels. Labels match case-insensitively.
+type Category struct {
+	Title         string
+	Labels        []string
+	Prefixes      []string
+	ExcludeLabels []string
+}
+
+// EntrySection is a titled group of changelog entries.
+type EntrySection struct {
+	Title   string
+	Entries []Entry
+}
+
+// Group sorts entries into the categories, in category order and with the
+// Other section last, leaving out excluded entries and empty sections.
+func (c *Categories) Group(entries []Entry) []EntrySection {
+	grouped := make([][]Entry, len(c.Sections)+1)
+	for _, entry := range entries {
+		if hasAnyLabel(entry.Labels, c.ExcludeLabels) || containsFold(c.ExcludeAuthors, entry.Author) {
+			continue
+		}
+		i := 0
+		for ; i < len(c.Sections); i++ {
+			if c.Sections[i].matches(entry) {
+				break
+			}
+		}
+		grouped[i] = append(grouped[i], entry)
+	}
+
+	var sections []EntrySection
+	for i, group := range grouped {
+		if len(group) == 0 {
+			continue
+		}
+		title := c.Other
+		if i < len(c.Sections) {
+			title = c.Sections[i].Title
+		}
+		sections = append(sections, EntrySection{Title: title, Entries: group})
+	}
+	return sections
+}
+
+func (c Category) matches(entry Entry) bool {
+	if hasAnyLabel(entry.Labels, c.ExcludeLabels) {
+		return false
+	}
+	if containsFold(c.Labels, "*") || hasAnyLabel(entry.Labels, c.Labels) {
+		return true
+	}
+	title := strings.ToLower(entry.Title)
+	for _, prefix := range c.Prefixes {
+		prefix = strings.ToLower(prefix)
+		if !strings.HasPrefix(title, prefix) {
+			continue
+		}
+		// "feat" matches "feat:" and "feat(api):" but not "feature"
+		rest := title[len(prefix):]
+		if rest == "" || !isWordChar(rest[0]) || !isWordChar(prefix[len(prefix)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAnyLabel(labels, wanted []string) bool {
+	for _, label := range labels {
+		if containsFold(wanted, label) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c == '-' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// ParseReleaseConfig reads the changelog categories of a GitHub
+// .github/release.yml, so the notes are grouped like the release notes GitHub
+// generates, with uncategorized pull requests under "Other Changes".
+func ParseReleaseConfig(data []byte) (*Categories, error) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("failed to parse release.yml: %w", err)
+	}
+
+	type exclusions struct {
+		Labels  []string `mapstructure:"labels"`
+		Authors []string `mapstructure:"authors"`
+	}
+	var changelog struct {
+		Exclude    exclusions `mapstructure:"exclude"`
+		Categories []struct {
+			Title   string     `mapstructure:"title"`
+			Labels  []string   `mapstructure:"labels"`
+			Exclude exclusions `mapstructure:"exclude"`
+		} `mapstructure:"categories"`
+	}
+	if err := v.UnmarshalKey("changelog", &changelog); err != nil {
+		return nil, fmt.Errorf("failed to parse release.yml: %w", err)
+	}
+
+	categories := &Categories{
+		Other:          "Other Changes",
+		ExcludeLabels:  changelog.Exclude.Labels,
+		ExcludeAuthors: changelog.Exclude.Authors,
+	}
+	for _, category := range changelog.Categories {
+		if category.Title == "" {
+			return nil, fmt.Errorf("failed to parse release.yml: a category has no title")
+		}
+		categories.Sections = append(categories.Sections, Category{
+			Title:         category.Title,
+			Labels:        category.Labels,
+			ExcludeLabels: category.Exclude.Labels,
+		})
+	}
+	return categories, nil
+}
+
+// BuildChangelogString renders entries as one table, or with categories, as
+// a table per section under a heading of its own. Commits made without a
+// pull request follow in a Direct commits table.
+func BuildChangelogString(entries []Entry, categories *Categories, jiraEnabled bool, jiraOrgId string) string {
+	prs, commits := splitDirectCommits(entries)
+	return buildPullRequestsString(prs, categories, jiraEnabled, jiraOrgId) +
+		BuildCommitsTableString(commits, jiraEnabled, jiraOrgId)
+}
+
+func buildPullRequestsString(entries []Entry, categories *Categories, jiraEnabled bool, jiraOrgId string) string {
+	if len(entries) == 0 {
+		return ""
+	}
+	if categories == nil {
+		return BuildEntriesTableString(entries, jiraEnabled, jiraOrgId)
+	}
+
+	sections := categories.Group(entries)
+	if len(categories.Sections) == 0 {
+		// Only exclusions, e.g. a release.yml without categories
+		if len(sections) == 0 {
+			return ""
+		}
+		return BuildEntriesTableString(sections[0].Entries, jiraEnabled, jiraOrgId)
+	}
+
+	var builder strings.Builder
+	for _, section := range sections {
+		builder.WriteString("### " + section.Title + "\n\n")
+		builder.WriteString(BuildEntriesTableString(section.Entries, jiraEnabled, jiraOrgId))
+	}
+	return builder.String()
+}
+
+// DirectCommitsTitle heads the commits made without a pull request.
+const DirectCommitsTitle = "Direct commits"
+
+// BuildCommitsTableString renders commits made without a pull request as a
+// Direct commits table, or nothing when there are none.
+func BuildCommitsTableString(entries []Entry, jiraEnabled bool, jiraOrgId string) string {
+	if len(entries) == 0 {
+		return ""
+	}
+
+	var builder strings.Builder
+	builder.WriteString("### " + DirectCommitsTitle + "\n\n")
+	header := "| Commit | Author | Subject | Date |"
+	separator := "|--------|--------|---------|------|"
+	if jiraEnabled {
+		header += " Ticket # |"
+		separator += "----------|"
+	}
+	builder.WriteString(header + "\n")
+	builder.WriteString(separator + "\n")
+
+	for _, entry := range entries {
+		line := fmt.Sprintf("| `%s` | %s | %s | %s |",
+			entry.Ref,
+			escapeMarkdownTable(entry.Author),
+			escapeMarkdownTable(entry.Title),
+			entry.Date)
+		if jiraEnabled {
+			var ticketLinks []string
+			for _, ticket := range entry.Tickets {
+				ticketLinks = append(ticketLinks, fmt.Sprintf("[%s](%s)", normalizeTicket(ticket), jiraTicketURL(jiraOrgId, ticket)))
+			}
+			line += fmt.Sprintf(" %s |", strings.Join(ticketLinks, ", "))
+		}
+		builder.WriteString(line + "\n")
+	}
+	builder.WriteString("\n")
+	return builder.String()
+}
+
+// splitDirectCommits separates the pull requests in entries from the commits
+// made without one, keeping their order.
+func splitDirectCommits(entries []Entry) (prs, commits []Entry) {
+	for _, entry := range entries {
+		if entry.SHA != "" {
+			commits = append(commits, entry)
+		} else {
+			prs = append(prs, entry)
+		}
+	}
+	return prs, commits
+}
 
 type CrossLink struct {
 	Name    string
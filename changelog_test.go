@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strconv"
 	"strings"
 	"testing"
 )
@@ -265,6 +266,12 @@ func TestParsePRNumber(t *testing.T) {
 			expected:      789,
 			expectError:   false,
 		},
+		{
+			name:          "gitlab merge request",
+			commitMessage: "Merge branch 'login' into 'main'\n\nFix login\n\nSee merge request mobile/ios-app!42",
+			expected:      42,
+			expectError:   false,
+		},
 		{
 			name:          "no PR number",
 			commitMessage: "Regular commit message",
@@ -364,6 +371,17 @@ func TestBuildEntriesTableString(t *testing.T) {
 	}
 }
 
+func TestBuildEntriesTableStringUsesForgeRef(t *testing.T) {
+	entries := []Entry{
+		{Number: 12, Ref: "!12", Date: "2023-01-01", Author: "ann", Title: "Fix login"},
+	}
+
+	result := BuildEntriesTableString(entries, false, "")
+	if !strings.Contains(result, "| !12 | ann | Fix login | 2023-01-01 |") {
+		t.Errorf("Expected the merge request to be referenced as !12, got:\n%s", result)
+	}
+}
+
 func TestBuildEntriesTableStringJiraDisabled(t *testing.T) {
 	entries := []Entry{
 		{
@@ -544,3 +562,155 @@ func TestBuildEntriesTableStringWithNormalizedTickets(t *testing.T) {
 	}
 }
 
+
+func TestCategoriesGroup(t *testing.T) {
+	categories := &Categories{
+		Sections: []Category{
+			{Title: "Breaking", Labels: []string{"breaking-change"}},
+			{Title: "Features", Labels: []string{"enhancement"}, Prefixes: []string{"feat"}},
+			{Title: "Fixes", Prefixes: []string{"fix"}, ExcludeLabels: []string{"internal"}},
+		},
+		Other:          "Other",
+		ExcludeLabels:  []string{"skip-changelog"},
+		ExcludeAuthors: []string{"dependabot"},
+	}
+	entries := []Entry{
+		{Number: 1, Title: "feat(api): add retries"},
+		{Number: 2, Title: "Add dark mode", Labels: []string{"Enhancement", "breaking-change"}},
+		{Number: 3, Title: "fix: crash on start"},
+		{Number: 4, Title: "Feature flags cleanup"},
+		{Number: 5, Title: "fix: typo", Labels: []string{"skip-changelog"}},
+		{Number: 6, Title: "Bump deps", Author: "dependabot"},
+		{Number: 7, Title: "fix: test flake", Labels: []string{"internal"}},
+		{Number: 8, Title: "feat!: drop v1 API"},
+	}
+
+	var got []string
+	for _, section := range categories.Group(entries) {
+		var numbers []string
+		for _, entry := range section.Entries {
+			numbers = append(numbers, strconv.Itoa(entry.Number))
+		}
+		got = append(got, section.Title+":"+strings.Join(numbers, ","))
+	}
+	want := "Breaking:2 Features:1,8 Fixes:3 Other:4,7"
+	if strings.Join(got, " ") != want {
+		t.Errorf("Expected %s, got %s", want, strings.Join(got, " "))
+	}
+
+	wildcard := &Categories{Sections: []Category{{Title: "Changes", Labels: []string{"*"}}}, Other: "Other"}
+	sections := wildcard.Group(entries)
+	if len(sections) != 1 || len(sections[0].Entries) != len(entries) {
+		t.Errorf("Expected \"*\" to put every entry in one section, got %v", sections)
+	}
+}
+
+func TestParseReleaseConfig(t *testing.T) {
+	categories, err := ParseReleaseConfig([]byte(`
+changelog:
+  exclude:
+    labels:
+      - ignore-for-release
+    authors:
+      - octocat
+  categories:
+    - title: Breaking Changes 🛠
+      labels:
+        - Semver-Major
+        - breaking-change
+    - title: Exciting New Features 🎉
+      labels:
+        - Semver-Minor
+        - enhancement
+      exclude:
+        labels:
+          - experimental
+    - title: Other Changes
+      labels:
+        - "*"
+`))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(categories.Sections) != 3 {
+		t.Fatalf("Expected 3 categories, got %d", len(categories.Sections))
+	}
+	features := categories.Sections[1]
+	if features.Title != "Exciting New Features 🎉" || strings.Join(features.Labels, ",") != "Semver-Minor,enhancement" ||
+		strings.Join(features.ExcludeLabels, ",") != "experimental" {
+		t.Errorf("Unexpected features category %+v", features)
+	}
+	if strings.Join(categories.ExcludeLabels, ",") != "ignore-for-release" || strings.Join(categories.ExcludeAuthors, ",") != "octocat" {
+		t.Errorf("Expected the exclusions to be read, got %v and %v", categories.ExcludeLabels, categories.ExcludeAuthors)
+	}
+
+	if _, err := ParseReleaseConfig([]byte("changelog:\n  categories:\n    - labels: [bug]\n")); err == nil {
+		t.Error("Expected a category without a title to be rejected")
+	}
+	if _, err := ParseReleaseConfig([]byte("changelog: [")); err == nil {
+		t.Error("Expected invalid YAML to be rejected")
+	}
+}
+
+func TestBuildChangelogString(t *testing.T) {
+	entries := []Entry{
+		{Number: 1, Date: "2023-01-01", Author: "ann", Title: "feat: add retries"},
+		{Number: 2, Date: "2023-01-02", Author: "bob", Title: "Update docs", Labels: []string{"skip"}},
+		{Number: 3, Date: "2023-01-03", Author: "cy", Title: "Refactor client"},
+	}
+
+	if got, want := BuildChangelogString(entries, nil, false, ""), BuildEntriesTableString(entries, false, ""); got != want {
+		t.Errorf("Expected a single table without categories, got:\n%s", got)
+	}
+
+	excludeOnly := &Categories{Other: "Other Changes", ExcludeLabels: []string{"skip"}}
+	if got := BuildChangelogString(entries, excludeOnly, false, ""); strings.Contains(got, "###") || strings.Contains(got, "Update docs") {
+		t.Errorf("Expected a single table without the excluded entry, got:\n%s", got)
+	}
+
+	categories := &Categories{
+		Sections: []Category{
+			{Title: "Features", Prefixes: []string{"feat"}},
+			{Title: "Fixes", Prefixes: []string{"fix"}},
+		},
+		Other: "Other",
+	}
+	result := BuildChangelogString(entries, categories, false, "")
+	features := strings.Index(result, "### Features\n\n| PR # |")
+	other := strings.Index(result, "### Other\n\n| PR # |")
+	if features < 0 || other < features {
+		t.Fatalf("Expected a Features table followed by an Other table, got:\n%s", result)
+	}
+	if strings.Contains(result, "### Fixes") {
+		t.Error("Expected the empty Fixes section to be left out")
+	}
+	if !strings.Contains(result[other:], "| #3 | cy | Refactor client | 2023-01-03 |") {
+		t.Errorf("Expected #3 under Other, got:\n%s", result)
+	}
+}
+
+func TestBuildChangelogStringWithDirectCommits(t *testing.T) {
+	entries := []Entry{
+		{Number: 1, Date: "2023-01-01", Author: "ann", Title: "feat: add retries"},
+		{SHA: "abc1234def", Ref: "abc1234", Date: "2023-01-02", Author: "bob", Title: "Hotfix | config", Tickets: []string{"ops 9"}},
+	}
+
+	result := BuildChangelogString(entries, nil, true, "my-org")
+	table := strings.Index(result, "| #1 | ann | feat: add retries |")
+	commits := strings.Index(result, "### Direct commits\n\n| Commit | Author | Subject | Date | Ticket # |")
+	if table < 0 || commits < table {
+		t.Fatalf("Expected the pull requests followed by a Direct commits table, got:\n%s", result)
+	}
+	if !strings.Contains(result, "| `abc1234` | bob | Hotfix \\| config | 2023-01-02 | [OPS-9](https://my-org.atlassian.net/browse/OPS-9) |") {
+		t.Errorf("Expected the direct commit row, got:\n%s", result)
+	}
+	if strings.Count(result, "abc1234") != 1 {
+		t.Errorf("Expected the direct commit to only be listed once, got:\n%s", result)
+	}
+
+	categories := &Categories{Sections: []Category{{Title: "Everything", Labels: []string{"*"}}}, Other: "Other"}
+	if result := BuildChangelogString(entries[1:], categories, false, ""); strings.Contains(result, "Everything") || !strings.HasPrefix(result, "### Direct commits") {
+		t.Errorf("Expected direct commits to stay out of the categories, got:\n%s", result)
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultChangelogMessage is the commit message of changelog_file updates
+// when changelog_message isn't set.
+const DefaultChangelogMessage = "Update changelog for {version}"
+
+// changelogFileHeader starts a changelog file that doesn't exist yet.
+const changelogFileHeader = `# Changelog
+
+All notable changes to this project are documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/).
+`
+
+// BuildChangelogFileSection renders the Keep a Changelog section of version,
+// released on date: a "## [version] - date" heading and a bullet per entry,
+// grouped under a "### " heading per section when categories are set, with
+// commits made without a pull request under a last Direct commits heading.
+func BuildChangelogFileSection(version string, date time.Time, entries []Entry, categories *Categories, prURL func(number int) string) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("## [%s] - %s\n\n", version, date.Format("2006-01-02")))
+
+	writeEntries := func(entries []Entry) {
+		for _, entry := range entries {
+			ref := entry.Ref
+			if ref == "" {
+				ref = fmt.Sprintf("#%d", entry.Number)
+			}
+			title := strings.Join(strings.Fields(entry.Title), " ")
+			builder.WriteString(fmt.Sprintf("- %s ([%s](%s))\n", title, ref, prURL(entry.Number)))
+		}
+		builder.WriteString("\n")
+	}
+
+	entries, commits := splitDirectCommits(entries)
+	switch {
+	case len(entries) == 0:
+	case categories == nil:
+		writeEntries(entries)
+	default:
+		for _, section := range categories.Group(entries) {
+			if len(categories.Sections) > 0 {
+				builder.WriteString("### " + section.Title + "\n\n")
+			}
+			writeEntries(section.Entries)
+		}
+	}
+
+	if len(commits) > 0 {
+		builder.WriteString("### " + DirectCommitsTitle + "\n\n")
+		for _, commit := range commits {
+			builder.WriteString(fmt.Sprintf("- %s (`%s`)\n", strings.Join(strings.Fields(commit.Title), " "), commit.Ref))
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+// changelogSectionHeading returns the start of section's heading, e.g.
+// "## [1.3.0]", which identifies the release whatever its date.
+func changelogSectionHeading(section string) string {
+	heading, _, _ := strings.Cut(section, "\n")
+	if end := strings.Index(heading, "]"); end >= 0 {
+		return heading[:end+1]
+	}
+	return heading
+}
+
+// hasChangelogSection reports whether content already has a section for the
+// release of section, as a release interrupted after its changelog commit
+// leaves behind.
+func hasChangelogSection(content, section string) bool {
+	heading := changelogSectionHeading(section)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == heading || strings.HasPrefix(line, heading+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// insertChangelogSection adds section to the changelog content, above the
+// newest release but below the introduction and any Unreleased section. It
+// returns the new content and, for diffs, the line section was inserted at.
+func insertChangelogSection(content, section string) (string, int) {
+	if strings.TrimSpace(content) == "" {
+		content = changelogFileHeader
+	}
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+
+	at := len(lines)
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "## ") {
+			continue
+		}
+		heading := strings.ToLower(strings.Trim(strings.TrimSpace(line[3:]), "[]"))
+		if !strings.HasPrefix(heading, "unreleased") {
+			at = i
+			break
+		}
+	}
+
+	inserted := strings.Split(strings.TrimRight(section, "\n"), "\n")
+	if at < len(lines) {
+		inserted = append(inserted, "")
+	} else if lines[at-1] != "" {
+		inserted = append([]string{""}, inserted...)
+	}
+
+	updated := append(append(append([]string{}, lines[:at]...), inserted...), lines[at:]...)
+	return strings.Join(updated, "\n") + "\n", at
+}
+
+// changelogDiff renders the insertion of section into the changelog file at
+// path (with content old) as a unified diff, for dry runs.
+func changelogDiff(path, old, section string) string {
+	updated, at := insertChangelogSection(old, section)
+	newLines := strings.Split(strings.TrimSuffix(updated, "\n"), "\n")
+
+	var builder strings.Builder
+	if strings.TrimSpace(old) == "" {
+		builder.WriteString("--- /dev/null\n")
+		builder.WriteString("+++ b/" + path + "\n")
+		builder.WriteString(fmt.Sprintf("@@ -0,0 +1,%d @@\n", len(newLines)))
+		for _, line := range newLines {
+			builder.WriteString("+" + line + "\n")
+		}
+		return builder.String()
+	}
+
+	const context = 3
+	oldLines := strings.Split(strings.TrimSuffix(old, "\n"), "\n")
+	added := len(newLines) - len(oldLines)
+	start := at - context
+	if start < 0 {
+		start = 0
+	}
+	end := at + context
+	if end > len(oldLines) {
+		end = len(oldLines)
+	}
+
+	builder.WriteString("--- a/" + path + "\n")
+	builder.WriteString("+++ b/" + path + "\n")
+	builder.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", start+1, end-start, start+1, end-start+added))
+	for _, line := range oldLines[start:at] {
+		builder.WriteString(" " + line + "\n")
+	}
+	for _, line := range newLines[at : at+added] {
+		builder.WriteString("+" + line + "\n")
+	}
+	for _, line := range oldLines[at:end] {
+		builder.WriteString(" " + line + "\n")
+	}
+	return builder.String()
+}
+
+// PreviewChangelogSection returns the diff adding section to the changelog
+// file at path on branch in the checkout at dir, as last fetched, or "" when
+// the file already has a section for the release.
+func PreviewChangelogSection(dir, branch, path, section string) (string, error) {
+	old, err := LocalFile(dir, branch, path)
+	if err != nil {
+		return "", err
+	}
+	if hasChangelogSection(string(old), section) {
+		return "", nil
+	}
+	return changelogDiff(path, string(old), section), nil
+}
+
+// CommitChangelogSection adds section to the changelog file at path on the
+// remote's tip of branch, commits it with message and pushes the commit to
+// branch from the checkout at dir, returning its SHA. When the file already
+// has a section for the release, the commit that added it is returned
+// instead, with reused set. Like GenerateAssets, it needs a clean working
+// tree and restores the previous checkout afterwards.
+func CommitChangelogSection(dir, branch, path, section, message string) (sha string, reused bool, err error) {
+	remote := defaultRemote(dir)
+	if remote == "" {
+		return "", false, fmt.Errorf("%q has no remote to push %s to", dir, path)
+	}
+	if heads, err := runGit(dir, "ls-remote", "--heads", remote, branch); err != nil || strings.TrimSpace(heads) == "" {
+		return "", false, fmt.Errorf("%s is not a branch of %s in %q, so %s can't be committed to it", branch, remote, dir, path)
+	}
+
+	original, err := prepareRepo(dir, branch)
+	if err != nil {
+		return "", false, err
+	}
+	defer func() {
+		// Drop a change that didn't make it into a commit, so the checkout
+		// can be restored
+		if err != nil {
+			runGit(dir, "reset", "-q", "--hard")
+		}
+		if restoreErr := restoreRepo(dir, original); restoreErr != nil && err == nil {
+			err = restoreErr
+		}
+	}()
+
+	file := filepath.Join(dir, path)
+	old, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return "", false, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	if hasChangelogSection(string(old), section) {
+		heading := changelogSectionHeading(section)
+		out, err := runGit(dir, "log", "-1", "--format=%H", "-S", heading, "HEAD", "--", path)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to find the commit adding %s to %s in %q: %w", heading, path, dir, err)
+		}
+		if strings.TrimSpace(out) == "" {
+			return "", false, fmt.Errorf("no commit adds %s to %s in %q", heading, path, dir)
+		}
+		return strings.TrimSpace(out), true, nil
+	}
+	updated, _ := insertChangelogSection(string(old), section)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return "", false, fmt.Errorf("failed to create the directory of %s: %w", file, err)
+	}
+	if err := os.WriteFile(file, []byte(updated), 0644); err != nil {
+		return "", false, fmt.Errorf("failed to write %s: %w", file, err)
+	}
+
+	if _, err := runGit(dir, "add", "--", path); err != nil {
+		return "", false, fmt.Errorf("failed to stage %s in %q: %w", path, dir, err)
+	}
+	if _, err := runGit(dir, "commit", "-q", "-m", message); err != nil {
+		return "", false, fmt.Errorf("failed to commit %s in %q: %w", path, dir, err)
+	}
+	if _, err := runGit(dir, "push", remote, "HEAD:refs/heads/"+branch); err != nil {
+		return "", false, fmt.Errorf("failed to push %s to %s in %q: %w", path, branch, dir, err)
+	}
+
+	out, err := runGit(dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read the commit of %s in %q: %w", path, dir, err)
+	}
+	return strings.TrimSpace(out), false, nil
+}
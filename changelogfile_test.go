@@ -0,0 +1,168 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildChangelogFileSection(t *testing.T) {
+	date := time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{Number: 12, Ref: "#12", Title: "feat: add retries"},
+		{Number: 13, Ref: "#13", Title: "Update\ndocs"},
+	}
+	prURL := func(number int) string { return "https://example.com/pull/" + strconv.Itoa(number) }
+
+	want := "## [1.3.0] - 2026-10-16\n\n" +
+		"- feat: add retries ([#12](https://example.com/pull/12))\n" +
+		"- Update docs ([#13](https://example.com/pull/13))\n\n"
+	if got := BuildChangelogFileSection("1.3.0", date, entries, nil, prURL); got != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, got)
+	}
+
+	categories := &Categories{Sections: []Category{{Title: "Added", Prefixes: []string{"feat"}}}, Other: "Changed"}
+	want = "## [1.3.0] - 2026-10-16\n\n" +
+		"### Added\n\n- feat: add retries ([#12](https://example.com/pull/12))\n\n" +
+		"### Changed\n\n- Update docs ([#13](https://example.com/pull/13))\n\n"
+	if got := BuildChangelogFileSection("1.3.0", date, entries, categories, prURL); got != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, got)
+	}
+
+	direct := Entry{SHA: "abc1234def", Ref: "abc1234", Title: "Hotfix config"}
+	want = "## [1.3.1] - 2026-10-16\n\n### Direct commits\n\n- Hotfix config (`abc1234`)\n\n"
+	if got := BuildChangelogFileSection("1.3.1", date, []Entry{direct}, categories, prURL); got != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestInsertChangelogSection(t *testing.T) {
+	section := "## [1.1.0] - 2026-10-16\n\n- Fix login ([#2](url))\n\n"
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "new file",
+			content: "",
+			want:    changelogFileHeader + "\n## [1.1.0] - 2026-10-16\n\n- Fix login ([#2](url))\n",
+		},
+		{
+			name:    "above the last release",
+			content: "# Changelog\n\n## [1.0.0] - 2026-01-01\n\n- Initial release\n",
+			want:    "# Changelog\n\n## [1.1.0] - 2026-10-16\n\n- Fix login ([#2](url))\n\n## [1.0.0] - 2026-01-01\n\n- Initial release\n",
+		},
+		{
+			name:    "below unreleased",
+			content: "# Changelog\n\n## [Unreleased]\n\n- Work in progress\n\n## [1.0.0] - 2026-01-01\n",
+			want:    "# Changelog\n\n## [Unreleased]\n\n- Work in progress\n\n## [1.1.0] - 2026-10-16\n\n- Fix login ([#2](url))\n\n## [1.0.0] - 2026-01-01\n",
+		},
+		{
+			name:    "no release yet",
+			content: "# Changelog\nIntroduction",
+			want:    "# Changelog\nIntroduction\n\n## [1.1.0] - 2026-10-16\n\n- Fix login ([#2](url))\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := insertChangelogSection(tt.content, section); got != tt.want {
+				t.Errorf("Expected:\n%q\ngot:\n%q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestHasChangelogSection(t *testing.T) {
+	content := "# Changelog\n\n## [1.1.0-rc.1] - 2026-10-01\n\n## [1.0.0] - 2026-01-01\n"
+	tests := []struct {
+		section string
+		want    bool
+	}{
+		{"## [1.0.0] - 2026-10-16\n\n- Fix login\n", true},
+		{"## [1.1.0] - 2026-10-16\n", false},
+		{"## [1.1.0-rc.1] - 2026-10-16\n", true},
+		{"## [1.0] - 2026-10-16\n", false},
+	}
+	for _, tt := range tests {
+		if got := hasChangelogSection(content, tt.section); got != tt.want {
+			t.Errorf("%q: expected %v, got %v", changelogSectionHeading(tt.section), tt.want, got)
+		}
+	}
+}
+
+func TestChangelogDiff(t *testing.T) {
+	old := "# Changelog\n\n## [1.0.0] - 2026-01-01\n\n- Initial release\n"
+	diff := changelogDiff("CHANGELOG.md", old, "## [1.1.0] - 2026-10-16\n\n- Fix login\n")
+
+	want := "--- a/CHANGELOG.md\n+++ b/CHANGELOG.md\n@@ -1,5 +1,9 @@\n" +
+		" # Changelog\n \n+## [1.1.0] - 2026-10-16\n+\n+- Fix login\n+\n ## [1.0.0] - 2026-01-01\n \n - Initial release\n"
+	if diff != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, diff)
+	}
+
+	if diff := changelogDiff("CHANGELOG.md", "", "## [1.1.0] - 2026-10-16\n"); !strings.HasPrefix(diff, "--- /dev/null\n+++ b/CHANGELOG.md\n@@ -0,0 +1,") {
+		t.Errorf("Expected a diff creating the file, got:\n%s", diff)
+	}
+}
+
+func TestCommitChangelogSection(t *testing.T) {
+	remote := t.TempDir()
+	gitIn(t, remote, "init", "-q", "--bare")
+
+	dir, _ := initTestRepo(t)
+	gitIn(t, dir, "remote", "add", "origin", remote)
+	gitIn(t, dir, "push", "-q", "origin", "HEAD:refs/heads/main")
+	original := gitIn(t, dir, "rev-parse", "--abbrev-ref", "HEAD")
+
+	sha, reused, err := CommitChangelogSection(dir, "main", "docs/CHANGELOG.md", "## [1.0.0] - 2026-10-16\n\n- First\n", "Update changelog for v1.0.0")
+	if err != nil || reused {
+		t.Fatalf("Expected a new commit, got reused %v and error: %v", reused, err)
+	}
+
+	if got := gitIn(t, remote, "rev-parse", "main"); got != sha {
+		t.Errorf("Expected main to be pushed to %s, got %s", sha, got)
+	}
+	if got := gitIn(t, remote, "log", "-1", "--format=%s", "main"); got != "Update changelog for v1.0.0" {
+		t.Errorf("Unexpected commit message %q", got)
+	}
+	if got := gitIn(t, remote, "show", "main:docs/CHANGELOG.md"); !strings.Contains(got, "## [1.0.0] - 2026-10-16\n\n- First") {
+		t.Errorf("Expected the section in the pushed changelog, got:\n%s", got)
+	}
+	if got := gitIn(t, dir, "rev-parse", "--abbrev-ref", "HEAD"); got != original {
+		t.Errorf("Expected the checkout to be restored to %s, got %s", original, got)
+	}
+
+	// A retried release, a day later and after another commit, reuses the
+	// commit adding its section
+	gitIn(t, dir, "fetch", "-q", "origin")
+	gitIn(t, dir, "checkout", "-q", "origin/main")
+	gitIn(t, dir, "commit", "-q", "--allow-empty", "-m", "Unrelated")
+	gitIn(t, dir, "push", "-q", "origin", "HEAD:refs/heads/main")
+	gitIn(t, dir, "checkout", "-q", original)
+	tip := gitIn(t, remote, "rev-parse", "main")
+
+	again, reused, err := CommitChangelogSection(dir, "main", "docs/CHANGELOG.md", "## [1.0.0] - 2026-10-17\n\n- First\n", "Update changelog for v1.0.0")
+	if err != nil || !reused || again != sha {
+		t.Errorf("Expected %s to be reused, got %s (reused %v) and error: %v", sha, again, reused, err)
+	}
+	if got := gitIn(t, remote, "rev-parse", "main"); got != tip {
+		t.Errorf("Expected nothing to be pushed, main moved to %s", got)
+	}
+
+	if _, _, err := CommitChangelogSection(dir, "missing", "CHANGELOG.md", "## [1.0.0]\n", "message"); err == nil {
+		t.Error("Expected an error for a branch the remote doesn't have")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := CommitChangelogSection(dir, "main", "CHANGELOG.md", "## [1.1.0]\n", "message"); err == nil {
+		t.Error("Expected an error for a dirty working tree")
+	}
+}
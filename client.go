@@ -3,6 +3,8 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -14,20 +16,159 @@ import (
 
 type Client struct {
 	*github.Client
-	ctx context.Context
+	webURL     *url.URL
+	graphQLURL *url.URL
+}
+
+// ClientOptions describes how to reach a GitHub host. Leaving the URLs empty
+// targets github.com; setting APIURL points every call at a GitHub Enterprise
+// Server (e.g. https://ghe.example.com/api/v3) or a local stand-in server.
+// UploadURL and WebURL are derived from APIURL when left empty. Logger
+// receives rate limit and retry messages; nil uses NewLogger. A non-empty
+// CacheDir enables the on-disk ETag cache for GET requests. When App is
+// configured the client authenticates as that GitHub App installation and
+// Token is ignored. A non-zero Timeout fails any request (including its
+// retries and rate limit waits) that takes longer.
+type ClientOptions struct {
+	Token     string
+	App       GitHubAppConfig
+	APIURL    string
+	UploadURL string
+	WebURL    string
+	CacheDir  string
+	Timeout   time.Duration
+	Logger    *Logger
 }
 
 func NewClient(token string) *Client {
-	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: token},
-	)
-	tc := oauth2.NewClient(ctx, ts)
+	client, _ := NewClientWithOptions(ClientOptions{Token: token})
+	return client
+}
+
+// NewClientWithOptions builds a client for the host described by opts. It
+// fails when one of the configured URLs cannot be parsed or the GitHub App
+// private key cannot be loaded.
+func NewClientWithOptions(opts ClientOptions) (*Client, error) {
+	logger := opts.Logger
+	if logger == nil {
+		logger = NewLogger()
+	}
+
+	apiURL, _ := url.Parse("https://api.github.com/")
+	uploadURL, _ := url.Parse("https://uploads.github.com/")
+	webURL, _ := url.Parse("https://github.com/")
+
+	if opts.APIURL != "" {
+		u, err := parseBaseURL(opts.APIURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid api_url %q: %w", opts.APIURL, err)
+		}
+		apiURL = u
+		uploadURL = deriveUploadURL(u)
+		webURL = deriveWebURL(u)
+	}
+	if opts.UploadURL != "" {
+		u, err := parseBaseURL(opts.UploadURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid upload_url %q: %w", opts.UploadURL, err)
+		}
+		uploadURL = u
+	}
+	if opts.WebURL != "" {
+		u, err := parseBaseURL(opts.WebURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid web_url %q: %w", opts.WebURL, err)
+		}
+		webURL = u
+	}
+
+	var transport http.RoundTripper = newRateLimitTransport(http.DefaultTransport, logger)
+
+	var appSource *appTokenSource
+	if opts.App.Configured() {
+		var err error
+		appSource, err = newAppTokenSource(opts.App, apiURL, &http.Client{Transport: transport, Timeout: opts.Timeout}, logger)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.CacheDir != "" {
+		transport = newCacheTransport(transport, opts.CacheDir, logger)
+	}
+	if appSource != nil {
+		transport = &appTransport{source: appSource, base: transport}
+	} else {
+		transport = &oauth2.Transport{
+			Source: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: opts.Token}),
+			Base:   transport,
+		}
+	}
+	tc := &http.Client{
+		Transport: transport,
+		Timeout:   opts.Timeout,
+	}
+
+	gh := github.NewClient(tc)
+	gh.BaseURL = apiURL
+	gh.UploadURL = uploadURL
 
 	return &Client{
-		Client: github.NewClient(tc),
-		ctx:    ctx,
+		Client:     gh,
+		webURL:     webURL,
+		graphQLURL: graphQLURL(apiURL),
+	}, nil
+}
+
+// parseBaseURL parses an absolute URL and ensures its path ends in a slash,
+// which go-github requires so relative API paths resolve beneath it.
+func parseBaseURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("must be an absolute URL")
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
 	}
+	return u, nil
+}
+
+// deriveUploadURL follows the GitHub Enterprise Server layout, where uploads
+// live at /api/uploads/ next to the /api/v3/ REST root. Any other API URL (such
+// as a stand-in server) is assumed to accept uploads on the same root.
+func deriveUploadURL(apiURL *url.URL) *url.URL {
+	u := *apiURL
+	if strings.HasSuffix(u.Path, "/api/v3/") {
+		u.Path = strings.TrimSuffix(u.Path, "v3/") + "uploads/"
+	}
+	return &u
+}
+
+// deriveWebURL guesses the browser-facing root for an API URL: /api/v3/ is
+// stripped for GitHub Enterprise Server and an "api." host prefix is dropped
+// for github.com-style hosts. Anything else is used unchanged.
+func deriveWebURL(apiURL *url.URL) *url.URL {
+	u := *apiURL
+	switch {
+	case strings.HasSuffix(u.Path, "/api/v3/"):
+		u.Path = strings.TrimSuffix(u.Path, "api/v3/")
+	case strings.HasPrefix(u.Host, "api."):
+		u.Host = strings.TrimPrefix(u.Host, "api.")
+	}
+	return &u
+}
+
+// ReleaseURL returns the browser URL of the release tagged tag in repo.
+func (c *Client) ReleaseURL(repo *Repository, tag string) string {
+	return fmt.Sprintf("%s%s/%s/releases/tag/%s", c.webURL, repo.Owner, repo.Name, tag)
+}
+
+// PullRequestURL returns the browser URL of pull request number in repo.
+func (c *Client) PullRequestURL(repo *Repository, number int) string {
+	return fmt.Sprintf("%s%s/%s/pull/%d", c.webURL, repo.Owner, repo.Name, number)
 }
 
 type Repository struct {
@@ -51,39 +192,170 @@ func (r *Repository) String() string {
 	return fmt.Sprintf("%s/%s", r.Owner, r.Name)
 }
 
-func (c *Client) GetLatestRelease(repo *Repository) (*github.RepositoryRelease, error) {
-	release, _, err := c.Repositories.GetLatestRelease(c.ctx, repo.Owner, repo.Name)
+func (c *Client) GetLatestRelease(ctx context.Context, repo *Repository) (*github.RepositoryRelease, error) {
+	release, _, err := c.Repositories.GetLatestRelease(ctx, repo.Owner, repo.Name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get latest release for %s: %w", repo, err)
 	}
 	return release, nil
 }
 
-func (c *Client) GetReleases(repo *Repository) ([]*github.RepositoryRelease, error) {
-	releases, _, err := c.Repositories.ListReleases(c.ctx, repo.Owner, repo.Name, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get releases for %s: %w", repo, err)
+func (c *Client) GetReleases(ctx context.Context, repo *Repository) ([]*github.RepositoryRelease, error) {
+	opts := &github.ListOptions{
+		Page:    1,
+		PerPage: 100,
 	}
-	return releases, nil
+
+	var allReleases []*github.RepositoryRelease
+
+	for {
+		releases, resp, err := c.Repositories.ListReleases(ctx, repo.Owner, repo.Name, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get releases for %s: %w", repo, err)
+		}
+
+		allReleases = append(allReleases, releases...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return allReleases, nil
 }
 
-func (c *Client) CompareCommits(repo *Repository, base, head string) (*github.CommitsComparison, error) {
-	comparison, _, err := c.Repositories.CompareCommits(c.ctx, repo.Owner, repo.Name, base, head)
-	if err != nil {
-		return nil, fmt.Errorf("failed to compare commits %s...%s for %s: %w", base, head, repo, err)
+const comparePageSize = 100
+
+// CompareCommits compares base...head, following pagination so every commit
+// GitHub is willing to list is returned. GitHub may still stop short of
+// TotalCommits for very large ranges (older servers cap a comparison at 250
+// commits), so callers that need every commit should check for that.
+func (c *Client) CompareCommits(ctx context.Context, repo *Repository, base, head string) (*github.CommitsComparison, error) {
+	var comparison *github.CommitsComparison
+	seen := make(map[string]bool)
+
+	for page := 1; ; page++ {
+		u := fmt.Sprintf("repos/%v/%v/compare/%v...%v?per_page=%d&page=%d",
+			repo.Owner, repo.Name, base, head, comparePageSize, page)
+		req, err := c.NewRequest("GET", u, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compare commits %s...%s for %s: %w", base, head, repo, err)
+		}
+
+		var result github.CommitsComparison
+		if _, err := c.Do(ctx, req, &result); err != nil {
+			return nil, fmt.Errorf("failed to compare commits %s...%s for %s: %w", base, head, repo, err)
+		}
+
+		if comparison == nil {
+			first := result
+			first.Commits = nil
+			comparison = &first
+		}
+
+		added := 0
+		for _, commit := range result.Commits {
+			if seen[commit.GetSHA()] {
+				continue
+			}
+			seen[commit.GetSHA()] = true
+			comparison.Commits = append(comparison.Commits, commit)
+			added++
+		}
+
+		// Stop on a short page, once everything has been listed, or when the
+		// server ignored the page parameter and repeated itself.
+		if len(result.Commits) < comparePageSize || added == 0 ||
+			len(comparison.Commits) >= comparison.GetTotalCommits() {
+			break
+		}
 	}
+
 	return comparison, nil
 }
 
-func (c *Client) GetPullRequest(repo *Repository, number int) (*github.PullRequest, error) {
-	pr, _, err := c.PullRequests.Get(c.ctx, repo.Owner, repo.Name, number)
+// ListCommitsSince walks the commit history of head, newest first, until it
+// reaches stopSHA, and returns the commits in between oldest first. It's the
+// fallback for ranges too large for the compare API. The history comes
+// newest first by date, so the commits of a branch merged after stopSHA but
+// made before it are left out, and only their merge commit is returned.
+func (c *Client) ListCommitsSince(ctx context.Context, repo *Repository, head, stopSHA string) ([]github.RepositoryCommit, error) {
+	opts := &github.CommitsListOptions{
+		SHA: head,
+		ListOptions: github.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		},
+	}
+
+	var commits []github.RepositoryCommit
+
+	for {
+		page, resp, err := c.Repositories.ListCommits(ctx, repo.Owner, repo.Name, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list commits of %s for %s: %w", head, repo, err)
+		}
+
+		for _, commit := range page {
+			if commit.GetSHA() == stopSHA {
+				reverseCommits(commits)
+				return commits, nil
+			}
+			commits = append(commits, *commit)
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return nil, fmt.Errorf("commit %s is not an ancestor of %s in %s", shortSHA(stopSHA), head, repo)
+}
+
+func reverseCommits(commits []github.RepositoryCommit) {
+	for i, j := 0, len(commits)-1; i < j; i, j = i+1, j-1 {
+		commits[i], commits[j] = commits[j], commits[i]
+	}
+}
+
+func (c *Client) GetPullRequest(ctx context.Context, repo *Repository, number int) (*github.PullRequest, error) {
+	pr, _, err := c.PullRequests.Get(ctx, repo.Owner, repo.Name, number)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get pull request #%d for %s: %w", number, repo, err)
 	}
 	return pr, nil
 }
 
-func (c *Client) GetRecentMergedPRs(repo *Repository, since time.Time) ([]*github.PullRequest, error) {
+// ListPullRequestsWithCommit lists the open and closed pull requests that
+// contain sha.
+func (c *Client) ListPullRequestsWithCommit(ctx context.Context, repo *Repository, sha string) ([]*github.PullRequest, error) {
+	opts := &github.PullRequestListOptions{
+		ListOptions: github.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		},
+	}
+
+	var allPRs []*github.PullRequest
+	for {
+		prs, resp, err := c.PullRequests.ListPullRequestsWithCommit(ctx, repo.Owner, repo.Name, sha, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pull requests of commit %s for %s: %w", shortSHA(sha), repo, err)
+		}
+		allPRs = append(allPRs, prs...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return allPRs, nil
+}
+
+func (c *Client) GetRecentMergedPRs(ctx context.Context, repo *Repository, since time.Time) ([]*github.PullRequest, error) {
 	opts := &github.PullRequestListOptions{
 		State:     "closed",
 		Sort:      "updated",
@@ -97,7 +369,7 @@ func (c *Client) GetRecentMergedPRs(repo *Repository, since time.Time) ([]*githu
 	var allPRs []*github.PullRequest
 	
 	for {
-		prs, resp, err := c.PullRequests.List(c.ctx, repo.Owner, repo.Name, opts)
+		prs, resp, err := c.PullRequests.List(ctx, repo.Owner, repo.Name, opts)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get pull requests for %s: %w", repo, err)
 		}
@@ -125,14 +397,35 @@ func (c *Client) GetRecentMergedPRs(repo *Repository, since time.Time) ([]*githu
 	return allPRs, nil
 }
 
-func (c *Client) CreateRelease(repo *Repository, release *github.RepositoryRelease) (*github.RepositoryRelease, error) {
-	createdRelease, _, err := c.Repositories.CreateRelease(c.ctx, repo.Owner, repo.Name, release)
+func (c *Client) CreateRelease(ctx context.Context, repo *Repository, release *github.RepositoryRelease) (*github.RepositoryRelease, error) {
+	createdRelease, _, err := c.Repositories.CreateRelease(ctx, repo.Owner, repo.Name, release)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create release for %s: %w", repo, err)
 	}
 	return createdRelease, nil
 }
 
+// CreateReleaseNotLatest creates release without making it the repository's
+// latest release, which GitHub otherwise does when it's the newest. The
+// make_latest field is newer than go-github's release type, so the request is
+// built by hand.
+func (c *Client) CreateReleaseNotLatest(ctx context.Context, repo *Repository, release *github.RepositoryRelease) (*github.RepositoryRelease, error) {
+	body := struct {
+		*github.RepositoryRelease
+		MakeLatest string `json:"make_latest"`
+	}{release, "false"}
+
+	req, err := c.NewRequest("POST", fmt.Sprintf("repos/%v/%v/releases", repo.Owner, repo.Name), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create release for %s: %w", repo, err)
+	}
+	created := new(github.RepositoryRelease)
+	if _, err := c.Do(ctx, req, created); err != nil {
+		return nil, fmt.Errorf("failed to create release for %s: %w", repo, err)
+	}
+	return created, nil
+}
+
 func minInt(a, b int) int {
 	if a < b {
 		return a
@@ -140,7 +433,7 @@ func minInt(a, b int) int {
 	return b
 }
 
-func (c *Client) GetLastNMergedPRs(repo *Repository, count int) ([]*github.PullRequest, error) {
+func (c *Client) GetLastNMergedPRs(ctx context.Context, repo *Repository, count int) ([]*github.PullRequest, error) {
 	opts := &github.PullRequestListOptions{
 		State:     "closed",
 		Sort:      "updated",
@@ -155,7 +448,7 @@ func (c *Client) GetLastNMergedPRs(repo *Repository, count int) ([]*github.PullR
 	needed := count
 	
 	for needed > 0 {
-		prs, resp, err := c.PullRequests.List(c.ctx, repo.Owner, repo.Name, opts)
+		prs, resp, err := c.PullRequests.List(ctx, repo.Owner, repo.Name, opts)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get pull requests for %s: %w", repo, err)
 		}
@@ -185,18 +478,18 @@ func (c *Client) GetLastNMergedPRs(repo *Repository, count int) ([]*github.PullR
 	return allPRs, nil
 }
 
-func (c *Client) CreateReleaseFromSHA(repo *Repository, release *github.RepositoryRelease, targetCommitish string) (*github.RepositoryRelease, error) {
+func (c *Client) CreateReleaseFromSHA(ctx context.Context, repo *Repository, release *github.RepositoryRelease, targetCommitish string) (*github.RepositoryRelease, error) {
 	// Set the target commitish (SHA) for the release
 	release.TargetCommitish = &targetCommitish
 	
-	createdRelease, _, err := c.Repositories.CreateRelease(c.ctx, repo.Owner, repo.Name, release)
+	createdRelease, _, err := c.Repositories.CreateRelease(ctx, repo.Owner, repo.Name, release)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create release from SHA %s for %s: %w", targetCommitish, repo, err)
 	}
 	return createdRelease, nil
 }
 
-func (c *Client) UploadReleaseAsset(repo *Repository, releaseID int64, path string) (*github.ReleaseAsset, error) {
+func (c *Client) UploadReleaseAsset(ctx context.Context, repo *Repository, releaseID int64, path string) (*github.ReleaseAsset, error) {
 	file, err := os.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open asset %s: %w", path, err)
@@ -204,23 +497,23 @@ func (c *Client) UploadReleaseAsset(repo *Repository, releaseID int64, path stri
 	defer file.Close()
 
 	opts := &github.UploadOptions{Name: filepath.Base(path)}
-	asset, _, err := c.Repositories.UploadReleaseAsset(c.ctx, repo.Owner, repo.Name, releaseID, opts, file)
+	asset, _, err := c.Repositories.UploadReleaseAsset(ctx, repo.Owner, repo.Name, releaseID, opts, file)
 	if err != nil {
 		return nil, fmt.Errorf("failed to upload asset %s to release %d for %s: %w", path, releaseID, repo, err)
 	}
 	return asset, nil
 }
 
-func (c *Client) GetReleaseByTag(repo *Repository, tag string) (*github.RepositoryRelease, error) {
-	release, _, err := c.Repositories.GetReleaseByTag(c.ctx, repo.Owner, repo.Name, tag)
+func (c *Client) GetReleaseByTag(ctx context.Context, repo *Repository, tag string) (*github.RepositoryRelease, error) {
+	release, _, err := c.Repositories.GetReleaseByTag(ctx, repo.Owner, repo.Name, tag)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get release %s for %s: %w", tag, repo, err)
 	}
 	return release, nil
 }
 
-func (c *Client) EditRelease(repo *Repository, id int64, release *github.RepositoryRelease) (*github.RepositoryRelease, error) {
-	updated, _, err := c.Repositories.EditRelease(c.ctx, repo.Owner, repo.Name, id, release)
+func (c *Client) EditRelease(ctx context.Context, repo *Repository, id int64, release *github.RepositoryRelease) (*github.RepositoryRelease, error) {
+	updated, _, err := c.Repositories.EditRelease(ctx, repo.Owner, repo.Name, id, release)
 	if err != nil {
 		return nil, fmt.Errorf("failed to edit release %d for %s: %w", id, repo, err)
 	}
@@ -229,14 +522,14 @@ func (c *Client) EditRelease(repo *Repository, id int64, release *github.Reposit
 
 // GetTagSHA returns the commit SHA that the given tag ref currently points at.
 // For annotated tags, this resolves through the tag object to the underlying commit.
-func (c *Client) GetTagSHA(repo *Repository, tag string) (string, error) {
-	ref, _, err := c.Git.GetRef(c.ctx, repo.Owner, repo.Name, "tags/"+tag)
+func (c *Client) GetTagSHA(ctx context.Context, repo *Repository, tag string) (string, error) {
+	ref, _, err := c.Git.GetRef(ctx, repo.Owner, repo.Name, "tags/"+tag)
 	if err != nil {
 		return "", fmt.Errorf("failed to get tag ref %s for %s: %w", tag, repo, err)
 	}
 	obj := ref.GetObject()
 	if obj.GetType() == "tag" {
-		tagObj, _, err := c.Git.GetTag(c.ctx, repo.Owner, repo.Name, obj.GetSHA())
+		tagObj, _, err := c.Git.GetTag(ctx, repo.Owner, repo.Name, obj.GetSHA())
 		if err != nil {
 			return "", fmt.Errorf("failed to resolve annotated tag %s for %s: %w", tag, repo, err)
 		}
@@ -246,20 +539,98 @@ func (c *Client) GetTagSHA(repo *Repository, tag string) (string, error) {
 }
 
 // UpdateTagRef force-updates a lightweight tag to point at the given SHA.
-func (c *Client) UpdateTagRef(repo *Repository, tag, sha string) error {
+func (c *Client) UpdateTagRef(ctx context.Context, repo *Repository, tag, sha string) error {
 	refName := "tags/" + tag
 	ref := &github.Reference{
 		Ref:    &refName,
 		Object: &github.GitObject{SHA: &sha},
 	}
-	_, _, err := c.Git.UpdateRef(c.ctx, repo.Owner, repo.Name, ref, true)
+	_, _, err := c.Git.UpdateRef(ctx, repo.Owner, repo.Name, ref, true)
 	if err != nil {
 		return fmt.Errorf("failed to update tag %s to %s for %s: %w", tag, sha, repo, err)
 	}
 	return nil
 }
 
-func (c *Client) GetPullRequestComments(repo *Repository, number int) ([]*github.IssueComment, error) {
+func (c *Client) GetTags(ctx context.Context, repo *Repository) ([]string, error) {
+	opts := &github.ListOptions{
+		Page:    1,
+		PerPage: 100,
+	}
+
+	var names []string
+	for {
+		tags, resp, err := c.Repositories.ListTags(ctx, repo.Owner, repo.Name, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tags for %s: %w", repo, err)
+		}
+
+		for _, tag := range tags {
+			names = append(names, tag.GetName())
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return names, nil
+}
+
+// GetFile returns the contents of path at ref, or nil if it doesn't exist.
+func (c *Client) GetFile(ctx context.Context, repo *Repository, path, ref string) ([]byte, error) {
+	opts := &github.RepositoryContentGetOptions{Ref: ref}
+	file, _, resp, err := c.Repositories.GetContents(ctx, repo.Owner, repo.Name, path, opts)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s at %s for %s: %w", path, ref, repo, err)
+	}
+	if file == nil {
+		return nil, fmt.Errorf("%s at %s for %s is a directory", path, ref, repo)
+	}
+
+	content, err := file.GetContent()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s at %s for %s: %w", path, ref, repo, err)
+	}
+	return []byte(content), nil
+}
+
+// GetPullRequestFiles lists the paths of every file a pull request changes,
+// including the old paths of renamed files.
+func (c *Client) GetPullRequestFiles(ctx context.Context, repo *Repository, number int) ([]string, error) {
+	opts := &github.ListOptions{
+		Page:    1,
+		PerPage: 100,
+	}
+
+	var paths []string
+	for {
+		files, resp, err := c.PullRequests.ListFiles(ctx, repo.Owner, repo.Name, number, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get files for PR #%d in %s: %w", number, repo, err)
+		}
+
+		for _, file := range files {
+			paths = append(paths, file.GetFilename())
+			if previous := file.GetPreviousFilename(); previous != "" {
+				paths = append(paths, previous)
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return paths, nil
+}
+
+func (c *Client) GetPullRequestComments(ctx context.Context, repo *Repository, number int) ([]*github.IssueComment, error) {
 	opts := &github.IssueListCommentsOptions{
 		ListOptions: github.ListOptions{
 			Page:    1,
@@ -270,7 +641,7 @@ func (c *Client) GetPullRequestComments(repo *Repository, number int) ([]*github
 	var allComments []*github.IssueComment
 	
 	for {
-		comments, resp, err := c.Issues.ListComments(c.ctx, repo.Owner, repo.Name, number, opts)
+		comments, resp, err := c.Issues.ListComments(ctx, repo.Owner, repo.Name, number, opts)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get comments for PR #%d in %s: %w", number, repo, err)
 		}
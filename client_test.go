@@ -1,6 +1,12 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 )
@@ -105,4 +111,369 @@ func TestClientMethods(t *testing.T) {
 	if actualDuration < minDuration || actualDuration > maxDuration {
 		t.Errorf("Expected duration between %v and %v, got %v", minDuration, maxDuration, actualDuration)
 	}
-}
\ No newline at end of file
+}
+
+func TestNewClientWithOptionsURLs(t *testing.T) {
+	tests := []struct {
+		name           string
+		opts           ClientOptions
+		expectedAPI    string
+		expectedUpload string
+		expectedWeb    string
+	}{
+		{
+			name:           "github.com defaults",
+			opts:           ClientOptions{},
+			expectedAPI:    "https://api.github.com/",
+			expectedUpload: "https://uploads.github.com/",
+			expectedWeb:    "https://github.com/",
+		},
+		{
+			name:           "enterprise server derives upload and web URLs",
+			opts:           ClientOptions{APIURL: "https://ghe.example.com/api/v3"},
+			expectedAPI:    "https://ghe.example.com/api/v3/",
+			expectedUpload: "https://ghe.example.com/api/uploads/",
+			expectedWeb:    "https://ghe.example.com/",
+		},
+		{
+			name:           "stand-in server uses the API URL for everything",
+			opts:           ClientOptions{APIURL: "http://localhost:8080"},
+			expectedAPI:    "http://localhost:8080/",
+			expectedUpload: "http://localhost:8080/",
+			expectedWeb:    "http://localhost:8080/",
+		},
+		{
+			name: "explicit upload and web URLs win",
+			opts: ClientOptions{
+				APIURL:    "https://ghe.example.com/api/v3/",
+				UploadURL: "https://uploads.ghe.example.com",
+				WebURL:    "https://code.example.com",
+			},
+			expectedAPI:    "https://ghe.example.com/api/v3/",
+			expectedUpload: "https://uploads.ghe.example.com/",
+			expectedWeb:    "https://code.example.com/",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClientWithOptions(tt.opts)
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if client.BaseURL.String() != tt.expectedAPI {
+				t.Errorf("Expected API URL %q, got %q", tt.expectedAPI, client.BaseURL)
+			}
+			if client.UploadURL.String() != tt.expectedUpload {
+				t.Errorf("Expected upload URL %q, got %q", tt.expectedUpload, client.UploadURL)
+			}
+			if client.webURL.String() != tt.expectedWeb {
+				t.Errorf("Expected web URL %q, got %q", tt.expectedWeb, client.webURL)
+			}
+		})
+	}
+}
+
+func TestNewClientWithOptionsInvalidURL(t *testing.T) {
+	if _, err := NewClientWithOptions(ClientOptions{APIURL: "not-a-url"}); err == nil {
+		t.Error("Expected error for relative api_url, got none")
+	}
+}
+
+func TestClientUsesConfiguredAPIURL(t *testing.T) {
+	var gotPath, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"total_commits": 0, "commits": []}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(ClientOptions{Token: "secret", APIURL: server.URL + "/api/v3"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if _, err := client.CompareCommits(context.Background(), &Repository{Owner: "org", Name: "repo"}, "v1.0.0", "main"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if gotPath != "/api/v3/repos/org/repo/compare/v1.0.0...main" {
+		t.Errorf("Expected request to the configured host, got path %q", gotPath)
+	}
+	if gotAuth != "Bearer secret" {
+		t.Errorf("Expected bearer token to be sent, got %q", gotAuth)
+	}
+}
+
+func TestClientStopsWhenContextIsCancelled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client, err := NewClientWithOptions(ClientOptions{Token: "secret", APIURL: server.URL, Logger: NewLoggerWithLevel(ErrorLevel)})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.GetLatestRelease(ctx, &Repository{Owner: "org", Name: "repo"}); err == nil {
+		t.Fatal("Expected error once the context is done")
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client, err := NewClientWithOptions(ClientOptions{Token: "secret", APIURL: server.URL, Timeout: 50 * time.Millisecond, Logger: NewLoggerWithLevel(ErrorLevel)})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if _, err := client.GetLatestRelease(context.Background(), &Repository{Owner: "org", Name: "repo"}); err == nil {
+		t.Fatal("Expected error for a request exceeding the timeout")
+	}
+}
+
+func TestReleaseURL(t *testing.T) {
+	repo := &Repository{Owner: "org", Name: "repo"}
+
+	client := NewClient("fake-token")
+	if got := client.ReleaseURL(repo, "v1.2.3"); got != "https://github.com/org/repo/releases/tag/v1.2.3" {
+		t.Errorf("Unexpected github.com release URL: %s", got)
+	}
+
+	ghe, err := NewClientWithOptions(ClientOptions{APIURL: "https://ghe.example.com/api/v3"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if got := ghe.ReleaseURL(repo, "v1.2.3"); got != "https://ghe.example.com/org/repo/releases/tag/v1.2.3" {
+		t.Errorf("Unexpected enterprise release URL: %s", got)
+	}
+	if got := ghe.PullRequestURL(repo, 12); got != "https://ghe.example.com/org/repo/pull/12" {
+		t.Errorf("Unexpected enterprise pull request URL: %s", got)
+	}
+}
+
+// fakeCommits returns n commit objects with SHAs sha1..shaN.
+func fakeCommits(from, to int) []map[string]interface{} {
+	var commits []map[string]interface{}
+	for i := from; i <= to; i++ {
+		commits = append(commits, map[string]interface{}{
+			"sha":    fmt.Sprintf("sha%d", i),
+			"commit": map[string]interface{}{"message": fmt.Sprintf("commit %d", i)},
+		})
+	}
+	return commits
+}
+
+func TestCompareCommitsPaginates(t *testing.T) {
+	const total = 230
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		from := (page-1)*perPage + 1
+		to := from + perPage - 1
+		if to > total {
+			to = total
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"total_commits": total,
+			"commits":       fakeCommits(from, to),
+		})
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(ClientOptions{APIURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	comparison, err := client.CompareCommits(context.Background(), &Repository{Owner: "org", Name: "repo"}, "v1.0.0", "main")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(comparison.Commits) != total {
+		t.Fatalf("Expected %d commits, got %d", total, len(comparison.Commits))
+	}
+	if comparison.Commits[0].GetSHA() != "sha1" || comparison.Commits[total-1].GetSHA() != fmt.Sprintf("sha%d", total) {
+		t.Errorf("Expected commits in order, got first %s and last %s",
+			comparison.Commits[0].GetSHA(), comparison.Commits[total-1].GetSHA())
+	}
+}
+
+func TestCompareCommitsStopsWhenServerIgnoresPaging(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"total_commits": 400,
+			"commits":       fakeCommits(1, 250),
+		})
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(ClientOptions{APIURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	comparison, err := client.CompareCommits(context.Background(), &Repository{Owner: "org", Name: "repo"}, "v1.0.0", "main")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected to stop after a repeated page, got %d calls", calls)
+	}
+	if len(comparison.Commits) != 250 || comparison.GetTotalCommits() != 400 {
+		t.Errorf("Expected a truncated comparison of 250/400, got %d/%d", len(comparison.Commits), comparison.GetTotalCommits())
+	}
+}
+
+func TestListCommitsSince(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < 2 {
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?page=2>; rel="next"`, "http://"+r.Host, r.URL.Path))
+			// Newest first: sha300..sha201
+			var commits []map[string]interface{}
+			for i := 300; i > 200; i-- {
+				commits = append(commits, fakeCommits(i, i)...)
+			}
+			json.NewEncoder(w).Encode(commits)
+			return
+		}
+		var commits []map[string]interface{}
+		for i := 200; i > 100; i-- {
+			commits = append(commits, fakeCommits(i, i)...)
+		}
+		json.NewEncoder(w).Encode(commits)
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(ClientOptions{APIURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commits, err := client.ListCommitsSince(context.Background(), &Repository{Owner: "org", Name: "repo"}, "main", "sha150")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(commits) != 150 {
+		t.Fatalf("Expected 150 commits, got %d", len(commits))
+	}
+	if commits[0].GetSHA() != "sha151" || commits[149].GetSHA() != "sha300" {
+		t.Errorf("Expected oldest first, got first %s and last %s", commits[0].GetSHA(), commits[149].GetSHA())
+	}
+
+	if _, err := client.ListCommitsSince(context.Background(), &Repository{Owner: "org", Name: "repo"}, "main", "unknown"); err == nil {
+		t.Error("Expected error when the stop commit is never reached")
+	}
+}
+
+func TestGetReleasesPaginates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if r.URL.Query().Get("per_page") != "100" {
+			t.Errorf("Expected per_page=100, got %q", r.URL.Query().Get("per_page"))
+		}
+		var releases []map[string]interface{}
+		count := 100
+		if page == 2 {
+			count = 5
+		} else {
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s%s?page=2&per_page=100>; rel="next"`, r.Host, r.URL.Path))
+		}
+		for i := 0; i < count; i++ {
+			releases = append(releases, map[string]interface{}{"tag_name": fmt.Sprintf("v0.%d.%d", page, i)})
+		}
+		json.NewEncoder(w).Encode(releases)
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(ClientOptions{APIURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	releases, err := client.GetReleases(context.Background(), &Repository{Owner: "org", Name: "repo"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(releases) != 105 {
+		t.Errorf("Expected 105 releases across two pages, got %d", len(releases))
+	}
+}
+
+func TestCreateReleaseNotLatest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/repos/org/repo/releases" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if body["make_latest"] != "false" || body["tag_name"] != "v1.4.8" || body["target_commitish"] != "release/1.4" {
+			t.Errorf("Expected a v1.4.8 release that isn't latest, got %v", body)
+		}
+		w.Write([]byte(`{"id": 1, "tag_name": "v1.4.8"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(ClientOptions{APIURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forge := NewGitHubForge(client)
+	created, err := forge.CreateRelease(context.Background(), &Repository{Owner: "org", Name: "repo"},
+		&ForgeRelease{TagName: "v1.4.8", Name: "v1.4.8", Target: "release/1.4", NotLatest: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if created.ID != 1 {
+		t.Errorf("Expected the created release, got %+v", created)
+	}
+}
+
+func TestListCommitPullRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/org/repo/commits/a1/pulls" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"message": "No commit found for SHA"}`))
+			return
+		}
+		w.Write([]byte(`[{"number": 7, "title": "Add retries", "merged_at": "2024-05-01T10:00:00Z", "base": {"ref": "main"}},
+		  {"number": 21, "title": "Spike", "merged_at": null, "base": {"ref": "main"}}]`))
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(ClientOptions{APIURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	forge := NewGitHubForge(client).(commitPullRequestLister)
+	repo := &Repository{Owner: "org", Name: "repo"}
+
+	prs, err := forge.ListCommitPullRequests(context.Background(), repo, "a1")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(prs) != 2 || prs[0].Number != 7 || prs[0].BaseBranch != "main" || prs[0].MergedAt.IsZero() || !prs[1].MergedAt.IsZero() {
+		t.Errorf("Unexpected pull requests: %+v", prs)
+	}
+	if _, err := forge.ListCommitPullRequests(context.Background(), repo, "unknown"); err == nil {
+		t.Error("Expected an unknown commit to fail")
+	}
+}
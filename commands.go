@@ -2,33 +2,101 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"os/signal"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/Masterminds/semver"
+	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
 )
 
 type CLI struct {
 	config  *Config
 	logger  *Logger
-	client  *Client
+	forges  map[interface{}]Forge
 	manager *Manager
 	dryRun  bool
 }
 
-func NewCLI(cfg *Config, logger *Logger, dryRun bool) *CLI {
-	client := NewClient(cfg.GHToken)
-	manager := NewManager(client, logger, cfg.JiraBoards, cfg.JiraOrgId, dryRun)
+func NewCLI(cfg *Config, logger *Logger, dryRun bool) (*CLI, error) {
+	opts := cfg.ClientOptions(RepoConfig{})
+	opts.Logger = logger
+	client, err := NewClientWithOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	forge := NewGitHubForge(client)
+	manager := NewManager(forge, logger, cfg.JiraBoards, cfg.JiraOrgId, dryRun)
+	manager.graphQL = cfg.GraphQL
+	manager.bumpLabels = cfg.LabelBumps()
+	manager.prereleaseChannel = cfg.Channel()
+	manager.categories = cfg.ChangelogCategories()
 
 	return &CLI{
 		config:  cfg,
 		logger:  logger,
-		client:  client,
+		forges:  map[interface{}]Forge{opts: forge},
 		manager: manager,
 		dryRun:  dryRun,
+	}, nil
+}
+
+// forgeFor returns a client for the forge and host configured for repo,
+// sharing one client between all repos that point at the same host.
+func (c *CLI) forgeFor(repo RepoConfig) (Forge, error) {
+	var key interface{}
+	var create func() (Forge, error)
+
+	switch repo.ForgeKind() {
+	case ForgeGitLab:
+		opts := c.config.GitLabOptions(repo)
+		opts.Logger = c.logger
+		key = opts
+		create = func() (Forge, error) {
+			client, err := NewGitLabClient(opts)
+			if err != nil {
+				return nil, err
+			}
+			return client, nil
+		}
+	case ForgeGitea:
+		opts := c.config.GiteaOptions(repo)
+		opts.Logger = c.logger
+		key = opts
+		create = func() (Forge, error) {
+			client, err := NewGiteaClient(opts)
+			if err != nil {
+				return nil, err
+			}
+			return client, nil
+		}
+	default:
+		opts := c.config.ClientOptions(repo)
+		opts.Logger = c.logger
+		key = opts
+		create = func() (Forge, error) {
+			client, err := NewClientWithOptions(opts)
+			if err != nil {
+				return nil, err
+			}
+			return NewGitHubForge(client), nil
+		}
+	}
+
+	if forge, ok := c.forges[key]; ok {
+		return forge, nil
 	}
+	forge, err := create()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client for %s: %w", repo.Repo, err)
+	}
+	c.forges[key] = forge
+	return forge, nil
 }
 
 func (c *CLI) runWithSpinner(message string, fn func() error) error {
@@ -64,39 +132,96 @@ func (c *CLI) runWithSpinner(message string, fn func() error) error {
 	return err
 }
 
-func (c *CLI) ProcessRepositories(ctx context.Context, repoSpec string) ([]*ReleaseRepository, error) {
+// ProcessRepositories builds the release repositories of the project
+// repoSpec and resolves their versions. With --line, those matching
+// repoFilter move to the line's branch, or stay on their own with a warning
+// when there's no filter and they can't.
+func (c *CLI) ProcessRepositories(ctx context.Context, repoSpec, repoFilter string) ([]*ReleaseRepository, error) {
 	repoConfigs, err := c.config.GetProjectRepos(repoSpec)
 	if err != nil {
 		return nil, err
 	}
 
+	var line *VersionLine
+	if c.config.Line != "" {
+		line, err = ParseVersionLine(c.config.Line)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	var repos []*ReleaseRepository
+	// ownBranch is where a repository moved to the line was released from
+	ownBranch := make(map[*ReleaseRepository]string)
 	for _, cfg := range repoConfigs {
-		ghRepo, err := ParseRepoSpec(cfg.Repo)
+		parse := ParseRepoSpec
+		if cfg.ForgeKind() == ForgeGitLab {
+			parse = ParseProjectPath
+		}
+		ghRepo, err := parse(cfg.Repo)
+		if err != nil {
+			return nil, err
+		}
+
+		forge, err := c.forgeFor(cfg)
 		if err != nil {
 			return nil, err
 		}
 
-		commitSHA := c.config.GetBranch(cfg.Repo)
-		repo := NewRepository(ghRepo, cfg, commitSHA)
-		repos = append(repos, repo)
+		var notesTemplate *NotesTemplate
+		if path := c.config.NotesTemplatePath(repoSpec, cfg); path != "" {
+			notesTemplate, err = LoadNotesTemplate(path)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", cfg.Repo, err)
+			}
+		}
+
+		for _, repo := range NewRepositories(ghRepo, cfg, c.config.GetBranch(cfg.Repo)) {
+			repo.Forge = forge
+			repo.NotesTemplate = notesTemplate
+			if line != nil && (repoFilter == "" || repo.matchesName(repoFilter)) {
+				if cfg.Versioning == "calver" {
+					if repoFilter != "" {
+						return nil, fmt.Errorf("%s uses calendar versioning, which has no maintenance lines", cfg.Repo)
+					}
+					c.logger.Warn("Leaving %s out of the %s line, it uses calendar versioning", repo.GetDisplayName(), line)
+				} else {
+					ownBranch[repo] = repo.CommitSHA
+					repo.CommitSHA = c.config.GetLineBranch(line)
+					repo.Scheme.Line = line
+				}
+			}
+			repos = append(repos, repo)
+		}
 	}
 
 	err = c.runWithSpinner("Fetching repository information...", func() error {
 		wg := sync.WaitGroup{}
-		var fetchError error
-		for _, repo := range repos {
+		fetchErrors := make([]error, len(repos))
+		for i, repo := range repos {
 			wg.Add(1)
-			go func(r *ReleaseRepository) {
+			go func(i int, r *ReleaseRepository) {
 				defer wg.Done()
-				if err := c.manager.ResolveVersions(ctx, r); err != nil {
+				err := c.manager.ResolveVersions(ctx, r)
+				if err != nil && r.Scheme.Line != nil && repoFilter == "" {
+					c.logger.Warn("Leaving %s out of the %s line: %v", r.GetDisplayName(), r.Scheme.Line, err)
+					r.Scheme.Line = nil
+					r.CommitSHA = ownBranch[r]
+					err = c.manager.ResolveVersions(ctx, r)
+				}
+				if err != nil {
 					c.logger.Error("Failed to resolve versions for %s: %v", r.Repository, err)
-					fetchError = err
+					fetchErrors[i] = err
 				}
-			}(repo)
+			}(i, repo)
 		}
 		wg.Wait()
-		return fetchError
+		for _, err := range fetchErrors {
+			if err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 
 	if err != nil {
@@ -107,20 +232,91 @@ func (c *CLI) ProcessRepositories(ctx context.Context, repoSpec string) ([]*Rele
 }
 
 
-// findRepoByName returns the repository in repos matching name, which may be
-// either the short name (e.g. "qa-review") or the full "owner/repo" spec.
-// Returns nil if none match.
-func findRepoByName(repos []*ReleaseRepository, name string) *ReleaseRepository {
+// onLine narrows repos down to those released from the --line branch, when
+// there is one.
+func (c *CLI) onLine(repos []*ReleaseRepository) []*ReleaseRepository {
+	if c.config.Line == "" {
+		return repos
+	}
+	var kept []*ReleaseRepository
+	for _, repo := range repos {
+		if repo.Scheme.Line != nil {
+			kept = append(kept, repo)
+		}
+	}
+	return kept
+}
+
+// findReposByName returns the repositories in repos matching name, which may
+// be either the short name (e.g. "qa-review") or the full "owner/repo" spec,
+// optionally followed by :component. Returns nil if none match.
+func findReposByName(repos []*ReleaseRepository, name string) []*ReleaseRepository {
+	var matches []*ReleaseRepository
 	for _, r := range repos {
-		if r.Name == name || r.Repository.String() == name {
-			return r
+		if r.matchesName(name) {
+			matches = append(matches, r)
 		}
 	}
-	return nil
+	return matches
+}
+
+// findRepoByName returns the single repository in repos matching name, which
+// must name the component of a monorepo with more than one.
+func findRepoByName(repos []*ReleaseRepository, name, projectName string) (*ReleaseRepository, error) {
+	matches := findReposByName(repos, name)
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("repository '%s' not found in project '%s'", name, projectName)
+	case 1:
+		return matches[0], nil
+	}
+	var names []string
+	for _, r := range matches {
+		names = append(names, r.GetDisplayName())
+	}
+	return nil, fmt.Errorf("repository '%s' has several components, pick one of %s", name, strings.Join(names, ", "))
 }
 
-func (c *CLI) releaseCommand(args []string, providedProject, repoFilter string) {
-	ctx := context.Background()
+// releaseOutcome records what happened to one repository during a release
+// run. Reason explains why it wasn't released when Version is nil.
+type releaseOutcome struct {
+	Repo    *ReleaseRepository
+	Version *semver.Version
+	Reason  string
+}
+
+// formatReleaseSummary lists the repositories that were released, with their
+// new versions and what failed after publishing, followed by those that
+// weren't and why.
+func formatReleaseSummary(outcomes []releaseOutcome) string {
+	var released, notReleased strings.Builder
+	for _, o := range outcomes {
+		if o.Version != nil && o.Reason != "" {
+			fmt.Fprintf(&released, "- %s: %s (%s)\n", o.Repo.GetDisplayName(), o.Repo.Scheme.FormatVersion(o.Version), o.Reason)
+		} else if o.Version != nil {
+			fmt.Fprintf(&released, "- %s: %s\n", o.Repo.GetDisplayName(), o.Repo.Scheme.FormatVersion(o.Version))
+		} else {
+			fmt.Fprintf(&notReleased, "- %s: %s\n", o.Repo.GetDisplayName(), o.Reason)
+		}
+	}
+
+	var builder strings.Builder
+	if released.Len() > 0 {
+		builder.WriteString("Released:\n" + released.String())
+	}
+	if notReleased.Len() > 0 {
+		builder.WriteString("Not released:\n" + notReleased.String())
+	}
+	return builder.String()
+}
+
+// interrupted reports whether err stems from the user interrupting the run,
+// either through a cancelled context or Ctrl-C at a prompt.
+func interrupted(ctx context.Context, err error) bool {
+	return ctx.Err() != nil || errors.Is(err, promptui.ErrInterrupt)
+}
+
+func (c *CLI) releaseCommand(ctx context.Context, args []string, providedProject, repoFilter string) {
 
 	projectName, err := c.config.GetProjectName(providedProject, args)
 	if err != nil {
@@ -128,7 +324,7 @@ func (c *CLI) releaseCommand(args []string, providedProject, repoFilter string)
 	}
 
 	// allRepos is always the full project so cross-links resolve correctly.
-	allRepos, err := c.ProcessRepositories(ctx, projectName)
+	allRepos, err := c.ProcessRepositories(ctx, projectName, repoFilter)
 	if err != nil {
 		c.logger.FatalErr(err, "Failed to process repositories")
 	}
@@ -136,66 +332,103 @@ func (c *CLI) releaseCommand(args []string, providedProject, repoFilter string)
 	// repos is the set we actually release; narrowed to one when --repo is given.
 	repos := allRepos
 	if repoFilter != "" {
-		repo := findRepoByName(allRepos, repoFilter)
-		if repo == nil {
+		repos = findReposByName(allRepos, repoFilter)
+		if len(repos) == 0 {
 			c.logger.FatalErr(fmt.Errorf("repository '%s' not found in project '%s'", repoFilter, projectName), "Repository not found")
 		}
-		repos = []*ReleaseRepository{repo}
 	}
+	repos = c.onLine(repos)
 
 	releaseType := TypeRegular
 
-	var releases []*Release
+	var outcomes []releaseOutcome
+	stopped := false
+	failed := false
 
+	// Interrupts are only acted on between repositories, or by abandoning the
+	// one in progress before its release is published, so every repository
+	// ends up in the summary.
 	for _, repo := range repos {
+		if ctx.Err() != nil {
+			stopped = true
+		}
+		if stopped {
+			outcomes = append(outcomes, releaseOutcome{Repo: repo, Reason: "not started (interrupted)"})
+			continue
+		}
+
 		var entries []Entry
 		err := c.runWithSpinner(fmt.Sprintf("Fetching changelog for %s...", repo.GetDisplayName()), func() error {
 			var err error
 			entries, err = c.manager.GenerateChangelog(ctx, repo)
 			return err
 		})
+		if err != nil && interrupted(ctx, err) {
+			outcomes = append(outcomes, releaseOutcome{Repo: repo, Reason: "interrupted"})
+			stopped = true
+			continue
+		}
 		if err != nil {
 			c.logger.FatalErr(err, fmt.Sprintf("Failed to generate changelog for %s", repo.Repository))
 		}
 
 		// Process this repository individually using the interactive method
 		release, err := c.manager.ProcessReleaseInteractiveWithEntries(ctx, repo, releaseType, allRepos, entries)
+		if err != nil && release != nil {
+			// Published, but something after that failed
+			c.logger.Error("Failed to finish the release of %s: %v", repo.Repository, err)
+			outcomes = append(outcomes, releaseOutcome{Repo: repo, Version: release.Version, Reason: err.Error()})
+			failed = true
+			continue
+		}
+		if err != nil && interrupted(ctx, err) {
+			outcomes = append(outcomes, releaseOutcome{Repo: repo, Reason: "interrupted"})
+			stopped = true
+			continue
+		}
 		if err != nil {
+			fmt.Print(formatReleaseSummary(outcomes))
 			c.logger.FatalErr(err, fmt.Sprintf("Failed to process release for %s", repo.Repository))
 		}
 
-		// Only add to releases if it's not a skip (version unchanged means skip)
+		// A version unchanged means the release was skipped
 		if release.Version.String() != repo.LatestRelease.String() {
-			releases = append(releases, release)
+			outcomes = append(outcomes, releaseOutcome{Repo: repo, Version: release.Version})
+		} else {
+			outcomes = append(outcomes, releaseOutcome{Repo: repo, Reason: "skipped"})
 		}
 	}
 
-	c.logger.Info("Release processing completed for %s", projectName)
-	for _, rel := range releases {
-		c.logger.Info("- %s: %s", rel.Repository.Repository, FormatVersion(rel.Version))
+	fmt.Print(formatReleaseSummary(outcomes))
+	if stopped {
+		c.logger.Fatal("Release of %s interrupted", projectName)
 	}
-	return
-
+	if failed {
+		c.logger.Fatal("Release of %s finished with errors", projectName)
+	}
+	c.logger.Info("Release processing completed for %s", projectName)
 }
 
-func (c *CLI) reviewCommand(args []string, providedProject string) {
-	ctx := context.Background()
+func (c *CLI) reviewCommand(ctx context.Context, args []string, providedProject string) {
 
 	projectName, err := c.config.GetProjectName(providedProject, args)
 	if err != nil {
 		c.logger.FatalErr(err, "Failed to determine project")
 	}
 
-	repos, err := c.ProcessRepositories(ctx, projectName)
+	repos, err := c.ProcessRepositories(ctx, projectName, "")
 	if err != nil {
 		c.logger.FatalErr(err, "Failed to process repositories")
 	}
+	repos = c.onLine(repos)
 
 	c.logger.Info("Latest versions for %s:", projectName)
 	for _, repo := range repos {
 		displayName := repo.GetDisplayName()
-		if repo.LatestRelease != nil {
-			c.logger.Info("- %s: %s", displayName, FormatVersion(repo.LatestRelease))
+		if repo.LatestPrerelease != nil {
+			c.logger.Info("- %s: %s (pre-release %s in flight)", displayName, repo.latestRef(), repo.LatestPrereleaseTag)
+		} else if repo.LatestRelease != nil {
+			c.logger.Info("- %s: %s", displayName, repo.latestRef())
 		} else {
 			c.logger.Info("- %s: No releases found", displayName)
 		}
@@ -209,14 +442,18 @@ func (c *CLI) reviewCommand(args []string, providedProject string) {
 			entries, err = c.manager.GenerateChangelog(ctx, repo)
 			return err
 		})
+		if err != nil && ctx.Err() != nil {
+			c.logger.Warn("Review interrupted before %s", repo.Repository)
+			break
+		}
 		if err != nil {
 			c.logger.Error("Failed to generate changelog for %s: %v", repo.Repository, err)
 			continue
 		}
 
 		section := reviewSection{
-			RepoFullName: repo.Repository.String(),
-			CurrentVer:   FormatVersion(repo.LatestRelease),
+			RepoFullName: repo.FullName(),
+			CurrentVer:   repo.latestRef(),
 		}
 
 		if len(entries) == 0 {
@@ -226,8 +463,15 @@ func (c *CLI) reviewCommand(args []string, providedProject string) {
 			if repo.CrossLinkEnabled && len(repos) > 1 {
 				crossLinks = c.manager.generateCrossLinks(repo, repos)
 			}
-			section.Markdown = BuildCrossLinksString(crossLinks) +
-				BuildEntriesTableString(entries, repo.JiraEnabled, c.config.JiraOrgId)
+			notes, err := c.manager.ReleaseNotes(repo, "", entries, crossLinks)
+			if err != nil {
+				c.logger.Error("Skipping %s: %v", repo.GetDisplayName(), err)
+				continue
+			}
+			section.Markdown = notes
+			if suggestion := c.manager.SuggestBump(repo, entries); suggestion.Type != "" {
+				section.Markdown = fmt.Sprintf("_Suggested bump: %s_\n\n", suggestion.Reason) + section.Markdown
+			}
 		}
 		sections = append(sections, section)
 	}
@@ -250,8 +494,7 @@ func (c *CLI) reviewCommand(args []string, providedProject string) {
 	}
 }
 
-func (c *CLI) hotfixCommand(args []string, providedProject string) {
-	ctx := context.Background()
+func (c *CLI) hotfixCommand(ctx context.Context, args []string, providedProject string) {
 
 	repositoryName := args[0]
 	sha := args[1]
@@ -268,23 +511,17 @@ func (c *CLI) hotfixCommand(args []string, providedProject string) {
 		}
 	}
 	
-	allRepos, err := c.ProcessRepositories(ctx, projectName)
+	allRepos, err := c.ProcessRepositories(ctx, projectName, repositoryName)
 	if err != nil {
 		c.logger.FatalErr(err, "Failed to process repository")
 	}
 	// Find the repository that matches repositoryName
-	var repo *ReleaseRepository
-	for _, r := range allRepos {
-		if r.Name == repositoryName {
-			repo = r
-			break
-		}
-	}
-	if repo == nil {
-		c.logger.FatalErr(fmt.Errorf("repository '%s' not found in project '%s'", repositoryName, projectName), "Repository not found")
+	repo, err := findRepoByName(allRepos, repositoryName, projectName)
+	if err != nil {
+		c.logger.FatalErr(err, "Repository not found")
 	}
 
-	suffix, err := PromptForHotfixSuffix(repo.LatestRelease, sha)
+	suffix, err := PromptForHotfixSuffix(repo.latestRef(), sha)
 	if err != nil {
 		c.logger.FatalErr(err, "Failed to get hotfix suffix")
 	}
@@ -306,23 +543,20 @@ func (c *CLI) hotfixCommand(args []string, providedProject string) {
 		c.logger.FatalErr(err, "Failed to generate changelog from SHA")
 	}
 	
-	// Build release notes
-	var builder strings.Builder
-	if len(entries) > 0 {
-		builder.WriteString(BuildEntriesTableString(entries, repo.JiraEnabled, c.config.JiraOrgId))
+	releaseNotes, err := c.manager.ReleaseNotes(repo, repo.Scheme.FormatVersion(hotfixVersion), entries, nil)
+	if err != nil {
+		c.logger.FatalErr(err, "Failed to build release notes")
 	}
-	releaseNotes := builder.String()
 	
 	// Create the hotfix release
 	if err := c.manager.CreateHotfixRelease(ctx, repo, hotfixVersion, releaseNotes, sha); err != nil {
 		c.logger.FatalErr(err, "Failed to create hotfix release")
 	}
 	
-	c.logger.Info("Hotfix release completed for %s: %s", repo.Repository, FormatVersion(hotfixVersion))
+	c.logger.Info("Hotfix release completed for %s: %s", repo.Repository, repo.Scheme.FormatVersion(hotfixVersion))
 }
 
-func (c *CLI) appendCommand(args []string, providedProject string) {
-	ctx := context.Background()
+func (c *CLI) appendCommand(ctx context.Context, args []string, providedProject string) {
 
 	repositoryName := args[0]
 	tag := args[1]
@@ -339,20 +573,14 @@ func (c *CLI) appendCommand(args []string, providedProject string) {
 		}
 	}
 
-	allRepos, err := c.ProcessRepositories(ctx, projectName)
+	allRepos, err := c.ProcessRepositories(ctx, projectName, repositoryName)
 	if err != nil {
 		c.logger.FatalErr(err, "Failed to process repository")
 	}
 
-	var repo *ReleaseRepository
-	for _, r := range allRepos {
-		if r.Name == repositoryName {
-			repo = r
-			break
-		}
-	}
-	if repo == nil {
-		c.logger.FatalErr(fmt.Errorf("repository '%s' not found in project '%s'", repositoryName, projectName), "Repository not found")
+	repo, err := findRepoByName(allRepos, repositoryName, projectName)
+	if err != nil {
+		c.logger.FatalErr(err, "Repository not found")
 	}
 
 	err = c.runWithSpinner(fmt.Sprintf("Appending commits up to %s to release %s...", sha, tag), func() error {
@@ -369,6 +597,23 @@ func configureCliCommands() {
 	var dryRun bool
 	var projectName string
 	var repoName string
+	var noCache bool
+	var offline bool
+	var timeout time.Duration
+	var autoBump bool
+	var line string
+	var force bool
+
+	// The first Ctrl-C cancels ctx: in-flight requests and generate-assets
+	// commands are abandoned, a release already being published is finished,
+	// and the commands stop before the next repository. Restoring the default
+	// handler afterwards lets a second Ctrl-C exit immediately.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
 
 	loadConfigAndCreateCLI := func() *CLI {
 		level := ParseLevel(logLevel)
@@ -378,12 +623,35 @@ func configureCliCommands() {
 		if err != nil {
 			logger.FatalErr(err, "Failed to load configuration")
 		}
+		if noCache {
+			cfg.CacheDir = ""
+		}
+		cfg.Timeout = timeout
+		cfg.Line = line
+
+		// Offline runs never reach a forge, so they need no credentials.
+		if offline {
+			if err := cfg.ValidateOffline(); err != nil {
+				logger.FatalErr(err, "Invalid configuration")
+			}
+		} else {
+			if err := cfg.ResolveToken(logger); err != nil {
+				logger.FatalErr(err, "Failed to resolve access token")
+			}
 
-		if err := cfg.Validate(); err != nil {
-			logger.FatalErr(err, "Invalid configuration")
+			if err := cfg.Validate(); err != nil {
+				logger.FatalErr(err, "Invalid configuration")
+			}
 		}
 
-		return NewCLI(cfg, logger, dryRun)
+		cli, err := NewCLI(cfg, logger, dryRun)
+		if err != nil {
+			logger.FatalErr(err, "Failed to create GitHub client")
+		}
+		cli.manager.offline = offline
+		cli.manager.autoBump = autoBump
+		cli.manager.force = force
+		return cli
 	}
 
 	var rootCmd = &cobra.Command{
@@ -397,7 +665,7 @@ If no project name is specified and only one project is configured, it will be u
 		Args: cobra.MaximumNArgs(1), // Allow 0 or 1 arguments
 		Run: func(cmd *cobra.Command, args []string) {
 			cli := loadConfigAndCreateCLI()
-			cli.releaseCommand(args, projectName, repoName)
+			cli.releaseCommand(ctx, args, projectName, repoName)
 		},
 	}
 
@@ -405,7 +673,12 @@ If no project name is specified and only one project is configured, it will be u
 	rootCmd.PersistentFlags().StringVarP(&logLevel, "log-level", "l", "warn", "Set logging level (debug, info, warn, error)")
 	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Perform a dry run without creating actual releases")
 	rootCmd.PersistentFlags().StringVarP(&projectName, "project", "p", "", "Specify the project to use")
-	rootCmd.Flags().StringVarP(&repoName, "repo", "r", "", "Release only the named repository within the project")
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "Bypass the on-disk GitHub response cache")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 0, "Fail any API request that takes longer than this, e.g. 30s (default: no limit)")
+	rootCmd.Flags().StringVarP(&repoName, "repo", "r", "", "Release only the named repository (or repository:component) within the project")
+	rootCmd.Flags().BoolVar(&autoBump, "auto", false, "Release with the bump suggested by Conventional Commits instead of prompting")
+	rootCmd.Flags().StringVar(&line, "line", "", "Release a maintenance line, e.g. 1.4, from its branch (see line_branch)")
+	rootCmd.Flags().BoolVar(&force, "force", false, "Accept any custom version, even one that isn't newer than the latest release or is already tagged")
 
 	releaseCmd := &cobra.Command{
 		Use:   "release [project-name|owner/repo]",
@@ -413,10 +686,13 @@ If no project name is specified and only one project is configured, it will be u
 		Args:  cobra.MaximumNArgs(1), // Allow 0 or 1 arguments
 		Run: func(cmd *cobra.Command, args []string) {
 			cli := loadConfigAndCreateCLI()
-			cli.releaseCommand(args, projectName, repoName)
+			cli.releaseCommand(ctx, args, projectName, repoName)
 		},
 	}
-	releaseCmd.Flags().StringVarP(&repoName, "repo", "r", "", "Release only the named repository within the project")
+	releaseCmd.Flags().StringVarP(&repoName, "repo", "r", "", "Release only the named repository (or repository:component) within the project")
+	releaseCmd.Flags().BoolVar(&autoBump, "auto", false, "Release with the bump suggested by Conventional Commits instead of prompting")
+	releaseCmd.Flags().StringVar(&line, "line", "", "Release a maintenance line, e.g. 1.4, from its branch (see line_branch)")
+	releaseCmd.Flags().BoolVar(&force, "force", false, "Accept any custom version, even one that isn't newer than the latest release or is already tagged")
 
 	reviewCmd := &cobra.Command{
 		Use:   "review [project-name|owner/repo]",
@@ -424,9 +700,11 @@ If no project name is specified and only one project is configured, it will be u
 		Args:  cobra.MaximumNArgs(1), // Allow 0 or 1 arguments
 		Run: func(cmd *cobra.Command, args []string) {
 			cli := loadConfigAndCreateCLI()
-			cli.reviewCommand(args, projectName)
+			cli.reviewCommand(ctx, args, projectName)
 		},
 	}
+	reviewCmd.Flags().BoolVar(&offline, "offline", false, "Preview from each repository's local checkout and commit messages, without API calls")
+	reviewCmd.Flags().StringVar(&line, "line", "", "Review a maintenance line, e.g. 1.4, on its branch (see line_branch)")
 
 	hotfixCmd := &cobra.Command{
 		Use:   "hotfix <repository> <sha>",
@@ -434,7 +712,7 @@ If no project name is specified and only one project is configured, it will be u
 		Args:  cobra.ExactArgs(2), // Require exactly 2 arguments: repository and SHA
 		Run: func(cmd *cobra.Command, args []string) {
 			cli := loadConfigAndCreateCLI()
-			cli.hotfixCommand(args, projectName)
+			cli.hotfixCommand(ctx, args, projectName)
 		},
 	}
 
@@ -444,14 +722,42 @@ If no project name is specified and only one project is configured, it will be u
 		Args:  cobra.ExactArgs(3),
 		Run: func(cmd *cobra.Command, args []string) {
 			cli := loadConfigAndCreateCLI()
-			cli.appendCommand(args, projectName)
+			cli.appendCommand(ctx, args, projectName)
+		},
+	}
+
+	cacheCmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the on-disk GitHub response cache",
+	}
+
+	cacheClearCmd := &cobra.Command{
+		Use:   "clear",
+		Short: "Remove all cached GitHub responses",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			logger := NewLoggerWithLevel(ParseLevel(logLevel))
+			cfg, err := LoadFromPath(configPath)
+			if err != nil {
+				logger.FatalErr(err, "Failed to load configuration")
+			}
+			if cfg.CacheDir == "" {
+				logger.Fatal("No cache_dir is configured, nothing to clear")
+			}
+			removed, err := ClearCache(cfg.CacheDir)
+			if err != nil {
+				logger.FatalErr(err, "Failed to clear cache")
+			}
+			fmt.Printf("Removed %d cached responses from %s\n", removed, cfg.CacheDir)
 		},
 	}
+	cacheCmd.AddCommand(cacheClearCmd)
 
 	rootCmd.AddCommand(releaseCmd)
 	rootCmd.AddCommand(reviewCmd)
 	rootCmd.AddCommand(hotfixCmd)
 	rootCmd.AddCommand(appendCmd)
+	rootCmd.AddCommand(cacheCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		// Create a basic logger for command execution errors
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/manifoldco/promptui"
+)
+
+func TestFormatReleaseSummary(t *testing.T) {
+	repo := func(name string) *ReleaseRepository {
+		return &ReleaseRepository{Repository: &Repository{Owner: "org", Name: name}}
+	}
+	version, _ := ParseVersion("v1.3.0")
+
+	got := formatReleaseSummary([]releaseOutcome{
+		{Repo: repo("backend"), Version: version},
+		{Repo: repo("frontend"), Reason: "skipped"},
+		{Repo: repo("docs"), Reason: "interrupted"},
+		{Repo: repo("api"), Version: version, Reason: "failed to upload assets"},
+	})
+	want := "Released:\n- backend: v1.3.0\n- api: v1.3.0 (failed to upload assets)\nNot released:\n- frontend: skipped\n- docs: interrupted\n"
+	if got != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, got)
+	}
+
+	if got := formatReleaseSummary(nil); got != "" {
+		t.Errorf("Expected empty summary, got %q", got)
+	}
+}
+
+func TestInterrupted(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want bool
+	}{
+		{"cancelled context", cancelled, context.Canceled, true},
+		{"ctrl-c at a prompt", context.Background(), fmt.Errorf("failed to get version bump choice: %w", promptui.ErrInterrupt), true},
+		{"other error", context.Background(), errors.New("boom"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := interrupted(tt.ctx, tt.err); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewRepositories(t *testing.T) {
+	repo := &Repository{Owner: "org", Name: "platform"}
+
+	single := NewRepositories(repo, RepoConfig{Repo: "org/platform"}, "main")
+	if len(single) != 1 || single[0].Component != "" {
+		t.Fatalf("Expected the repository itself, got %+v", single)
+	}
+
+	repos := NewRepositories(repo, RepoConfig{
+		Repo: "org/platform",
+		Components: []ComponentConfig{
+			{Name: "api", Paths: []string{"packages/api"}},
+			{Name: "web", Paths: []string{"packages/web"}, TagPrefix: "web-"},
+		},
+	}, "main")
+	if len(repos) != 2 {
+		t.Fatalf("Expected one repository per component, got %d", len(repos))
+	}
+
+	version, _ := ParseVersion("2.1.0")
+	if got := repos[0].Scheme.FormatVersion(version); got != "api/v2.1.0" {
+		t.Errorf("Expected the tag to default to name/, got %s", got)
+	}
+	if got := repos[1].Scheme.FormatVersion(version); got != "web-v2.1.0" {
+		t.Errorf("Expected the tag prefix to be used, got %s", got)
+	}
+	if got := repos[0].GetDisplayName(); got != "platform:api" {
+		t.Errorf("Expected platform:api, got %s", got)
+	}
+	if got := repos[1].FullName(); got != "org/platform:web" {
+		t.Errorf("Expected org/platform:web, got %s", got)
+	}
+}
+
+func TestFindReposByName(t *testing.T) {
+	platform := &Repository{Owner: "org", Name: "platform"}
+	repos := []*ReleaseRepository{
+		{Repository: &Repository{Owner: "org", Name: "docs"}},
+		{Repository: platform, Component: "api"},
+		{Repository: platform, Component: "web"},
+	}
+
+	tests := []struct {
+		name        string
+		want        int
+		expectError bool
+	}{
+		{name: "docs", want: 1},
+		{name: "org/docs", want: 1},
+		{name: "platform", want: 2, expectError: true},
+		{name: "platform:web", want: 1},
+		{name: "org/platform:api", want: 1},
+		{name: "platform:cli", want: 0, expectError: true},
+		{name: "docs:api", want: 0, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findReposByName(repos, tt.name); len(got) != tt.want {
+				t.Errorf("Expected %d matches, got %d", tt.want, len(got))
+			}
+			_, err := findRepoByName(repos, tt.name, "project")
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Expected no error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestTouchesPaths(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  bool
+	}{
+		{name: "file under path", files: []string{"README", "packages/api/main.go"}, want: true},
+		{name: "path itself", files: []string{"packages/api"}, want: true},
+		{name: "sibling with the same prefix", files: []string{"packages/api-client/main.go"}},
+		{name: "other package", files: []string{"packages/web/index.ts"}},
+		{name: "no files"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := touchesPaths(tt.files, []string{"packages/api/"}); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestComponentChangelogOffline(t *testing.T) {
+	dir, _ := initTestRepo(t)
+	gitIn(t, dir, "tag", "api/v1.0.0")
+	for _, change := range []struct{ path, message string }{
+		{"packages/api/main.go", "Add endpoint (#1)"},
+		{"packages/web/index.ts", "Restyle header (#2)"},
+		{"packages/api/util.go", "Fix parsing (#3)"},
+	} {
+		name := filepath.Join(dir, change.path)
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		gitIn(t, dir, "add", ".")
+		gitIn(t, dir, "commit", "-q", "-m", change.message)
+	}
+	head := gitIn(t, dir, "rev-parse", "HEAD")
+
+	manager := NewManager(&githubForge{}, NewLoggerWithLevel(ErrorLevel), nil, "", false)
+	manager.offline = true
+	repos := NewRepositories(&Repository{Owner: "org", Name: "platform"}, RepoConfig{
+		Repo: "org/platform",
+		Path: dir,
+		Components: []ComponentConfig{
+			{Name: "api", Paths: []string{"packages/api"}},
+			{Name: "web", Paths: []string{"packages/web"}},
+		},
+	}, head)
+
+	api := repos[0]
+	if err := manager.ResolveVersions(context.Background(), api); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if api.LatestTag != "api/v1.0.0" {
+		t.Fatalf("Expected the component's tag, got %q", api.LatestTag)
+	}
+
+	entries, err := manager.GenerateChangelog(context.Background(), api)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Number != 1 || entries[1].Number != 3 {
+		t.Errorf("Expected only the api pull requests, got %+v", entries)
+	}
+	if len(api.Commits) != 2 {
+		t.Errorf("Expected the api commits only, got %d", len(api.Commits))
+	}
+}
+
+func TestProcessRepositoriesLine(t *testing.T) {
+	api, _ := initTestRepo(t)
+	gitIn(t, api, "branch", "-M", "main")
+	gitIn(t, api, "tag", "v1.4.0")
+	gitIn(t, api, "branch", "release/1.4")
+	gitIn(t, api, "commit", "-q", "--allow-empty", "-m", "Start 2.x")
+	gitIn(t, api, "tag", "v2.0.0")
+
+	web, _ := initTestRepo(t)
+	gitIn(t, web, "branch", "-M", "main")
+	gitIn(t, web, "tag", "v3.0.0")
+
+	data, _ := initTestRepo(t)
+	gitIn(t, data, "branch", "-M", "main")
+	gitIn(t, data, "tag", "2026.10.0")
+
+	cfg := &Config{
+		Projects: map[string][]RepoConfig{"platform": {
+			{Repo: "org/api", Path: api},
+			{Repo: "org/web", Path: web},
+			{Repo: "org/data", Path: data, Versioning: "calver"},
+		}},
+		Line: "1.4",
+	}
+	cli, err := NewCLI(cfg, NewLoggerWithLevel(ErrorLevel), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli.manager.offline = true
+
+	describe := func(repos []*ReleaseRepository) string {
+		var described []string
+		for _, repo := range repos {
+			described = append(described, repo.Name+"@"+repo.CommitSHA+"="+repo.LatestTag)
+		}
+		return strings.Join(described, ",")
+	}
+
+	tests := []struct {
+		filter      string
+		want        string
+		onLine      string
+		expectError bool
+	}{
+		{
+			filter: "",
+			want:   "api@release/1.4=v1.4.0,web@main=v3.0.0,data@main=2026.10.0",
+			onLine: "api@release/1.4=v1.4.0",
+		},
+		{
+			filter: "api",
+			want:   "api@release/1.4=v1.4.0,web@main=v3.0.0,data@main=2026.10.0",
+			onLine: "api@release/1.4=v1.4.0",
+		},
+		{filter: "web", expectError: true},
+		{filter: "data", expectError: true},
+	}
+	for _, tt := range tests {
+		repos, err := cli.ProcessRepositories(context.Background(), "platform", tt.filter)
+		if tt.expectError {
+			if err == nil {
+				t.Errorf("--repo %q: expected an error, got %s", tt.filter, describe(repos))
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("--repo %q: expected no error, got: %v", tt.filter, err)
+		}
+		if got := describe(repos); got != tt.want {
+			t.Errorf("--repo %q: expected %s, got %s", tt.filter, tt.want, got)
+		}
+		if got := describe(cli.onLine(repos)); got != tt.onLine {
+			t.Errorf("--repo %q: expected %s on the line, got %s", tt.filter, tt.onLine, got)
+		}
+	}
+}
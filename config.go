@@ -4,26 +4,99 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	GHToken    string                        `mapstructure:"gh_token"`
-	Projects   map[string][]RepoConfig       `mapstructure:"projects"`
-	JiraBoards []string                      `mapstructure:"jira_boards"`
-	JiraOrgId  string                        `mapstructure:"jira_org_id"`
-	Branches   map[string]string             `mapstructure:"branches"`
+	GHToken           string                  `mapstructure:"gh_token"`
+	GHTokenCommand    string                  `mapstructure:"gh_token_command"`
+	GHTokenFile       string                  `mapstructure:"gh_token_file"`
+	Projects          map[string][]RepoConfig `mapstructure:"projects"`
+	JiraBoards        []string                `mapstructure:"jira_boards"`
+	JiraOrgId         string                  `mapstructure:"jira_org_id"`
+	Branches          map[string]string       `mapstructure:"branches"`
+	APIURL            string                  `mapstructure:"api_url"`
+	UploadURL         string                  `mapstructure:"upload_url"`
+	WebURL            string                  `mapstructure:"web_url"`
+	CacheDir          string                  `mapstructure:"cache_dir"`
+	GraphQL           bool                    `mapstructure:"graphql"`
+	GitHubApp         GitHubAppConfig         `mapstructure:"github_app"`
+	GitLabToken       string                  `mapstructure:"gitlab_token"`
+	GiteaToken        string                  `mapstructure:"gitea_token"`
+	BumpLabels        map[string][]string     `mapstructure:"bump_labels"`
+	PrereleaseChannel string                  `mapstructure:"prerelease_channel"`
+	LineBranch        string                  `mapstructure:"line_branch"`
+	Categories        []CategoryConfig        `mapstructure:"categories"`
+	NotesTemplate     string                  `mapstructure:"notes_template"`
+	NotesTemplates    map[string]string       `mapstructure:"notes_templates"`
+	Timeout           time.Duration           `mapstructure:"-"` // set from --timeout
+	Line              string                  `mapstructure:"-"` // set from --line
 }
 
+// CategoryConfig is a section of the release notes, listing the PRs with one
+// of Labels or whose title starts with one of Prefixes.
+type CategoryConfig struct {
+	Title    string   `mapstructure:"title"`
+	Labels   []string `mapstructure:"labels"`
+	Prefixes []string `mapstructure:"prefixes"`
+}
+
+// DefaultLineBranch names the branch a maintenance line is released from
+// when line_branch isn't set.
+const DefaultLineBranch = "release/{line}"
+
 type RepoConfig struct {
-	Repo           string `mapstructure:"repo"`
-	Alias          string `mapstructure:"alias"`
-	Jira           bool   `mapstructure:"jira"`
-	CrossLink      bool   `mapstructure:"crossLink"`
-	GenerateAssets string `mapstructure:"generate-assets"`
-	Path           string `mapstructure:"path"`
+	Repo             string            `mapstructure:"repo"`
+	Alias            string            `mapstructure:"alias"`
+	Jira             bool              `mapstructure:"jira"`
+	CrossLink        bool              `mapstructure:"crossLink"`
+	GenerateAssets   string            `mapstructure:"generate-assets"`
+	Path             string            `mapstructure:"path"`
+	APIURL           string            `mapstructure:"api_url"`
+	UploadURL        string            `mapstructure:"upload_url"`
+	WebURL           string            `mapstructure:"web_url"`
+	Forge            string            `mapstructure:"forge"`
+	LocalGit         bool              `mapstructure:"local_git"`
+	Versioning       string            `mapstructure:"versioning"`
+	CalVerFormat     string            `mapstructure:"calver_format"`
+	TagPattern       string            `mapstructure:"tag_pattern"`
+	Components       []ComponentConfig `mapstructure:"components"`
+	VersionSource    string            `mapstructure:"version_source"`
+	NotesTemplate    string            `mapstructure:"notes_template"`
+	ChangelogFile    string            `mapstructure:"changelog_file"`
+	ChangelogMessage string            `mapstructure:"changelog_message"`
+	DirectCommits    bool              `mapstructure:"direct_commits"`
+}
+
+// GetChangelogMessage returns the commit message of changelog_file updates,
+// with {version} standing for the new tag.
+func (r RepoConfig) GetChangelogMessage() string {
+	if r.ChangelogMessage == "" {
+		return DefaultChangelogMessage
+	}
+	return r.ChangelogMessage
+}
+
+// Where the latest version of a repository is read from, set with
+// version_source: the forge's latest release, or the highest version tag on
+// the release branch.
+const (
+	VersionSourceRelease = "release"
+	VersionSourceTags    = "tags"
+)
+
+// ComponentConfig is one independently versioned package of a monorepo. Its
+// changelog only lists pull requests changing files under Paths, and its
+// tags are the repository's tag pattern behind TagPrefix (default: Name
+// followed by a slash), e.g. api/v2.1.0.
+type ComponentConfig struct {
+	Name      string   `mapstructure:"name"`
+	Paths     []string `mapstructure:"paths"`
+	TagPrefix string   `mapstructure:"tag_prefix"`
 }
 
 
@@ -55,9 +128,17 @@ func LoadFromPath(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	cfg.CacheDir = expandTilde(cfg.CacheDir)
+	cfg.GHTokenFile = expandTilde(cfg.GHTokenFile)
+	cfg.GitHubApp.PrivateKeyPath = expandTilde(cfg.GitHubApp.PrivateKeyPath)
+	cfg.NotesTemplate = expandTilde(cfg.NotesTemplate)
+	for project, path := range cfg.NotesTemplates {
+		cfg.NotesTemplates[project] = expandTilde(path)
+	}
 	for project, repos := range cfg.Projects {
 		for i := range repos {
 			repos[i].Path = expandTilde(repos[i].Path)
+			repos[i].NotesTemplate = expandTilde(repos[i].NotesTemplate)
 		}
 		cfg.Projects[project] = repos
 	}
@@ -92,9 +173,191 @@ func (c *Config) GetBranch(repoSpec string) string {
 	return "main"
 }
 
+// GetLineBranch returns the branch line is maintained on, line_branch with
+// {line} replaced by e.g. 1.4.
+func (c *Config) GetLineBranch(line *VersionLine) string {
+	pattern := c.LineBranch
+	if pattern == "" {
+		pattern = DefaultLineBranch
+	}
+	return strings.Replace(pattern, "{line}", line.String(), 1)
+}
+
+// ClientOptions returns the GitHub host settings for repo. A repo that sets
+// api_url gets its own upload_url and web_url (derived when empty); otherwise
+// the top-level settings apply.
+func (c *Config) ClientOptions(repo RepoConfig) ClientOptions {
+	if repo.APIURL != "" {
+		return ClientOptions{
+			Token:     c.GHToken,
+			App:       c.GitHubApp,
+			APIURL:    repo.APIURL,
+			UploadURL: repo.UploadURL,
+			WebURL:    repo.WebURL,
+			CacheDir:  c.CacheDir,
+			Timeout:   c.Timeout,
+		}
+	}
+	opts := ClientOptions{
+		Token:     c.GHToken,
+		App:       c.GitHubApp,
+		APIURL:    c.APIURL,
+		UploadURL: c.UploadURL,
+		WebURL:    c.WebURL,
+		CacheDir:  c.CacheDir,
+		Timeout:   c.Timeout,
+	}
+	if repo.UploadURL != "" {
+		opts.UploadURL = repo.UploadURL
+	}
+	if repo.WebURL != "" {
+		opts.WebURL = repo.WebURL
+	}
+	return opts
+}
+
+// GitLabOptions returns the GitLab instance settings for repo. GitLab
+// repositories only use their own api_url and web_url, since the top-level
+// ones describe the GitHub host.
+func (c *Config) GitLabOptions(repo RepoConfig) GitLabOptions {
+	return GitLabOptions{
+		Token:    c.GitLabToken,
+		APIURL:   repo.APIURL,
+		WebURL:   repo.WebURL,
+		CacheDir: c.CacheDir,
+		Timeout:  c.Timeout,
+	}
+}
+
+// GiteaOptions returns the Gitea or Forgejo instance settings for repo, which
+// must set api_url to the instance's base URL.
+func (c *Config) GiteaOptions(repo RepoConfig) GiteaOptions {
+	return GiteaOptions{
+		Token:    c.GiteaToken,
+		BaseURL:  repo.APIURL,
+		CacheDir: c.CacheDir,
+		Timeout:  c.Timeout,
+	}
+}
+
+// prereleaseChannelPattern matches a channel usable as the first identifier
+// of a semver pre-release, such as rc, beta or alpha.
+var prereleaseChannelPattern = regexp.MustCompile(`^[A-Za-z][0-9A-Za-z-]*$`)
+
+// Channel returns the configured pre-release channel, "rc" by default.
+func (c *Config) Channel() string {
+	if c.PrereleaseChannel == "" {
+		return DefaultPrereleaseChannel
+	}
+	return c.PrereleaseChannel
+}
+
+// ChangelogCategories returns the configured release note sections, with a
+// catch-all Other section, or nil when none are configured.
+func (c *Config) ChangelogCategories() *Categories {
+	if len(c.Categories) == 0 {
+		return nil
+	}
+	categories := &Categories{Other: "Other"}
+	for _, category := range c.Categories {
+		categories.Sections = append(categories.Sections, Category{
+			Title:    category.Title,
+			Labels:   category.Labels,
+			Prefixes: category.Prefixes,
+		})
+	}
+	return categories
+}
+
+// NotesTemplatePath returns the notes template for repo in project: its own
+// notes_template, else the project's entry in notes_templates, else the
+// top-level notes_template. It's empty for the built-in layout.
+func (c *Config) NotesTemplatePath(project string, repo RepoConfig) string {
+	if repo.NotesTemplate != "" {
+		return repo.NotesTemplate
+	}
+	if path, exists := c.NotesTemplates[project]; exists && path != "" {
+		return path
+	}
+	return c.NotesTemplate
+}
+
+// LabelBumps maps each PR label listed under bump_labels, in lower case, to
+// the bump it demands. A label listed under several bumps demands the largest.
+func (c *Config) LabelBumps() map[string]BumpType {
+	bumps := make(map[string]BumpType)
+	for bump, labels := range c.BumpLabels {
+		for _, label := range labels {
+			label = strings.ToLower(label)
+			if bumpRank(BumpType(bump)) > bumpRank(bumps[label]) {
+				bumps[label] = BumpType(bump)
+			}
+		}
+	}
+	return bumps
+}
+
+// Scheme returns how the repository numbers its releases, semver (the
+// default) or calver in calver_format, and how it names their tags.
+func (r RepoConfig) Scheme() (VersionScheme, error) {
+	if r.TagPattern != "" && strings.Count(r.TagPattern, "{version}") != 1 {
+		return VersionScheme{}, fmt.Errorf("tag_pattern %q must contain {version} once", r.TagPattern)
+	}
+
+	switch r.Versioning {
+	case "", "semver":
+		if r.CalVerFormat != "" {
+			return VersionScheme{}, fmt.Errorf("calver_format requires versioning: calver")
+		}
+		return VersionScheme{TagPattern: r.TagPattern}, nil
+	case "calver":
+		format := r.CalVerFormat
+		if format == "" {
+			format = DefaultCalVerFormat
+		}
+		calver, err := ParseCalVer(format)
+		if err != nil {
+			return VersionScheme{}, err
+		}
+		return VersionScheme{CalVer: calver, TagPattern: r.TagPattern}, nil
+	}
+	return VersionScheme{}, fmt.Errorf("unknown versioning %q (expected semver or calver)", r.Versioning)
+}
+
+func validateComponents(components []ComponentConfig) error {
+	names := make(map[string]bool)
+	for i, component := range components {
+		if component.Name == "" || strings.Contains(component.Name, ":") {
+			return fmt.Errorf("component %d: name is required and can't contain a colon", i)
+		}
+		if names[component.Name] {
+			return fmt.Errorf("component %s is declared twice", component.Name)
+		}
+		names[component.Name] = true
+		if len(component.Paths) == 0 {
+			return fmt.Errorf("component %s: at least one path is required", component.Name)
+		}
+	}
+	return nil
+}
+
+// usesForge reports whether any configured repository is hosted on kind.
+func (c *Config) usesForge(kind string) bool {
+	for _, repos := range c.Projects {
+		for _, repo := range repos {
+			if repo.ForgeKind() == kind {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // FindProjectByRepository returns the project name that contains the given repository.
 // The repoName can be either the short name (e.g., "my-repo") or the full name (e.g., "owner/my-repo").
 func (c *Config) FindProjectByRepository(repoName string) (string, error) {
+	// A monorepo component is named repo:component
+	repoName, _, _ = strings.Cut(repoName, ":")
 	var matchingProjects []string
 
 	for projectName, repos := range c.Projects {
@@ -155,15 +418,74 @@ func (c *Config) GetProjectName(providedProject string, args []string) (string,
 	return "", fmt.Errorf("multiple projects found (%v), please specify one using --project flag or as argument", projectNames)
 }
 
+// Validate checks the configuration, including that credentials are present
+// for every forge a repository is hosted on.
 func (c *Config) Validate() error {
-	if c.GHToken == "" {
-		return fmt.Errorf("gh_token is required in configuration")
+	if len(c.Projects) == 0 {
+		return fmt.Errorf("at least one project must be configured")
+	}
+
+	if c.GitHubApp.Configured() {
+		if err := c.GitHubApp.Validate(); err != nil {
+			return err
+		}
+	} else if c.GHToken == "" && c.usesForge(ForgeGitHub) {
+		return fmt.Errorf("a GitHub token is required: set VERSIONISTA_GITHUB_TOKEN or GITHUB_TOKEN, or configure gh_token_command, gh_token_file, gh_token or github_app")
+	}
+
+	if c.GitLabToken == "" && c.usesForge(ForgeGitLab) {
+		return fmt.Errorf("a GitLab token is required: set VERSIONISTA_GITLAB_TOKEN or GITLAB_TOKEN, or configure gitlab_token")
 	}
 
+	if c.GiteaToken == "" && c.usesForge(ForgeGitea) {
+		return fmt.Errorf("a Gitea token is required: set VERSIONISTA_GITEA_TOKEN or GITEA_TOKEN, or configure gitea_token")
+	}
+
+	return c.ValidateOffline()
+}
+
+// ValidateOffline checks everything Validate does except credentials, which
+// an --offline run never uses.
+func (c *Config) ValidateOffline() error {
 	if len(c.Projects) == 0 {
 		return fmt.Errorf("at least one project must be configured")
 	}
 
+	if err := validateHostURLs("", c.APIURL, c.UploadURL, c.WebURL); err != nil {
+		return err
+	}
+
+	for bump := range c.BumpLabels {
+		if bumpRank(BumpType(bump)) == 0 {
+			return fmt.Errorf("bump_labels: unknown bump %q (expected patch, minor or major)", bump)
+		}
+	}
+
+	if c.PrereleaseChannel != "" && !prereleaseChannelPattern.MatchString(c.PrereleaseChannel) {
+		return fmt.Errorf("prerelease_channel: %q must be letters, digits and hyphens, starting with a letter", c.PrereleaseChannel)
+	}
+
+	if c.LineBranch != "" && strings.Count(c.LineBranch, "{line}") != 1 {
+		return fmt.Errorf("line_branch %q must contain {line} once", c.LineBranch)
+	}
+	if c.Line != "" {
+		if _, err := ParseVersionLine(c.Line); err != nil {
+			return err
+		}
+	}
+
+	for i, category := range c.Categories {
+		if category.Title == "" || len(category.Labels)+len(category.Prefixes) == 0 {
+			return fmt.Errorf("category %d: a title and at least one label or prefix are required", i)
+		}
+	}
+
+	for projectName := range c.NotesTemplates {
+		if _, exists := c.Projects[projectName]; !exists {
+			return fmt.Errorf("notes_templates: project %s not found in configuration", projectName)
+		}
+	}
+
 	jiraEnabledProjectFound := false
 	for projectName, repos := range c.Projects {
 		if len(repos) == 0 {
@@ -177,6 +499,36 @@ func (c *Config) Validate() error {
 			if repo.Jira {
 				jiraEnabledProjectFound = true
 			}
+			if err := validateForgeKind(repo.ForgeKind()); err != nil {
+				return fmt.Errorf("project %s, repo %s: %w", projectName, repo.Repo, err)
+			}
+			if repo.ForgeKind() == ForgeGitea && repo.APIURL == "" {
+				return fmt.Errorf("project %s, repo %s: api_url is required for %s repositories", projectName, repo.Repo, repo.Forge)
+			}
+			if repo.LocalGit && repo.Path == "" {
+				return fmt.Errorf("project %s, repo %s: path is required when local_git is enabled", projectName, repo.Repo)
+			}
+			if repo.ChangelogFile != "" && repo.Path == "" {
+				return fmt.Errorf("project %s, repo %s: path is required when changelog_file is set", projectName, repo.Repo)
+			}
+			if filepath.IsAbs(repo.ChangelogFile) {
+				return fmt.Errorf("project %s, repo %s: changelog_file must be relative to path", projectName, repo.Repo)
+			}
+			if _, err := repo.Scheme(); err != nil {
+				return fmt.Errorf("project %s, repo %s: %w", projectName, repo.Repo, err)
+			}
+			switch repo.VersionSource {
+			case "", VersionSourceRelease, VersionSourceTags:
+			default:
+				return fmt.Errorf("project %s, repo %s: unknown version_source %q (expected %s or %s)",
+					projectName, repo.Repo, repo.VersionSource, VersionSourceRelease, VersionSourceTags)
+			}
+			if err := validateComponents(repo.Components); err != nil {
+				return fmt.Errorf("project %s, repo %s: %w", projectName, repo.Repo, err)
+			}
+			if err := validateHostURLs(fmt.Sprintf("project %s, repo %s: ", projectName, repo.Repo), repo.APIURL, repo.UploadURL, repo.WebURL); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -185,4 +537,22 @@ func (c *Config) Validate() error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// validateHostURLs checks that each non-empty GitHub host URL is absolute.
+// prefix locates the setting in error messages.
+func validateHostURLs(prefix, apiURL, uploadURL, webURL string) error {
+	for _, setting := range []struct{ name, value string }{
+		{"api_url", apiURL},
+		{"upload_url", uploadURL},
+		{"web_url", webURL},
+	} {
+		if setting.value == "" {
+			continue
+		}
+		if _, err := parseBaseURL(setting.value); err != nil {
+			return fmt.Errorf("%s%s %q is invalid: %w", prefix, setting.name, setting.value, err)
+		}
+	}
+	return nil
+}
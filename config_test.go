@@ -131,6 +131,30 @@ func TestValidate(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			name: "github app instead of gh_token",
+			config: Config{
+				GitHubApp: GitHubAppConfig{AppID: 1, InstallationID: 2, PrivateKeyPath: "app.pem"},
+				Projects: map[string][]RepoConfig{
+					"test": {
+						{Repo: "owner/repo"},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "incomplete github app",
+			config: Config{
+				GitHubApp: GitHubAppConfig{AppID: 1},
+				Projects: map[string][]RepoConfig{
+					"test": {
+						{Repo: "owner/repo"},
+					},
+				},
+			},
+			expectError: true,
+		},
 		{
 			name: "no projects",
 			config: Config{
@@ -139,6 +163,261 @@ func TestValidate(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			name: "relative api_url",
+			config: Config{
+				GHToken: "test_token",
+				APIURL:  "ghe.example.com/api/v3",
+				Projects: map[string][]RepoConfig{
+					"test": {
+						{Repo: "owner/repo"},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid repo web_url",
+			config: Config{
+				GHToken: "test_token",
+				Projects: map[string][]RepoConfig{
+					"test": {
+						{Repo: "owner/repo", WebURL: "/just/a/path"},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "gitlab-only project without a GitHub token",
+			config: Config{
+				GitLabToken: "gitlab_token",
+				Projects: map[string][]RepoConfig{
+					"mobile": {
+						{Repo: "mobile/ios-app", Forge: "gitlab"},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "gitlab repo without a GitLab token",
+			config: Config{
+				GHToken: "test_token",
+				Projects: map[string][]RepoConfig{
+					"test": {
+						{Repo: "owner/repo"},
+						{Repo: "mobile/ios-app", Forge: "gitlab"},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "forgejo repo with base URL",
+			config: Config{
+				GHToken:    "test_token",
+				GiteaToken: "gitea_token",
+				Projects: map[string][]RepoConfig{
+					"tools": {
+						{Repo: "tools/deployer", Forge: "forgejo", APIURL: "https://forgejo.example.com"},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "gitea repo without base URL",
+			config: Config{
+				GiteaToken: "gitea_token",
+				Projects: map[string][]RepoConfig{
+					"tools": {
+						{Repo: "tools/deployer", Forge: "gitea"},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "unknown forge",
+			config: Config{
+				GHToken: "test_token",
+				Projects: map[string][]RepoConfig{
+					"test": {
+						{Repo: "owner/repo", Forge: "bitbucket"},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "local_git without a path",
+			config: Config{
+				GHToken: "test_token",
+				Projects: map[string][]RepoConfig{
+					"test": {
+						{Repo: "owner/repo", LocalGit: true},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid prerelease_channel",
+			config: Config{
+				GHToken:           "test_token",
+				PrereleaseChannel: "rc.1",
+				Projects: map[string][]RepoConfig{
+					"test": {
+						{Repo: "owner/repo"},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "unknown bump in bump_labels",
+			config: Config{
+				GHToken:    "test_token",
+				BumpLabels: map[string][]string{"huge": {"semver:huge"}},
+				Projects: map[string][]RepoConfig{
+					"test": {
+						{Repo: "owner/repo"},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "monorepo components",
+			config: Config{
+				GHToken: "test_token",
+				Projects: map[string][]RepoConfig{
+					"test": {
+						{Repo: "owner/repo", Components: []ComponentConfig{
+							{Name: "api", Paths: []string{"packages/api"}},
+							{Name: "web", Paths: []string{"packages/web"}, TagPrefix: "web-"},
+						}},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "component without paths",
+			config: Config{
+				GHToken: "test_token",
+				Projects: map[string][]RepoConfig{
+					"test": {
+						{Repo: "owner/repo", Components: []ComponentConfig{{Name: "api"}}},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "duplicate component",
+			config: Config{
+				GHToken: "test_token",
+				Projects: map[string][]RepoConfig{
+					"test": {
+						{Repo: "owner/repo", Components: []ComponentConfig{
+							{Name: "api", Paths: []string{"packages/api"}},
+							{Name: "api", Paths: []string{"packages/web"}},
+						}},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "component name with a colon",
+			config: Config{
+				GHToken: "test_token",
+				Projects: map[string][]RepoConfig{
+					"test": {
+						{Repo: "owner/repo", Components: []ComponentConfig{{Name: "a:b", Paths: []string{"a"}}}},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "unknown version_source",
+			config: Config{
+				GHToken: "test_token",
+				Projects: map[string][]RepoConfig{
+					"test": {
+						{Repo: "owner/repo", VersionSource: "latest"},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "line_branch without line",
+			config: Config{
+				GHToken:    "test_token",
+				LineBranch: "release",
+				Projects: map[string][]RepoConfig{
+					"test": {
+						{Repo: "owner/repo"},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "category without labels or prefixes",
+			config: Config{
+				GHToken:    "test_token",
+				Categories: []CategoryConfig{{Title: "Features"}},
+				Projects: map[string][]RepoConfig{
+					"test": {
+						{Repo: "owner/repo"},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "notes template for an unknown project",
+			config: Config{
+				GHToken:        "test_token",
+				NotesTemplates: map[string]string{"other": "notes.tmpl"},
+				Projects: map[string][]RepoConfig{
+					"test": {
+						{Repo: "owner/repo"},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "changelog_file without path",
+			config: Config{
+				GHToken: "test_token",
+				Projects: map[string][]RepoConfig{
+					"test": {
+						{Repo: "owner/repo", ChangelogFile: "CHANGELOG.md"},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid --line",
+			config: Config{
+				GHToken: "test_token",
+				Line:    "1.4.8",
+				Projects: map[string][]RepoConfig{
+					"test": {
+						{Repo: "owner/repo"},
+					},
+				},
+			},
+			expectError: true,
+		},
 		{
 			name: "empty repo name",
 			config: Config{
@@ -166,6 +445,104 @@ func TestValidate(t *testing.T) {
 	}
 }
 
+func TestValidateOffline(t *testing.T) {
+	cfg := Config{
+		Projects: map[string][]RepoConfig{
+			"test": {
+				{Repo: "owner/repo", Path: "/src/repo"},
+			},
+		},
+	}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected Validate to require a token")
+	}
+	if err := cfg.ValidateOffline(); err != nil {
+		t.Errorf("Expected ValidateOffline to need no token, got: %v", err)
+	}
+
+	cfg.Projects["test"][0].LocalGit = true
+	cfg.Projects["test"][0].Path = ""
+	if err := cfg.ValidateOffline(); err == nil {
+		t.Error("Expected ValidateOffline to still check repositories")
+	}
+}
+
+func TestChannel(t *testing.T) {
+	cfg := Config{}
+	if got := cfg.Channel(); got != "rc" {
+		t.Errorf("Expected the default channel rc, got %s", got)
+	}
+	cfg.PrereleaseChannel = "beta"
+	if got := cfg.Channel(); got != "beta" {
+		t.Errorf("Expected channel beta, got %s", got)
+	}
+}
+
+func TestRepoConfigScheme(t *testing.T) {
+	tests := []struct {
+		name        string
+		repo        RepoConfig
+		wantCalVer  string
+		expectError bool
+	}{
+		{name: "semver by default", repo: RepoConfig{}},
+		{name: "explicit semver", repo: RepoConfig{Versioning: "semver"}},
+		{name: "calver default format", repo: RepoConfig{Versioning: "calver"}, wantCalVer: "YYYY.MM.MICRO"},
+		{name: "calver custom format", repo: RepoConfig{Versioning: "calver", CalVerFormat: "YY.0M.DD"}, wantCalVer: "YY.0M.DD"},
+		{name: "invalid format", repo: RepoConfig{Versioning: "calver", CalVerFormat: "DD.MM.YYYY"}, expectError: true},
+		{name: "format without calver", repo: RepoConfig{CalVerFormat: "YYYY.MM.MICRO"}, expectError: true},
+		{name: "unknown scheme", repo: RepoConfig{Versioning: "date"}, expectError: true},
+		{name: "tag pattern", repo: RepoConfig{TagPattern: "release-{version}"}},
+		{name: "tag pattern without version", repo: RepoConfig{TagPattern: "release"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme, err := tt.repo.Scheme()
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			got := ""
+			if scheme.CalVer != nil {
+				got = scheme.CalVer.Format
+			}
+			if got != tt.wantCalVer {
+				t.Errorf("Expected calver format %q, got %q", tt.wantCalVer, got)
+			}
+		})
+	}
+}
+
+func TestLabelBumps(t *testing.T) {
+	cfg := Config{BumpLabels: map[string][]string{
+		"major": {"semver:major", "Breaking"},
+		"minor": {"semver:minor", "breaking"},
+		"patch": {"semver:patch"},
+	}}
+
+	got := cfg.LabelBumps()
+	want := map[string]BumpType{
+		"semver:major": BumpMajor,
+		"breaking":     BumpMajor,
+		"semver:minor": BumpMinor,
+		"semver:patch": BumpPatch,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for label, bump := range want {
+		if got[label] != bump {
+			t.Errorf("Expected %s to demand %s, got %s", label, bump, got[label])
+		}
+	}
+}
+
 func TestGetProjectRepos(t *testing.T) {
 	cfg := &Config{
 		Projects: map[string][]RepoConfig{
@@ -217,6 +594,72 @@ func TestGetBranch(t *testing.T) {
 	}
 }
 
+func TestNotesTemplatePath(t *testing.T) {
+	cfg := Config{
+		NotesTemplate:  "default.tmpl",
+		NotesTemplates: map[string]string{"web": "web.tmpl"},
+	}
+
+	tests := []struct {
+		project string
+		repo    RepoConfig
+		want    string
+	}{
+		{project: "web", repo: RepoConfig{Repo: "org/app", NotesTemplate: "app.tmpl"}, want: "app.tmpl"},
+		{project: "web", repo: RepoConfig{Repo: "org/site"}, want: "web.tmpl"},
+		{project: "api", repo: RepoConfig{Repo: "org/api"}, want: "default.tmpl"},
+	}
+	for _, tt := range tests {
+		if got := cfg.NotesTemplatePath(tt.project, tt.repo); got != tt.want {
+			t.Errorf("%s in %s: expected %s, got %s", tt.repo.Repo, tt.project, tt.want, got)
+		}
+	}
+
+	if got := (&Config{}).NotesTemplatePath("web", RepoConfig{Repo: "org/site"}); got != "" {
+		t.Errorf("Expected the built-in layout without templates, got %s", got)
+	}
+}
+
+func TestGetLineBranch(t *testing.T) {
+	line, _ := ParseVersionLine("1.4")
+
+	cfg := &Config{}
+	if branch := cfg.GetLineBranch(line); branch != "release/1.4" {
+		t.Errorf("Expected 'release/1.4' (default), got: %s", branch)
+	}
+
+	cfg.LineBranch = "maint-{line}.x"
+	if branch := cfg.GetLineBranch(line); branch != "maint-1.4.x" {
+		t.Errorf("Expected 'maint-1.4.x', got: %s", branch)
+	}
+}
+
+func TestClientOptions(t *testing.T) {
+	cfg := &Config{
+		GHToken: "token",
+		APIURL:  "https://ghe.example.com/api/v3",
+		WebURL:  "https://ghe.example.com",
+	}
+
+	defaults := cfg.ClientOptions(RepoConfig{Repo: "org/repo"})
+	if defaults.APIURL != cfg.APIURL || defaults.WebURL != cfg.WebURL || defaults.Token != "token" {
+		t.Errorf("Expected top-level settings, got: %+v", defaults)
+	}
+
+	override := cfg.ClientOptions(RepoConfig{Repo: "org/repo", APIURL: "https://other.example.com/api/v3"})
+	if override.APIURL != "https://other.example.com/api/v3" {
+		t.Errorf("Expected repo api_url to win, got: %s", override.APIURL)
+	}
+	if override.WebURL != "" {
+		t.Errorf("Expected top-level web_url not to leak onto a repo with its own api_url, got: %s", override.WebURL)
+	}
+
+	webOnly := cfg.ClientOptions(RepoConfig{Repo: "org/repo", WebURL: "https://mirror.example.com"})
+	if webOnly.APIURL != cfg.APIURL || webOnly.WebURL != "https://mirror.example.com" {
+		t.Errorf("Expected repo web_url over top-level api_url, got: %+v", webOnly)
+	}
+}
+
 func TestExpandTilde(t *testing.T) {
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Token environment variables are checked in order before any configured
+// token source.
+var (
+	tokenEnvVars       = []string{"VERSIONISTA_GITHUB_TOKEN", "GITHUB_TOKEN"}
+	gitlabTokenEnvVars = []string{"VERSIONISTA_GITLAB_TOKEN", "GITLAB_TOKEN"}
+	giteaTokenEnvVars  = []string{"VERSIONISTA_GITEA_TOKEN", "GITEA_TOKEN"}
+)
+
+// ResolveToken fills in GHToken from the first available source:
+//
+//  1. the VERSIONISTA_GITHUB_TOKEN or GITHUB_TOKEN environment variables
+//  2. the stdout of gh_token_command (e.g. `gh auth token` or a password manager)
+//  3. the contents of gh_token_file
+//  4. gh_token from the YAML file
+//
+// Nothing is resolved when a GitHub App is configured, since it mints its own
+// tokens. The GitLab and Gitea tokens are taken from the VERSIONISTA_GITLAB_TOKEN
+// or GITLAB_TOKEN and VERSIONISTA_GITEA_TOKEN or GITEA_TOKEN environment
+// variables, falling back to gitlab_token and gitea_token. The source used is
+// logged at debug level; the token never is.
+func (c *Config) ResolveToken(logger *Logger) error {
+	return c.resolveToken(os.Getenv, logger)
+}
+
+func (c *Config) resolveToken(getenv func(string) string, logger *Logger) error {
+	resolveEnvToken(&c.GitLabToken, "GitLab", gitlabTokenEnvVars, getenv, logger)
+	resolveEnvToken(&c.GiteaToken, "Gitea", giteaTokenEnvVars, getenv, logger)
+	return c.resolveGitHubToken(getenv, logger)
+}
+
+// resolveEnvToken replaces *token with the first of names that is set in the
+// environment.
+func resolveEnvToken(token *string, forge string, names []string, getenv func(string) string, logger *Logger) {
+	for _, name := range names {
+		if value := strings.TrimSpace(getenv(name)); value != "" {
+			logger.Debug("Using %s token from the %s environment variable", forge, name)
+			*token = value
+			return
+		}
+	}
+}
+
+func (c *Config) resolveGitHubToken(getenv func(string) string, logger *Logger) error {
+	if c.GitHubApp.Configured() {
+		logger.Debug("Using GitHub App %d for authentication", c.GitHubApp.AppID)
+		return nil
+	}
+
+	for _, name := range tokenEnvVars {
+		if token := strings.TrimSpace(getenv(name)); token != "" {
+			logger.Debug("Using GitHub token from the %s environment variable", name)
+			c.GHToken = token
+			return nil
+		}
+	}
+
+	if c.GHTokenCommand != "" {
+		token, err := runTokenCommand(c.GHTokenCommand)
+		if err != nil {
+			return err
+		}
+		logger.Debug("Using GitHub token from gh_token_command")
+		c.GHToken = token
+		return nil
+	}
+
+	if c.GHTokenFile != "" {
+		data, err := os.ReadFile(c.GHTokenFile)
+		if err != nil {
+			return fmt.Errorf("failed to read gh_token_file: %w", err)
+		}
+		token := strings.TrimSpace(string(data))
+		if token == "" {
+			return fmt.Errorf("gh_token_file %s is empty", c.GHTokenFile)
+		}
+		logger.Debug("Using GitHub token from gh_token_file %s", c.GHTokenFile)
+		c.GHToken = token
+		return nil
+	}
+
+	if c.GHToken != "" {
+		logger.Debug("Using GitHub token from gh_token in the configuration file")
+	}
+	return nil
+}
+
+// runTokenCommand runs command with the default shell and returns its trimmed
+// stdout. Only stderr is included in errors so a token printed before a
+// failure never ends up in logs.
+func runTokenCommand(command string) (string, error) {
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+
+	cmd := exec.Command(shell, "-c", command)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("gh_token_command %q failed: %w\n%s", command, err, strings.TrimSpace(stderr.String()))
+	}
+
+	token := strings.TrimSpace(stdout.String())
+	if token == "" {
+		return "", fmt.Errorf("gh_token_command %q printed no token", command)
+	}
+	return token, nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveToken(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("file-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		env         map[string]string
+		config      Config
+		expected    string
+		expectedLog string
+		expectError bool
+	}{
+		{
+			name:        "versionista env var wins",
+			env:         map[string]string{"VERSIONISTA_GITHUB_TOKEN": "env-token", "GITHUB_TOKEN": "other"},
+			config:      Config{GHToken: "yaml-token", GHTokenCommand: "echo command-token"},
+			expected:    "env-token",
+			expectedLog: "VERSIONISTA_GITHUB_TOKEN",
+		},
+		{
+			name:        "GITHUB_TOKEN env var",
+			env:         map[string]string{"GITHUB_TOKEN": "gh-env-token"},
+			config:      Config{GHToken: "yaml-token"},
+			expected:    "gh-env-token",
+			expectedLog: "GITHUB_TOKEN",
+		},
+		{
+			name:        "token command",
+			config:      Config{GHToken: "yaml-token", GHTokenCommand: "echo '  command-token  '", GHTokenFile: tokenFile},
+			expected:    "command-token",
+			expectedLog: "gh_token_command",
+		},
+		{
+			name:        "token file",
+			config:      Config{GHToken: "yaml-token", GHTokenFile: tokenFile},
+			expected:    "file-token",
+			expectedLog: "gh_token_file",
+		},
+		{
+			name:        "yaml fallback",
+			config:      Config{GHToken: "yaml-token"},
+			expected:    "yaml-token",
+			expectedLog: "configuration file",
+		},
+		{
+			name:     "no source leaves token empty",
+			config:   Config{},
+			expected: "",
+		},
+		{
+			name:        "failing command",
+			config:      Config{GHTokenCommand: "echo c2VjcmV0LW91dHB1dA== | base64 -d; exit 3"},
+			expectError: true,
+		},
+		{
+			name:        "command printing nothing",
+			config:      Config{GHTokenCommand: "true"},
+			expectError: true,
+		},
+		{
+			name:        "missing token file",
+			config:      Config{GHTokenFile: filepath.Join(t.TempDir(), "missing")},
+			expectError: true,
+		},
+		{
+			name:     "github app skips token resolution",
+			env:      map[string]string{"GITHUB_TOKEN": "env-token"},
+			config:   Config{GitHubApp: GitHubAppConfig{AppID: 1, InstallationID: 2, PrivateKeyPath: "app.pem"}},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			logger := NewLoggerWithLevel(DebugLevel)
+			logger.debugLogger = log.New(&logs, "", 0)
+
+			cfg := tt.config
+			err := cfg.resolveToken(func(name string) string { return tt.env[name] }, logger)
+
+			if tt.expectError {
+				if err == nil {
+					t.Fatal("Expected error but got none")
+				}
+				if strings.Contains(err.Error(), "secret-output") {
+					t.Errorf("Expected command stdout to stay out of errors, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if cfg.GHToken != tt.expected {
+				t.Errorf("Expected token %q, got %q", tt.expected, cfg.GHToken)
+			}
+			if !strings.Contains(logs.String(), tt.expectedLog) {
+				t.Errorf("Expected debug log to mention %q, got %q", tt.expectedLog, logs.String())
+			}
+			if cfg.GHToken != "" && strings.Contains(logs.String(), cfg.GHToken) {
+				t.Errorf("Token leaked into debug log: %q", logs.String())
+			}
+		})
+	}
+}
+
+func TestResolveForgeTokens(t *testing.T) {
+	cfg := Config{GHToken: "yaml-token", GitLabToken: "yaml-gitlab-token", GiteaToken: "yaml-gitea-token"}
+	env := map[string]string{"GITLAB_TOKEN": "env-gitlab-token", "VERSIONISTA_GITEA_TOKEN": "env-gitea-token"}
+	if err := cfg.resolveToken(func(name string) string { return env[name] }, NewLoggerWithLevel(ErrorLevel)); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if cfg.GitLabToken != "env-gitlab-token" {
+		t.Errorf("Expected GITLAB_TOKEN to take precedence, got %q", cfg.GitLabToken)
+	}
+	if cfg.GiteaToken != "env-gitea-token" {
+		t.Errorf("Expected VERSIONISTA_GITEA_TOKEN to take precedence, got %q", cfg.GiteaToken)
+	}
+	if cfg.GHToken != "yaml-token" {
+		t.Errorf("Expected the GitHub token to be resolved independently, got %q", cfg.GHToken)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Forge kinds accepted by the `forge` repository setting.
+const (
+	ForgeGitHub  = "github"
+	ForgeGitLab  = "gitlab"
+	ForgeGitea   = "gitea"
+	ForgeForgejo = "forgejo"
+)
+
+// Forge is the hosting service a repository lives on. The release manager
+// only talks to forges through this interface, so a project can mix
+// repositories hosted on GitHub, GitLab and Gitea or Forgejo.
+type Forge interface {
+	GetLatestRelease(ctx context.Context, repo *Repository) (*ForgeRelease, error)
+	GetReleaseByTag(ctx context.Context, repo *Repository, tag string) (*ForgeRelease, error)
+	// ListReleases returns every release, including drafts and pre-releases.
+	ListReleases(ctx context.Context, repo *Repository) ([]*ForgeRelease, error)
+	// ListTags returns the names of every tag in the repository.
+	ListTags(ctx context.Context, repo *Repository) ([]string, error)
+	// GetFile returns the contents of path at ref, or nil when there's no
+	// such file.
+	GetFile(ctx context.Context, repo *Repository, path, ref string) ([]byte, error)
+	// CreateRelease publishes release, tagging release.Target (the default
+	// branch when empty) if the tag doesn't exist yet.
+	CreateRelease(ctx context.Context, repo *Repository, release *ForgeRelease) (*ForgeRelease, error)
+	EditReleaseNotes(ctx context.Context, repo *Repository, release *ForgeRelease, notes string) error
+	UploadReleaseAsset(ctx context.Context, repo *Repository, release *ForgeRelease, path string) error
+
+	CompareCommits(ctx context.Context, repo *Repository, base, head string) (*Comparison, error)
+	ListCommitsSince(ctx context.Context, repo *Repository, head, stopSHA string) ([]Commit, error)
+	GetTagSHA(ctx context.Context, repo *Repository, tag string) (string, error)
+
+	GetPullRequest(ctx context.Context, repo *Repository, number int) (*PullRequest, error)
+	GetPullRequestComments(ctx context.Context, repo *Repository, number int) ([]string, error)
+	// GetPullRequestFiles lists the paths a pull request changes.
+	GetPullRequestFiles(ctx context.Context, repo *Repository, number int) ([]string, error)
+	GetLastNMergedPRs(ctx context.Context, repo *Repository, count int) ([]*PullRequest, error)
+
+	// ReleaseURL returns the browser URL of the release tagged tag.
+	ReleaseURL(repo *Repository, tag string) string
+	// PullRequestURL returns the browser URL of pull or merge request number.
+	PullRequestURL(repo *Repository, number int) string
+	// ChangeRef formats a pull or merge request number the way the forge
+	// links it in Markdown, e.g. #12 on GitHub and !12 on GitLab.
+	ChangeRef(number int) string
+}
+
+// tagMover is implemented by forges that can move an existing tag to another
+// commit, which appending to a release relies on.
+type tagMover interface {
+	UpdateTagRef(ctx context.Context, repo *Repository, tag, sha string) error
+}
+
+// pullRequestBatcher is implemented by forges that can fetch many pull
+// requests, with their comments, in a few requests.
+type pullRequestBatcher interface {
+	GetPullRequestsBatch(ctx context.Context, repo *Repository, numbers []int) (map[int]*PullRequest, error)
+}
+
+// commitPullRequestLister is implemented by forges that can list the pull
+// requests a commit belongs to, which finds the pull request of a squashed or
+// rebased commit whose message doesn't reference it.
+type commitPullRequestLister interface {
+	ListCommitPullRequests(ctx context.Context, repo *Repository, sha string) ([]*PullRequest, error)
+}
+
+// commitPullRequestBatcher is implemented by forges that can list the pull
+// requests of many commits, with their comments, in a few requests.
+type commitPullRequestBatcher interface {
+	GetCommitPullRequestsBatch(ctx context.Context, repo *Repository, shas []string) (map[string][]*PullRequest, error)
+}
+
+// ForgeRelease is a release as the forges describe it. ID is only meaningful
+// to forges that address releases by number rather than by tag. NotLatest
+// keeps a maintenance release from becoming the repository's latest release
+// on forges that flag one (GitHub).
+type ForgeRelease struct {
+	ID         int64
+	TagName    string
+	Name       string
+	Body       string
+	Target     string
+	Draft      bool
+	Prerelease bool
+	NotLatest  bool
+}
+
+// Comparison lists the commits in base...head, oldest first. Commits may stop
+// short of TotalCommits when the forge caps the size of a comparison.
+type Comparison struct {
+	Commits      []Commit
+	TotalCommits int
+	MergeBaseSHA string
+}
+
+// Commit is a commit as the forges list it. Parents lists the SHAs of its
+// parents, first parent first. PullRequest is the number of the pull request
+// the commit was merged with once a changelog has looked it up, and 0 before
+// or when it has none.
+type Commit struct {
+	SHA         string
+	Message     string
+	Author      string
+	Date        time.Time
+	Parents     []string
+	PullRequest int
+}
+
+// PullRequest is a GitHub pull request or a GitLab merge request. Comments is
+// nil when they weren't fetched alongside the pull request and must be
+// requested separately.
+type PullRequest struct {
+	Number     int
+	Title      string
+	Body       string
+	Author     string
+	BaseBranch string
+	MergedAt   time.Time
+	Labels     []string
+	Comments   []string
+}
+
+// ForgeKind returns the forge the repository is hosted on, defaulting to
+// GitHub. Forgejo shares Gitea's API and is reported as Gitea.
+func (r RepoConfig) ForgeKind() string {
+	switch r.Forge {
+	case "":
+		return ForgeGitHub
+	case ForgeForgejo:
+		return ForgeGitea
+	}
+	return r.Forge
+}
+
+func validateForgeKind(kind string) error {
+	switch kind {
+	case ForgeGitHub, ForgeGitLab, ForgeGitea:
+		return nil
+	}
+	return fmt.Errorf("unknown forge %q (expected %s, %s, %s or %s)", kind, ForgeGitHub, ForgeGitLab, ForgeGitea, ForgeForgejo)
+}
@@ -0,0 +1,439 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// giteaPageSize is the page size requested from list endpoints. Gitea caps
+// it at the instance's MAX_RESPONSE_ITEMS, 50 by default.
+const giteaPageSize = 50
+
+// GiteaOptions describes how to reach a Gitea or Forgejo instance. BaseURL is
+// the instance root (e.g. https://forgejo.example.com); the API is expected
+// beneath it at /api/v1/.
+type GiteaOptions struct {
+	Token    string
+	BaseURL  string
+	CacheDir string
+	Timeout  time.Duration
+	Logger   *Logger
+}
+
+// GiteaClient is the Forge for repositories hosted on Gitea or Forgejo, whose
+// API follows GitHub's closely enough that releases, pull requests and
+// attachments map one to one.
+type GiteaClient struct {
+	rest   *restClient
+	webURL *url.URL
+}
+
+// NewGiteaClient builds a client for the instance described by opts. It fails
+// when BaseURL is missing or cannot be parsed.
+func NewGiteaClient(opts GiteaOptions) (*GiteaClient, error) {
+	logger := opts.Logger
+	if logger == nil {
+		logger = NewLogger()
+	}
+
+	if opts.BaseURL == "" {
+		return nil, fmt.Errorf("api_url is required for Gitea repositories")
+	}
+	webURL, err := parseBaseURL(opts.BaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid api_url %q: %w", opts.BaseURL, err)
+	}
+	// Accept the API root as well as the instance root.
+	webURL.Path = strings.TrimSuffix(webURL.Path, "api/v1/")
+	apiURL := *webURL
+	apiURL.Path += "api/v1/"
+
+	authorization := ""
+	if opts.Token != "" {
+		authorization = "token " + opts.Token
+	}
+
+	return &GiteaClient{
+		rest:   newRestClient(&apiURL, authorization, opts.CacheDir, opts.Timeout, logger),
+		webURL: webURL,
+	}, nil
+}
+
+func giteaRepoPath(repo *Repository) string {
+	return fmt.Sprintf("repos/%s/%s", url.PathEscape(repo.Owner), url.PathEscape(repo.Name))
+}
+
+type giteaRelease struct {
+	ID              int64  `json:"id"`
+	TagName         string `json:"tag_name"`
+	Name            string `json:"name"`
+	Body            string `json:"body"`
+	TargetCommitish string `json:"target_commitish"`
+	Draft           bool   `json:"draft"`
+	Prerelease      bool   `json:"prerelease"`
+}
+
+func (r *giteaRelease) toForgeRelease() *ForgeRelease {
+	return &ForgeRelease{
+		ID:         r.ID,
+		TagName:    r.TagName,
+		Name:       r.Name,
+		Body:       r.Body,
+		Target:     r.TargetCommitish,
+		Draft:      r.Draft,
+		Prerelease: r.Prerelease,
+	}
+}
+
+type giteaCommit struct {
+	SHA    string `json:"sha"`
+	Commit struct {
+		Message string `json:"message"`
+		Author  struct {
+			Name string    `json:"name"`
+			Date time.Time `json:"date"`
+		} `json:"author"`
+	} `json:"commit"`
+	Parents []struct {
+		SHA string `json:"sha"`
+	} `json:"parents"`
+}
+
+func (c giteaCommit) toCommit() Commit {
+	commit := Commit{
+		SHA:     c.SHA,
+		Message: c.Commit.Message,
+		Author:  c.Commit.Author.Name,
+		Date:    c.Commit.Author.Date,
+	}
+	for _, parent := range c.Parents {
+		commit.Parents = append(commit.Parents, parent.SHA)
+	}
+	return commit
+}
+
+type giteaPullRequest struct {
+	Number   int        `json:"number"`
+	Title    string     `json:"title"`
+	Body     string     `json:"body"`
+	MergedAt *time.Time `json:"merged_at"`
+	User     struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+}
+
+func (p *giteaPullRequest) toPullRequest() *PullRequest {
+	pr := &PullRequest{
+		Number:     p.Number,
+		Title:      p.Title,
+		Body:       p.Body,
+		Author:     p.User.Login,
+		BaseBranch: p.Base.Ref,
+	}
+	if p.MergedAt != nil {
+		pr.MergedAt = *p.MergedAt
+	}
+	for _, label := range p.Labels {
+		pr.Labels = append(pr.Labels, label.Name)
+	}
+	return pr
+}
+
+func (c *GiteaClient) GetLatestRelease(ctx context.Context, repo *Repository) (*ForgeRelease, error) {
+	var release giteaRelease
+	if _, err := c.rest.do(ctx, http.MethodGet, giteaRepoPath(repo)+"/releases/latest", nil, nil, &release); err != nil {
+		return nil, fmt.Errorf("failed to get latest release for %s: %w", repo, err)
+	}
+	return release.toForgeRelease(), nil
+}
+
+func (c *GiteaClient) GetReleaseByTag(ctx context.Context, repo *Repository, tag string) (*ForgeRelease, error) {
+	var release giteaRelease
+	if _, err := c.rest.do(ctx, http.MethodGet, giteaRepoPath(repo)+"/releases/tags/"+url.PathEscape(tag), nil, nil, &release); err != nil {
+		return nil, fmt.Errorf("failed to get release %s for %s: %w", tag, repo, err)
+	}
+	return release.toForgeRelease(), nil
+}
+
+func (c *GiteaClient) ListReleases(ctx context.Context, repo *Repository) ([]*ForgeRelease, error) {
+	var releases []*ForgeRelease
+	for page := 1; ; page++ {
+		query := url.Values{
+			"limit": {strconv.Itoa(giteaPageSize)},
+			"page":  {strconv.Itoa(page)},
+		}
+		var result []giteaRelease
+		if _, err := c.rest.do(ctx, http.MethodGet, giteaRepoPath(repo)+"/releases", query, nil, &result); err != nil {
+			return nil, fmt.Errorf("failed to get releases for %s: %w", repo, err)
+		}
+		for i := range result {
+			releases = append(releases, result[i].toForgeRelease())
+		}
+		if len(result) < giteaPageSize {
+			break
+		}
+	}
+	return releases, nil
+}
+
+func (c *GiteaClient) ListTags(ctx context.Context, repo *Repository) ([]string, error) {
+	var names []string
+	for page := 1; ; page++ {
+		query := url.Values{
+			"limit": {strconv.Itoa(giteaPageSize)},
+			"page":  {strconv.Itoa(page)},
+		}
+		var tags []struct {
+			Name string `json:"name"`
+		}
+		if _, err := c.rest.do(ctx, http.MethodGet, giteaRepoPath(repo)+"/tags", query, nil, &tags); err != nil {
+			return nil, fmt.Errorf("failed to get tags for %s: %w", repo, err)
+		}
+		for _, tag := range tags {
+			names = append(names, tag.Name)
+		}
+		if len(tags) < giteaPageSize {
+			break
+		}
+	}
+	return names, nil
+}
+
+func (c *GiteaClient) GetFile(ctx context.Context, repo *Repository, path, ref string) ([]byte, error) {
+	var file struct {
+		Content string `json:"content"`
+	}
+	query := url.Values{"ref": {ref}}
+	resp, err := c.rest.do(ctx, http.MethodGet, giteaRepoPath(repo)+"/contents/"+path, query, nil, &file)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s at %s for %s: %w", path, ref, repo, err)
+	}
+	return decodeFileContent(file.Content, path, ref, repo)
+}
+
+func (c *GiteaClient) CreateRelease(ctx context.Context, repo *Repository, release *ForgeRelease) (*ForgeRelease, error) {
+	payload := map[string]interface{}{
+		"tag_name":   release.TagName,
+		"name":       release.Name,
+		"body":       release.Body,
+		"draft":      release.Draft,
+		"prerelease": release.Prerelease,
+	}
+	if release.Target != "" {
+		payload["target_commitish"] = release.Target
+	}
+
+	var created giteaRelease
+	if _, err := c.rest.do(ctx, http.MethodPost, giteaRepoPath(repo)+"/releases", nil, payload, &created); err != nil {
+		return nil, fmt.Errorf("failed to create release for %s: %w", repo, err)
+	}
+	return created.toForgeRelease(), nil
+}
+
+func (c *GiteaClient) EditReleaseNotes(ctx context.Context, repo *Repository, release *ForgeRelease, notes string) error {
+	payload := map[string]string{"body": notes}
+	if _, err := c.rest.do(ctx, http.MethodPatch, fmt.Sprintf("%s/releases/%d", giteaRepoPath(repo), release.ID), nil, payload, nil); err != nil {
+		return fmt.Errorf("failed to edit release %s for %s: %w", release.TagName, repo, err)
+	}
+	return nil
+}
+
+// UploadReleaseAsset attaches the file to the release. The multipart body is
+// built in memory so the request can be resent after a rate limit.
+func (c *GiteaClient) UploadReleaseAsset(ctx context.Context, repo *Repository, release *ForgeRelease, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to open asset %s: %w", path, err)
+	}
+
+	name := filepath.Base(path)
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("attachment", name)
+	if err != nil {
+		return err
+	}
+	part.Write(data)
+	if err := form.Close(); err != nil {
+		return err
+	}
+
+	query := url.Values{"name": {name}}
+	assetPath := fmt.Sprintf("%s/releases/%d/assets", giteaRepoPath(repo), release.ID)
+	if _, err := c.rest.do(ctx, http.MethodPost, assetPath, query, rawBody{form.FormDataContentType(), body.Bytes()}, nil); err != nil {
+		return fmt.Errorf("failed to upload asset %s to release %s for %s: %w", path, release.TagName, repo, err)
+	}
+	return nil
+}
+
+// CompareCommits compares base...head. Gitea lists every commit in one
+// response, newest first, so they're reversed to match the other forges.
+func (c *GiteaClient) CompareCommits(ctx context.Context, repo *Repository, base, head string) (*Comparison, error) {
+	comparePath := fmt.Sprintf("%s/compare/%s...%s", giteaRepoPath(repo), url.PathEscape(base), url.PathEscape(head))
+	var result struct {
+		Commits []giteaCommit `json:"commits"`
+	}
+	if _, err := c.rest.do(ctx, http.MethodGet, comparePath, nil, nil, &result); err != nil {
+		return nil, fmt.Errorf("failed to compare commits %s...%s for %s: %w", base, head, repo, err)
+	}
+
+	comparison := &Comparison{TotalCommits: len(result.Commits)}
+	for i := len(result.Commits) - 1; i >= 0; i-- {
+		comparison.Commits = append(comparison.Commits, result.Commits[i].toCommit())
+	}
+	return comparison, nil
+}
+
+// ListCommitsSince walks the commit history of head, newest first, until it
+// reaches stopSHA, and returns the commits in between oldest first.
+func (c *GiteaClient) ListCommitsSince(ctx context.Context, repo *Repository, head, stopSHA string) ([]Commit, error) {
+	var commits []Commit
+	for page := 1; ; page++ {
+		query := url.Values{
+			"sha":          {head},
+			"limit":        {strconv.Itoa(giteaPageSize)},
+			"page":         {strconv.Itoa(page)},
+			"stat":         {"false"},
+			"verification": {"false"},
+			"files":        {"false"},
+		}
+		var result []giteaCommit
+		if _, err := c.rest.do(ctx, http.MethodGet, giteaRepoPath(repo)+"/commits", query, nil, &result); err != nil {
+			return nil, fmt.Errorf("failed to list commits of %s for %s: %w", head, repo, err)
+		}
+
+		for _, commit := range result {
+			if commit.SHA == stopSHA {
+				for i, j := 0, len(commits)-1; i < j; i, j = i+1, j-1 {
+					commits[i], commits[j] = commits[j], commits[i]
+				}
+				return commits, nil
+			}
+			commits = append(commits, commit.toCommit())
+		}
+		if len(result) < giteaPageSize {
+			break
+		}
+	}
+
+	return nil, fmt.Errorf("commit %s is not an ancestor of %s in %s", shortSHA(stopSHA), head, repo)
+}
+
+func (c *GiteaClient) GetTagSHA(ctx context.Context, repo *Repository, tag string) (string, error) {
+	var result struct {
+		Commit struct {
+			SHA string `json:"sha"`
+		} `json:"commit"`
+	}
+	if _, err := c.rest.do(ctx, http.MethodGet, giteaRepoPath(repo)+"/tags/"+url.PathEscape(tag), nil, nil, &result); err != nil {
+		return "", fmt.Errorf("failed to get tag %s for %s: %w", tag, repo, err)
+	}
+	return result.Commit.SHA, nil
+}
+
+func (c *GiteaClient) GetPullRequest(ctx context.Context, repo *Repository, number int) (*PullRequest, error) {
+	var pr giteaPullRequest
+	if _, err := c.rest.do(ctx, http.MethodGet, fmt.Sprintf("%s/pulls/%d", giteaRepoPath(repo), number), nil, nil, &pr); err != nil {
+		return nil, fmt.Errorf("failed to get pull request #%d for %s: %w", number, repo, err)
+	}
+	return pr.toPullRequest(), nil
+}
+
+func (c *GiteaClient) GetPullRequestComments(ctx context.Context, repo *Repository, number int) ([]string, error) {
+	var comments []struct {
+		Body string `json:"body"`
+	}
+	if _, err := c.rest.do(ctx, http.MethodGet, fmt.Sprintf("%s/issues/%d/comments", giteaRepoPath(repo), number), nil, nil, &comments); err != nil {
+		return nil, fmt.Errorf("failed to get comments for PR #%d in %s: %w", number, repo, err)
+	}
+
+	bodies := make([]string, 0, len(comments))
+	for _, comment := range comments {
+		bodies = append(bodies, comment.Body)
+	}
+	return bodies, nil
+}
+
+func (c *GiteaClient) GetPullRequestFiles(ctx context.Context, repo *Repository, number int) ([]string, error) {
+	var paths []string
+	for page := 1; ; page++ {
+		query := url.Values{
+			"limit": {strconv.Itoa(giteaPageSize)},
+			"page":  {strconv.Itoa(page)},
+		}
+		var files []struct {
+			Filename string `json:"filename"`
+		}
+		if _, err := c.rest.do(ctx, http.MethodGet, fmt.Sprintf("%s/pulls/%d/files", giteaRepoPath(repo), number), query, nil, &files); err != nil {
+			return nil, fmt.Errorf("failed to get files for PR #%d in %s: %w", number, repo, err)
+		}
+		for _, file := range files {
+			paths = append(paths, file.Filename)
+		}
+		if len(files) < giteaPageSize {
+			break
+		}
+	}
+	return paths, nil
+}
+
+func (c *GiteaClient) GetLastNMergedPRs(ctx context.Context, repo *Repository, count int) ([]*PullRequest, error) {
+	var prs []*PullRequest
+	for page := 1; len(prs) < count; page++ {
+		query := url.Values{
+			"state": {"closed"},
+			"sort":  {"recentupdate"},
+			"limit": {strconv.Itoa(giteaPageSize)},
+			"page":  {strconv.Itoa(page)},
+		}
+		var result []giteaPullRequest
+		if _, err := c.rest.do(ctx, http.MethodGet, giteaRepoPath(repo)+"/pulls", query, nil, &result); err != nil {
+			return nil, fmt.Errorf("failed to get pull requests for %s: %w", repo, err)
+		}
+
+		for i := range result {
+			// Closed but unmerged pull requests have no merge time.
+			if result[i].MergedAt == nil {
+				continue
+			}
+			prs = append(prs, result[i].toPullRequest())
+			if len(prs) == count {
+				break
+			}
+		}
+		if len(result) < giteaPageSize {
+			break
+		}
+	}
+	return prs, nil
+}
+
+func (c *GiteaClient) ReleaseURL(repo *Repository, tag string) string {
+	return fmt.Sprintf("%s%s/%s/releases/tag/%s", c.webURL, repo.Owner, repo.Name, url.PathEscape(tag))
+}
+
+func (c *GiteaClient) PullRequestURL(repo *Repository, number int) string {
+	return fmt.Sprintf("%s%s/%s/pulls/%d", c.webURL, repo.Owner, repo.Name, number)
+}
+
+func (c *GiteaClient) ChangeRef(number int) string {
+	return fmt.Sprintf("#%d", number)
+}
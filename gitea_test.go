@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewGiteaClientURLs(t *testing.T) {
+	repo := &Repository{Owner: "tools", Name: "deployer"}
+
+	for _, baseURL := range []string{"https://forgejo.example.com", "https://forgejo.example.com/api/v1"} {
+		client, err := NewGiteaClient(GiteaOptions{BaseURL: baseURL})
+		if err != nil {
+			t.Fatalf("%s: expected no error, got: %v", baseURL, err)
+		}
+		if got := client.rest.baseURL.String(); got != "https://forgejo.example.com/api/v1/" {
+			t.Errorf("%s: unexpected API root %s", baseURL, got)
+		}
+		if got := client.ReleaseURL(repo, "v1.2.3"); got != "https://forgejo.example.com/tools/deployer/releases/tag/v1.2.3" {
+			t.Errorf("%s: unexpected release URL %s", baseURL, got)
+		}
+		if got := client.PullRequestURL(repo, 7); got != "https://forgejo.example.com/tools/deployer/pulls/7" {
+			t.Errorf("%s: unexpected pull request URL %s", baseURL, got)
+		}
+	}
+
+	if _, err := NewGiteaClient(GiteaOptions{}); err == nil {
+		t.Error("Expected error when no base URL is configured")
+	}
+}
+
+// newGiteaTestServer serves a canned repository under /api/v1, failing the
+// test on unauthenticated requests.
+func newGiteaTestServer(t *testing.T, handler http.HandlerFunc) *GiteaClient {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token secret" {
+			t.Errorf("Expected token authorization, got %q", r.Header.Get("Authorization"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewGiteaClient(GiteaOptions{Token: "secret", BaseURL: server.URL, Logger: NewLoggerWithLevel(ErrorLevel)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestGiteaCompareAndPullRequests(t *testing.T) {
+	client := newGiteaTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/repos/tools/deployer/compare/v1.0.0...main":
+			w.Write([]byte(`{"total_commits": 2, "commits": [
+			  {"sha": "bbb", "commit": {"message": "Merge pull request 'Add retries' (#7) from retries into main", "author": {"name": "Bob"}}, "parents": [{"sha": "aaa"}, {"sha": "wip"}]},
+			  {"sha": "aaa", "commit": {"message": "Fix typo (#6)", "author": {"name": "Ann", "date": "2024-05-01T10:00:00Z"}}}
+			]}`))
+		case "/api/v1/repos/tools/deployer/pulls/7":
+			w.Write([]byte(`{"number": 7, "title": "Add retries", "body": "Fixes OPS-3", "merged_at": "2024-05-02T10:00:00Z",
+			  "user": {"login": "bob"}, "labels": [{"name": "enhancement"}], "base": {"ref": "main"}}`))
+		case "/api/v1/repos/tools/deployer/issues/7/comments":
+			w.Write([]byte(`[{"body": "see OPS-4"}]`))
+		case "/api/v1/repos/tools/deployer/pulls":
+			if r.URL.Query().Get("state") != "closed" {
+				t.Errorf("Expected closed pull requests to be listed, got %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`[{"number": 9, "title": "Abandoned"}, {"number": 8, "title": "Merged", "merged_at": "2024-05-03T10:00:00Z"}]`))
+		default:
+			t.Errorf("Unexpected request %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	repo := &Repository{Owner: "tools", Name: "deployer"}
+
+	comparison, err := client.CompareCommits(context.Background(), repo, "v1.0.0", "main")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(comparison.Commits) != 2 || comparison.Commits[0].SHA != "aaa" || comparison.Commits[1].SHA != "bbb" {
+		t.Fatalf("Expected commits oldest first, got %+v", comparison.Commits)
+	}
+	if strings.Join(comparison.Commits[1].Parents, ",") != "aaa,wip" {
+		t.Errorf("Expected the merge commit's parents, first parent first, got %v", comparison.Commits[1].Parents)
+	}
+	number, err := ParsePRNumber(comparison.Commits[1].Message)
+	if err != nil || number != 7 {
+		t.Fatalf("Expected pull request 7 to be found in the merge commit, got %d, %v", number, err)
+	}
+
+	pr, err := client.GetPullRequest(context.Background(), repo, number)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if pr.Title != "Add retries" || pr.Author != "bob" || pr.BaseBranch != "main" || len(pr.Labels) != 1 || pr.Labels[0] != "enhancement" {
+		t.Errorf("Unexpected pull request: %+v", pr)
+	}
+
+	comments, err := client.GetPullRequestComments(context.Background(), repo, number)
+	if err != nil || len(comments) != 1 || comments[0] != "see OPS-4" {
+		t.Errorf("Expected one comment, got %q, %v", comments, err)
+	}
+
+	prs, err := client.GetLastNMergedPRs(context.Background(), repo, 10)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(prs) != 1 || prs[0].Number != 8 {
+		t.Errorf("Expected only the merged pull request, got %+v", prs)
+	}
+}
+
+func TestGiteaCreateReleaseWithAttachment(t *testing.T) {
+	var created map[string]interface{}
+	var attachment, edited string
+	client := newGiteaTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /api/v1/repos/tools/deployer/releases":
+			json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": 31, "tag_name": "v1.1.0", "name": "v1.1.0"}`))
+		case "POST /api/v1/repos/tools/deployer/releases/31/assets":
+			file, header, err := r.FormFile("attachment")
+			if err != nil {
+				t.Fatalf("Expected a multipart attachment, got: %v", err)
+			}
+			data, _ := io.ReadAll(file)
+			attachment = header.Filename + ":" + string(data)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{}`))
+		case "PATCH /api/v1/repos/tools/deployer/releases/31":
+			var payload map[string]string
+			json.NewDecoder(r.Body).Decode(&payload)
+			edited = payload["body"]
+			w.Write([]byte(`{}`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	repo := &Repository{Owner: "tools", Name: "deployer"}
+
+	release, err := client.CreateRelease(context.Background(), repo, &ForgeRelease{TagName: "v1.1.0", Name: "v1.1.0", Body: "notes", Target: "abc123"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if created["target_commitish"] != "abc123" || created["body"] != "notes" {
+		t.Errorf("Expected the release to target abc123 with notes, got %v", created)
+	}
+
+	asset := filepath.Join(t.TempDir(), "deployer.tar.gz")
+	if err := os.WriteFile(asset, []byte("archive"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.UploadReleaseAsset(context.Background(), repo, release, asset); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if attachment != "deployer.tar.gz:archive" {
+		t.Errorf("Unexpected attachment %q", attachment)
+	}
+
+	if err := client.EditReleaseNotes(context.Background(), repo, release, "more notes"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if edited != "more notes" {
+		t.Errorf("Expected the release body to be edited, got %q", edited)
+	}
+}
+
+func TestGiteaListTags(t *testing.T) {
+	client := newGiteaTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/repos/tools/deployer/tags" {
+			t.Errorf("Unexpected request %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`[{"name": "v3.0.0"}, {"name": "4.0.0"}]`))
+	})
+
+	tags, err := client.ListTags(context.Background(), &Repository{Owner: "tools", Name: "deployer"})
+	if err != nil || strings.Join(tags, ",") != "v3.0.0,4.0.0" {
+		t.Errorf("Expected v3.0.0 and 4.0.0, got %q, %v", tags, err)
+	}
+}
+
+func TestGiteaGetFile(t *testing.T) {
+	content := base64.StdEncoding.EncodeToString([]byte("changelog: {}\n"))
+	client := newGiteaTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("ref") != "main" {
+			t.Errorf("Expected the file to be read at main, got %q", r.URL.Query().Get("ref"))
+		}
+		switch r.URL.Path {
+		case "/api/v1/repos/tools/deployer/contents/.github/release.yml":
+			w.Write([]byte(`{"content": "` + content + `"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "not found"}`))
+		}
+	})
+	repo := &Repository{Owner: "tools", Name: "deployer"}
+
+	data, err := client.GetFile(context.Background(), repo, ".github/release.yml", "main")
+	if err != nil || string(data) != "changelog: {}\n" {
+		t.Errorf("Expected the decoded file, got %q, %v", data, err)
+	}
+	data, err = client.GetFile(context.Background(), repo, ".github/release.yaml", "main")
+	if err != nil || data != nil {
+		t.Errorf("Expected a missing file to be nil, got %q, %v", data, err)
+	}
+}
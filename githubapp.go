@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	// appJWTLifetime stays under GitHub's ten minute maximum.
+	appJWTLifetime = 9 * time.Minute
+	// appTokenRefreshMargin makes installation tokens (valid for an hour) look
+	// expired early, so a token never runs out halfway through a release.
+	appTokenRefreshMargin = 5 * time.Minute
+)
+
+// GitHubAppConfig identifies a GitHub App installation to authenticate as,
+// instead of a personal access token.
+type GitHubAppConfig struct {
+	AppID          int64  `mapstructure:"app_id"`
+	InstallationID int64  `mapstructure:"installation_id"`
+	PrivateKeyPath string `mapstructure:"private_key_path"`
+}
+
+// Configured reports whether any GitHub App setting is present.
+func (a GitHubAppConfig) Configured() bool {
+	return a.AppID != 0 || a.InstallationID != 0 || a.PrivateKeyPath != ""
+}
+
+// Validate checks that every setting needed to mint tokens is present.
+func (a GitHubAppConfig) Validate() error {
+	switch {
+	case a.AppID == 0:
+		return fmt.Errorf("github_app.app_id is required")
+	case a.InstallationID == 0:
+		return fmt.Errorf("github_app.installation_id is required")
+	case a.PrivateKeyPath == "":
+		return fmt.Errorf("github_app.private_key_path is required")
+	}
+	return nil
+}
+
+// appTokenSource mints installation access tokens for a GitHub App. Each
+// Token call signs a short-lived JWT with the app's private key and exchanges
+// it for an installation token; appTransport reuses the token until it's
+// about to expire.
+type appTokenSource struct {
+	app        GitHubAppConfig
+	key        *rsa.PrivateKey
+	tokenURL   string
+	httpClient *http.Client
+	logger     *Logger
+	now        func() time.Time
+}
+
+func newAppTokenSource(app GitHubAppConfig, apiURL *url.URL, httpClient *http.Client, logger *Logger) (*appTokenSource, error) {
+	if err := app.Validate(); err != nil {
+		return nil, err
+	}
+	key, err := loadAppPrivateKey(app.PrivateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	return &appTokenSource{
+		app:        app,
+		key:        key,
+		tokenURL:   fmt.Sprintf("%sapp/installations/%d/access_tokens", apiURL, app.InstallationID),
+		httpClient: httpClient,
+		logger:     logger,
+		now:        time.Now,
+	}, nil
+}
+
+// Token exchanges a new JWT for an installation token. The exchange is
+// cancelled along with ctx.
+func (s *appTokenSource) Token(ctx context.Context) (*oauth2.Token, error) {
+	jwt, err := s.signJWT()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.tokenURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request installation token for GitHub App %d: %w", s.app.AppID, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read installation token for GitHub App %d: %w", s.app.AppID, err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("failed to get installation token for GitHub App %d (installation %d): %s: %s",
+			s.app.AppID, s.app.InstallationID, resp.Status, bytes.TrimSpace(body))
+	}
+
+	var result struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse installation token for GitHub App %d: %w", s.app.AppID, err)
+	}
+
+	s.logger.Debug("Minted installation token for GitHub App %d, expires at %s", s.app.AppID, result.ExpiresAt.Format(time.RFC3339))
+	return &oauth2.Token{
+		AccessToken: result.Token,
+		Expiry:      result.ExpiresAt.Add(-appTokenRefreshMargin),
+	}, nil
+}
+
+// appTransport authorizes requests with an installation token of source,
+// minting one with the context of the request that needs it, so --timeout
+// and interrupts cancel the token exchange like any other request.
+type appTransport struct {
+	source *appTokenSource
+	base   http.RoundTripper
+
+	mu    sync.Mutex
+	token *oauth2.Token
+}
+
+func (t *appTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.validToken(req.Context())
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	authorized := req.Clone(req.Context())
+	token.SetAuthHeader(authorized)
+	return t.base.RoundTrip(authorized)
+}
+
+func (t *appTransport) validToken(ctx context.Context) (*oauth2.Token, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.token.Valid() {
+		return t.token, nil
+	}
+	token, err := t.source.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t.token = token
+	return token, nil
+}
+
+// signJWT returns an RS256 JWT identifying the app, as GitHub requires for
+// the installation token exchange. iat is backdated a minute to allow for
+// clock drift.
+func (s *appTokenSource) signJWT() (string, error) {
+	now := s.now()
+	header := map[string]string{"alg": "RS256", "typ": "JWT"}
+	claims := map[string]interface{}{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": fmt.Sprint(s.app.AppID),
+	}
+
+	var segments []string
+	for _, part := range []interface{}{header, claims} {
+		encoded, err := json.Marshal(part)
+		if err != nil {
+			return "", err
+		}
+		segments = append(segments, base64.RawURLEncoding.EncodeToString(encoded))
+	}
+
+	signingInput := segments[0] + "." + segments[1]
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign GitHub App JWT: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// loadAppPrivateKey reads the PEM private key GitHub generates for an app
+// (PKCS#1), also accepting PKCS#8.
+func loadAppPrivateKey(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GitHub App private key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("GitHub App private key %s is not PEM encoded", path)
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub App private key %s: %w", path, err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("GitHub App private key %s is not an RSA key", path)
+	}
+	return key, nil
+}
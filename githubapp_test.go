@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTestAppKey generates an RSA key, writes it as a PKCS#1 PEM file and
+// returns the path and the key.
+func writeTestAppKey(t *testing.T) (string, *rsa.PrivateKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "app.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path, key
+}
+
+// verifyAppJWT checks the JWT signature and returns its claims.
+func verifyAppJWT(t *testing.T, token string, key *rsa.PrivateKey) map[string]interface{} {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("Expected a three part JWT, got %q", token)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+		t.Fatalf("JWT signature does not verify: %v", err)
+	}
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims map[string]interface{}
+	json.Unmarshal(payload, &claims)
+	return claims
+}
+
+func TestGitHubAppAuthentication(t *testing.T) {
+	tests := []struct {
+		name              string
+		tokenLifetime     time.Duration
+		expectedExchanges int
+	}{
+		{"token reused while valid", time.Hour, 1},
+		{"token refreshed before it expires", 2 * time.Minute, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyPath, key := writeTestAppKey(t)
+			exchanges := 0
+			var apiAuth []string
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/app/installations/99/access_tokens" {
+					exchanges++
+					if r.Method != http.MethodPost {
+						t.Errorf("Expected POST for token exchange, got %s", r.Method)
+					}
+					claims := verifyAppJWT(t, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), key)
+					if claims["iss"] != "42" {
+						t.Errorf("Expected iss 42, got %v", claims["iss"])
+					}
+					w.WriteHeader(http.StatusCreated)
+					json.NewEncoder(w).Encode(map[string]interface{}{
+						"token":      fmt.Sprintf("ghs_%d", exchanges),
+						"expires_at": time.Now().Add(tt.tokenLifetime).UTC().Format(time.RFC3339),
+					})
+					return
+				}
+				apiAuth = append(apiAuth, r.Header.Get("Authorization"))
+				w.Write([]byte(`{"tag_name": "v1.0.0"}`))
+			}))
+			defer server.Close()
+
+			client, err := NewClientWithOptions(ClientOptions{
+				Token:  "ignored",
+				App:    GitHubAppConfig{AppID: 42, InstallationID: 99, PrivateKeyPath: keyPath},
+				APIURL: server.URL,
+			})
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			repo := &Repository{Owner: "org", Name: "repo"}
+			for i := 0; i < 2; i++ {
+				if _, err := client.GetLatestRelease(context.Background(), repo); err != nil {
+					t.Fatalf("Expected no error, got: %v", err)
+				}
+			}
+
+			if exchanges != tt.expectedExchanges {
+				t.Errorf("Expected %d token exchanges, got %d", tt.expectedExchanges, exchanges)
+			}
+			if apiAuth[0] != "Bearer ghs_1" {
+				t.Errorf("Expected API calls to use the installation token, got %q", apiAuth[0])
+			}
+		})
+	}
+}
+
+func TestGitHubAppTokenExchangeFailure(t *testing.T) {
+	keyPath, _ := writeTestAppKey(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"message": "A JSON web token could not be decoded"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(ClientOptions{
+		App:    GitHubAppConfig{AppID: 42, InstallationID: 99, PrivateKeyPath: keyPath},
+		APIURL: server.URL,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	_, err = client.GetLatestRelease(context.Background(), &Repository{Owner: "org", Name: "repo"})
+	if err == nil || !strings.Contains(err.Error(), "could not be decoded") {
+		t.Errorf("Expected the token exchange error to surface, got: %v", err)
+	}
+}
+
+func TestGitHubAppTokenExchangeIsCancelled(t *testing.T) {
+	keyPath, _ := writeTestAppKey(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Hang like an unresponsive server until the client gives up
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(ClientOptions{
+		App:    GitHubAppConfig{AppID: 42, InstallationID: 99, PrivateKeyPath: keyPath},
+		APIURL: server.URL,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = client.GetLatestRelease(ctx, &Repository{Owner: "org", Name: "repo"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the token exchange to stop with the context, got: %v", err)
+	}
+}
+
+func TestLoadAppPrivateKey(t *testing.T) {
+	pkcs1Path, _ := writeTestAppKey(t)
+	if _, err := loadAppPrivateKey(pkcs1Path); err != nil {
+		t.Errorf("Expected PKCS#1 key to load, got: %v", err)
+	}
+
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	pkcs8Path := filepath.Join(t.TempDir(), "pkcs8.pem")
+	os.WriteFile(pkcs8Path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600)
+	if _, err := loadAppPrivateKey(pkcs8Path); err != nil {
+		t.Errorf("Expected PKCS#8 key to load, got: %v", err)
+	}
+
+	garbage := filepath.Join(t.TempDir(), "garbage.pem")
+	os.WriteFile(garbage, []byte("not a key"), 0600)
+	if _, err := loadAppPrivateKey(garbage); err == nil {
+		t.Error("Expected error for a file that isn't PEM")
+	}
+}
+
+func TestGitHubAppConfigValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		app         GitHubAppConfig
+		expectError bool
+	}{
+		{"complete", GitHubAppConfig{AppID: 1, InstallationID: 2, PrivateKeyPath: "key.pem"}, false},
+		{"missing app_id", GitHubAppConfig{InstallationID: 2, PrivateKeyPath: "key.pem"}, true},
+		{"missing installation_id", GitHubAppConfig{AppID: 1, PrivateKeyPath: "key.pem"}, true},
+		{"missing private_key_path", GitHubAppConfig{AppID: 1, InstallationID: 2}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.app.Validate()
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v28/github"
+)
+
+// githubForge adapts Client to the Forge interface, converting go-github
+// types to their forge-neutral counterparts.
+type githubForge struct {
+	client *Client
+}
+
+func NewGitHubForge(client *Client) Forge {
+	return &githubForge{client: client}
+}
+
+func (f *githubForge) GetLatestRelease(ctx context.Context, repo *Repository) (*ForgeRelease, error) {
+	release, err := f.client.GetLatestRelease(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	return fromGitHubRelease(release), nil
+}
+
+func (f *githubForge) GetReleaseByTag(ctx context.Context, repo *Repository, tag string) (*ForgeRelease, error) {
+	release, err := f.client.GetReleaseByTag(ctx, repo, tag)
+	if err != nil {
+		return nil, err
+	}
+	return fromGitHubRelease(release), nil
+}
+
+func (f *githubForge) ListReleases(ctx context.Context, repo *Repository) ([]*ForgeRelease, error) {
+	releases, err := f.client.GetReleases(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*ForgeRelease, 0, len(releases))
+	for _, release := range releases {
+		result = append(result, fromGitHubRelease(release))
+	}
+	return result, nil
+}
+
+func (f *githubForge) ListTags(ctx context.Context, repo *Repository) ([]string, error) {
+	return f.client.GetTags(ctx, repo)
+}
+
+func (f *githubForge) GetFile(ctx context.Context, repo *Repository, path, ref string) ([]byte, error) {
+	return f.client.GetFile(ctx, repo, path, ref)
+}
+
+func (f *githubForge) CreateRelease(ctx context.Context, repo *Repository, release *ForgeRelease) (*ForgeRelease, error) {
+	gh := &github.RepositoryRelease{
+		TagName:    github.String(release.TagName),
+		Name:       github.String(release.Name),
+		Body:       github.String(release.Body),
+		Draft:      github.Bool(release.Draft),
+		Prerelease: github.Bool(release.Prerelease),
+	}
+
+	var created *github.RepositoryRelease
+	var err error
+	if release.NotLatest {
+		if release.Target != "" {
+			gh.TargetCommitish = github.String(release.Target)
+		}
+		created, err = f.client.CreateReleaseNotLatest(ctx, repo, gh)
+	} else if release.Target != "" {
+		created, err = f.client.CreateReleaseFromSHA(ctx, repo, gh, release.Target)
+	} else {
+		created, err = f.client.CreateRelease(ctx, repo, gh)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return fromGitHubRelease(created), nil
+}
+
+func (f *githubForge) EditReleaseNotes(ctx context.Context, repo *Repository, release *ForgeRelease, notes string) error {
+	_, err := f.client.EditRelease(ctx, repo, release.ID, &github.RepositoryRelease{Body: github.String(notes)})
+	return err
+}
+
+func (f *githubForge) UploadReleaseAsset(ctx context.Context, repo *Repository, release *ForgeRelease, path string) error {
+	_, err := f.client.UploadReleaseAsset(ctx, repo, release.ID, path)
+	return err
+}
+
+func (f *githubForge) CompareCommits(ctx context.Context, repo *Repository, base, head string) (*Comparison, error) {
+	comparison, err := f.client.CompareCommits(ctx, repo, base, head)
+	if err != nil {
+		return nil, err
+	}
+	return &Comparison{
+		Commits:      fromGitHubCommits(comparison.Commits),
+		TotalCommits: comparison.GetTotalCommits(),
+		MergeBaseSHA: comparison.GetMergeBaseCommit().GetSHA(),
+	}, nil
+}
+
+func (f *githubForge) ListCommitsSince(ctx context.Context, repo *Repository, head, stopSHA string) ([]Commit, error) {
+	commits, err := f.client.ListCommitsSince(ctx, repo, head, stopSHA)
+	if err != nil {
+		return nil, err
+	}
+	return fromGitHubCommits(commits), nil
+}
+
+func (f *githubForge) GetTagSHA(ctx context.Context, repo *Repository, tag string) (string, error) {
+	return f.client.GetTagSHA(ctx, repo, tag)
+}
+
+func (f *githubForge) UpdateTagRef(ctx context.Context, repo *Repository, tag, sha string) error {
+	return f.client.UpdateTagRef(ctx, repo, tag, sha)
+}
+
+func (f *githubForge) GetPullRequest(ctx context.Context, repo *Repository, number int) (*PullRequest, error) {
+	pr, err := f.client.GetPullRequest(ctx, repo, number)
+	if err != nil {
+		return nil, err
+	}
+	return fromGitHubPullRequest(pr), nil
+}
+
+func (f *githubForge) GetPullRequestsBatch(ctx context.Context, repo *Repository, numbers []int) (map[int]*PullRequest, error) {
+	return f.client.GetPullRequestsBatch(ctx, repo, numbers)
+}
+
+func (f *githubForge) GetCommitPullRequestsBatch(ctx context.Context, repo *Repository, shas []string) (map[string][]*PullRequest, error) {
+	return f.client.GetCommitPullRequestsBatch(ctx, repo, shas)
+}
+
+// ListCommitPullRequests lists the pull requests containing sha, whether they
+// were merged with a merge commit, squashed or rebased.
+func (f *githubForge) ListCommitPullRequests(ctx context.Context, repo *Repository, sha string) ([]*PullRequest, error) {
+	prs, err := f.client.ListPullRequestsWithCommit(ctx, repo, sha)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*PullRequest, 0, len(prs))
+	for _, pr := range prs {
+		result = append(result, fromGitHubPullRequest(pr))
+	}
+	return result, nil
+}
+
+func (f *githubForge) GetPullRequestComments(ctx context.Context, repo *Repository, number int) ([]string, error) {
+	comments, err := f.client.GetPullRequestComments(ctx, repo, number)
+	if err != nil {
+		return nil, err
+	}
+	bodies := make([]string, 0, len(comments))
+	for _, comment := range comments {
+		bodies = append(bodies, comment.GetBody())
+	}
+	return bodies, nil
+}
+
+func (f *githubForge) GetPullRequestFiles(ctx context.Context, repo *Repository, number int) ([]string, error) {
+	return f.client.GetPullRequestFiles(ctx, repo, number)
+}
+
+func (f *githubForge) GetLastNMergedPRs(ctx context.Context, repo *Repository, count int) ([]*PullRequest, error) {
+	prs, err := f.client.GetLastNMergedPRs(ctx, repo, count)
+	if err != nil {
+		return nil, err
+	}
+	var result []*PullRequest
+	for _, pr := range prs {
+		result = append(result, fromGitHubPullRequest(pr))
+	}
+	return result, nil
+}
+
+func (f *githubForge) ReleaseURL(repo *Repository, tag string) string {
+	return f.client.ReleaseURL(repo, tag)
+}
+
+func (f *githubForge) PullRequestURL(repo *Repository, number int) string {
+	return f.client.PullRequestURL(repo, number)
+}
+
+func (f *githubForge) ChangeRef(number int) string {
+	return fmt.Sprintf("#%d", number)
+}
+
+func fromGitHubRelease(release *github.RepositoryRelease) *ForgeRelease {
+	return &ForgeRelease{
+		ID:         release.GetID(),
+		TagName:    release.GetTagName(),
+		Name:       release.GetName(),
+		Body:       release.GetBody(),
+		Target:     release.GetTargetCommitish(),
+		Draft:      release.GetDraft(),
+		Prerelease: release.GetPrerelease(),
+	}
+}
+
+func fromGitHubCommits(commits []github.RepositoryCommit) []Commit {
+	result := make([]Commit, 0, len(commits))
+	for _, commit := range commits {
+		var parents []string
+		for _, parent := range commit.Parents {
+			parents = append(parents, parent.GetSHA())
+		}
+		result = append(result, Commit{
+			SHA:     commit.GetSHA(),
+			Message: commit.GetCommit().GetMessage(),
+			Author:  commit.GetCommit().GetAuthor().GetName(),
+			Date:    commit.GetCommit().GetAuthor().GetDate(),
+			Parents: parents,
+		})
+	}
+	return result
+}
+
+func fromGitHubPullRequest(pr *github.PullRequest) *PullRequest {
+	result := &PullRequest{
+		Number:     pr.GetNumber(),
+		Title:      pr.GetTitle(),
+		Body:       pr.GetBody(),
+		Author:     pr.GetUser().GetLogin(),
+		BaseBranch: pr.GetBase().GetRef(),
+		MergedAt:   pr.GetMergedAt(),
+	}
+	for _, label := range pr.Labels {
+		result.Labels = append(result.Labels, label.GetName())
+	}
+	return result
+}
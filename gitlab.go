@@ -0,0 +1,456 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GitLabOptions describes how to reach a GitLab instance. Leaving APIURL
+// empty targets gitlab.com; WebURL is derived from APIURL when left empty.
+type GitLabOptions struct {
+	Token    string
+	APIURL   string
+	WebURL   string
+	CacheDir string
+	Timeout  time.Duration
+	Logger   *Logger
+}
+
+// GitLabClient is the Forge for repositories hosted on GitLab. Merge requests
+// stand in for pull requests, releases go through the Releases API and assets
+// are uploaded to the generic package registry and linked from the release.
+type GitLabClient struct {
+	rest   *restClient
+	webURL *url.URL
+}
+
+// NewGitLabClient builds a client for the GitLab instance described by opts.
+// It fails when one of the configured URLs cannot be parsed.
+func NewGitLabClient(opts GitLabOptions) (*GitLabClient, error) {
+	logger := opts.Logger
+	if logger == nil {
+		logger = NewLogger()
+	}
+
+	apiURL, _ := url.Parse("https://gitlab.com/api/v4/")
+	webURL, _ := url.Parse("https://gitlab.com/")
+
+	if opts.APIURL != "" {
+		u, err := parseBaseURL(opts.APIURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid api_url %q: %w", opts.APIURL, err)
+		}
+		apiURL = u
+		webURL = deriveGitLabWebURL(u)
+	}
+	if opts.WebURL != "" {
+		u, err := parseBaseURL(opts.WebURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid web_url %q: %w", opts.WebURL, err)
+		}
+		webURL = u
+	}
+
+	authorization := ""
+	if opts.Token != "" {
+		authorization = "Bearer " + opts.Token
+	}
+
+	return &GitLabClient{
+		rest:   newRestClient(apiURL, authorization, opts.CacheDir, opts.Timeout, logger),
+		webURL: webURL,
+	}, nil
+}
+
+// deriveGitLabWebURL strips the /api/v4/ suffix GitLab serves its REST API
+// under. Anything else is used unchanged.
+func deriveGitLabWebURL(apiURL *url.URL) *url.URL {
+	u := *apiURL
+	u.Path = strings.TrimSuffix(u.Path, "api/v4/")
+	return &u
+}
+
+// ParseProjectPath parses a GitLab project path, which unlike a GitHub
+// repository may sit in nested subgroups ("group/subgroup/project"). Owner is
+// everything before the last slash.
+func ParseProjectPath(path string) (*Repository, error) {
+	i := strings.LastIndex(path, "/")
+	if i <= 0 || i == len(path)-1 {
+		return nil, fmt.Errorf("invalid project path: %s (expected format: group/project)", path)
+	}
+	return &Repository{
+		Owner: path[:i],
+		Name:  path[i+1:],
+	}, nil
+}
+
+// projectPath returns the API path of repo, whose full "group/project" name
+// has to be URL-encoded into a single path segment.
+func projectPath(repo *Repository) string {
+	return "projects/" + url.PathEscape(repo.String())
+}
+
+type gitlabRelease struct {
+	TagName     string `json:"tag_name"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Commit      struct {
+		ID string `json:"id"`
+	} `json:"commit"`
+}
+
+// toForgeRelease converts r. GitLab has no pre-release flag, so releases
+// tagged with a pre-release version are reported as pre-releases.
+func (r *gitlabRelease) toForgeRelease() *ForgeRelease {
+	release := &ForgeRelease{
+		TagName: r.TagName,
+		Name:    r.Name,
+		Body:    r.Description,
+		Target:  r.Commit.ID,
+	}
+	if v, err := ParseVersion(r.TagName); err == nil && v.Prerelease() != "" {
+		release.Prerelease = true
+	}
+	return release
+}
+
+type gitlabCommit struct {
+	ID           string    `json:"id"`
+	Message      string    `json:"message"`
+	AuthorName   string    `json:"author_name"`
+	AuthoredDate time.Time `json:"authored_date"`
+	ParentIDs    []string  `json:"parent_ids"`
+}
+
+func (c gitlabCommit) toCommit() Commit {
+	return Commit{
+		SHA:     c.ID,
+		Message: c.Message,
+		Author:  c.AuthorName,
+		Date:    c.AuthoredDate,
+		Parents: c.ParentIDs,
+	}
+}
+
+type gitlabMergeRequest struct {
+	IID          int        `json:"iid"`
+	Title        string     `json:"title"`
+	Description  string     `json:"description"`
+	MergedAt     *time.Time `json:"merged_at"`
+	TargetBranch string     `json:"target_branch"`
+	Labels       []string   `json:"labels"`
+	Author       struct {
+		Username string `json:"username"`
+	} `json:"author"`
+}
+
+func (mr *gitlabMergeRequest) toPullRequest() *PullRequest {
+	pr := &PullRequest{
+		Number:     mr.IID,
+		Title:      mr.Title,
+		Body:       mr.Description,
+		Author:     mr.Author.Username,
+		BaseBranch: mr.TargetBranch,
+		Labels:     mr.Labels,
+	}
+	if mr.MergedAt != nil {
+		pr.MergedAt = *mr.MergedAt
+	}
+	return pr
+}
+
+func (c *GitLabClient) GetLatestRelease(ctx context.Context, repo *Repository) (*ForgeRelease, error) {
+	query := url.Values{"order_by": {"released_at"}, "sort": {"desc"}, "per_page": {"1"}}
+	var releases []gitlabRelease
+	if _, err := c.rest.do(ctx, http.MethodGet, projectPath(repo)+"/releases", query, nil, &releases); err != nil {
+		return nil, fmt.Errorf("failed to get latest release for %s: %w", repo, err)
+	}
+	if len(releases) == 0 {
+		return nil, fmt.Errorf("failed to get latest release for %s: no releases found", repo)
+	}
+	return releases[0].toForgeRelease(), nil
+}
+
+func (c *GitLabClient) GetReleaseByTag(ctx context.Context, repo *Repository, tag string) (*ForgeRelease, error) {
+	var release gitlabRelease
+	if _, err := c.rest.do(ctx, http.MethodGet, projectPath(repo)+"/releases/"+url.PathEscape(tag), nil, nil, &release); err != nil {
+		return nil, fmt.Errorf("failed to get release %s for %s: %w", tag, repo, err)
+	}
+	return release.toForgeRelease(), nil
+}
+
+func (c *GitLabClient) ListReleases(ctx context.Context, repo *Repository) ([]*ForgeRelease, error) {
+	query := url.Values{"per_page": {"100"}}
+	var releases []*ForgeRelease
+	for page := "1"; page != ""; {
+		query.Set("page", page)
+		var result []gitlabRelease
+		resp, err := c.rest.do(ctx, http.MethodGet, projectPath(repo)+"/releases", query, nil, &result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get releases for %s: %w", repo, err)
+		}
+		for i := range result {
+			releases = append(releases, result[i].toForgeRelease())
+		}
+		page = resp.Header.Get("X-Next-Page")
+	}
+	return releases, nil
+}
+
+func (c *GitLabClient) ListTags(ctx context.Context, repo *Repository) ([]string, error) {
+	query := url.Values{"per_page": {"100"}}
+	var names []string
+	for page := "1"; page != ""; {
+		query.Set("page", page)
+		var tags []struct {
+			Name string `json:"name"`
+		}
+		resp, err := c.rest.do(ctx, http.MethodGet, projectPath(repo)+"/repository/tags", query, nil, &tags)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tags for %s: %w", repo, err)
+		}
+		for _, tag := range tags {
+			names = append(names, tag.Name)
+		}
+		page = resp.Header.Get("X-Next-Page")
+	}
+	return names, nil
+}
+
+func (c *GitLabClient) GetFile(ctx context.Context, repo *Repository, path, ref string) ([]byte, error) {
+	var file struct {
+		Content string `json:"content"`
+	}
+	query := url.Values{"ref": {ref}}
+	resp, err := c.rest.do(ctx, http.MethodGet, projectPath(repo)+"/repository/files/"+url.PathEscape(path), query, nil, &file)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s at %s for %s: %w", path, ref, repo, err)
+	}
+	return decodeFileContent(file.Content, path, ref, repo)
+}
+
+func (c *GitLabClient) CreateRelease(ctx context.Context, repo *Repository, release *ForgeRelease) (*ForgeRelease, error) {
+	ref := release.Target
+	if ref == "" {
+		var project struct {
+			DefaultBranch string `json:"default_branch"`
+		}
+		if _, err := c.rest.do(ctx, http.MethodGet, projectPath(repo), nil, nil, &project); err != nil {
+			return nil, fmt.Errorf("failed to get default branch for %s: %w", repo, err)
+		}
+		ref = project.DefaultBranch
+	}
+
+	payload := map[string]string{
+		"tag_name":    release.TagName,
+		"name":        release.Name,
+		"description": release.Body,
+		"ref":         ref,
+	}
+	var created gitlabRelease
+	if _, err := c.rest.do(ctx, http.MethodPost, projectPath(repo)+"/releases", nil, payload, &created); err != nil {
+		return nil, fmt.Errorf("failed to create release for %s: %w", repo, err)
+	}
+	return created.toForgeRelease(), nil
+}
+
+func (c *GitLabClient) EditReleaseNotes(ctx context.Context, repo *Repository, release *ForgeRelease, notes string) error {
+	payload := map[string]string{"description": notes}
+	if _, err := c.rest.do(ctx, http.MethodPut, projectPath(repo)+"/releases/"+url.PathEscape(release.TagName), nil, payload, nil); err != nil {
+		return fmt.Errorf("failed to edit release %s for %s: %w", release.TagName, repo, err)
+	}
+	return nil
+}
+
+// UploadReleaseAsset stores the file in the project's generic package
+// registry, under a package named after the repository and versioned by the
+// release tag, then links it from the release.
+func (c *GitLabClient) UploadReleaseAsset(ctx context.Context, repo *Repository, release *ForgeRelease, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to open asset %s: %w", path, err)
+	}
+
+	name := filepath.Base(path)
+	packagePath := fmt.Sprintf("%s/packages/generic/%s/%s/%s", projectPath(repo),
+		url.PathEscape(repo.Name), url.PathEscape(release.TagName), url.PathEscape(name))
+	if _, err := c.rest.do(ctx, http.MethodPut, packagePath, nil, rawBody{"application/octet-stream", data}, nil); err != nil {
+		return fmt.Errorf("failed to upload asset %s to release %s for %s: %w", path, release.TagName, repo, err)
+	}
+
+	link := map[string]string{
+		"name":      name,
+		"url":       c.rest.baseURL.String() + packagePath,
+		"link_type": "package",
+	}
+	if _, err := c.rest.do(ctx, http.MethodPost, projectPath(repo)+"/releases/"+url.PathEscape(release.TagName)+"/assets/links", nil, link, nil); err != nil {
+		return fmt.Errorf("failed to link asset %s to release %s for %s: %w", path, release.TagName, repo, err)
+	}
+	return nil
+}
+
+// CompareCommits compares base...head. GitLab lists every commit in one
+// response, so the comparison is always complete.
+func (c *GitLabClient) CompareCommits(ctx context.Context, repo *Repository, base, head string) (*Comparison, error) {
+	query := url.Values{"from": {base}, "to": {head}}
+	var result struct {
+		Commits []gitlabCommit `json:"commits"`
+	}
+	if _, err := c.rest.do(ctx, http.MethodGet, projectPath(repo)+"/repository/compare", query, nil, &result); err != nil {
+		return nil, fmt.Errorf("failed to compare commits %s...%s for %s: %w", base, head, repo, err)
+	}
+
+	comparison := &Comparison{TotalCommits: len(result.Commits)}
+	for _, commit := range result.Commits {
+		comparison.Commits = append(comparison.Commits, commit.toCommit())
+	}
+	return comparison, nil
+}
+
+// ListCommitsSince walks the commit history of head, newest first, until it
+// reaches stopSHA, and returns the commits in between oldest first.
+func (c *GitLabClient) ListCommitsSince(ctx context.Context, repo *Repository, head, stopSHA string) ([]Commit, error) {
+	var commits []Commit
+	for page := "1"; page != ""; {
+		query := url.Values{"ref_name": {head}, "per_page": {"100"}, "page": {page}}
+		var result []gitlabCommit
+		resp, err := c.rest.do(ctx, http.MethodGet, projectPath(repo)+"/repository/commits", query, nil, &result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list commits of %s for %s: %w", head, repo, err)
+		}
+
+		for _, commit := range result {
+			if commit.ID == stopSHA {
+				for i, j := 0, len(commits)-1; i < j; i, j = i+1, j-1 {
+					commits[i], commits[j] = commits[j], commits[i]
+				}
+				return commits, nil
+			}
+			commits = append(commits, commit.toCommit())
+		}
+		page = resp.Header.Get("X-Next-Page")
+	}
+
+	return nil, fmt.Errorf("commit %s is not an ancestor of %s in %s", shortSHA(stopSHA), head, repo)
+}
+
+func (c *GitLabClient) GetTagSHA(ctx context.Context, repo *Repository, tag string) (string, error) {
+	var result struct {
+		Commit struct {
+			ID string `json:"id"`
+		} `json:"commit"`
+	}
+	if _, err := c.rest.do(ctx, http.MethodGet, projectPath(repo)+"/repository/tags/"+url.PathEscape(tag), nil, nil, &result); err != nil {
+		return "", fmt.Errorf("failed to get tag %s for %s: %w", tag, repo, err)
+	}
+	return result.Commit.ID, nil
+}
+
+func (c *GitLabClient) GetPullRequest(ctx context.Context, repo *Repository, number int) (*PullRequest, error) {
+	var mr gitlabMergeRequest
+	if _, err := c.rest.do(ctx, http.MethodGet, fmt.Sprintf("%s/merge_requests/%d", projectPath(repo), number), nil, nil, &mr); err != nil {
+		return nil, fmt.Errorf("failed to get merge request !%d for %s: %w", number, repo, err)
+	}
+	return mr.toPullRequest(), nil
+}
+
+// GetPullRequestComments returns the bodies of the merge request's notes,
+// leaving out the system notes GitLab adds for events like pushes.
+func (c *GitLabClient) GetPullRequestComments(ctx context.Context, repo *Repository, number int) ([]string, error) {
+	var comments []string
+	for page := "1"; page != ""; {
+		query := url.Values{"per_page": {"100"}, "page": {page}}
+		var notes []struct {
+			Body   string `json:"body"`
+			System bool   `json:"system"`
+		}
+		resp, err := c.rest.do(ctx, http.MethodGet, fmt.Sprintf("%s/merge_requests/%d/notes", projectPath(repo), number), query, nil, &notes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get notes for merge request !%d in %s: %w", number, repo, err)
+		}
+
+		for _, note := range notes {
+			if !note.System {
+				comments = append(comments, note.Body)
+			}
+		}
+		page = resp.Header.Get("X-Next-Page")
+	}
+	return comments, nil
+}
+
+// GetPullRequestFiles lists the old and new paths of every file a merge
+// request changes, so renames out of a path count too.
+func (c *GitLabClient) GetPullRequestFiles(ctx context.Context, repo *Repository, number int) ([]string, error) {
+	var paths []string
+	for page := "1"; page != ""; {
+		query := url.Values{"per_page": {"100"}, "page": {page}}
+		var diffs []struct {
+			OldPath string `json:"old_path"`
+			NewPath string `json:"new_path"`
+		}
+		resp, err := c.rest.do(ctx, http.MethodGet, fmt.Sprintf("%s/merge_requests/%d/diffs", projectPath(repo), number), query, nil, &diffs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get diffs for merge request !%d in %s: %w", number, repo, err)
+		}
+
+		for _, diff := range diffs {
+			paths = append(paths, diff.NewPath)
+			if diff.OldPath != diff.NewPath {
+				paths = append(paths, diff.OldPath)
+			}
+		}
+		page = resp.Header.Get("X-Next-Page")
+	}
+	return paths, nil
+}
+
+func (c *GitLabClient) GetLastNMergedPRs(ctx context.Context, repo *Repository, count int) ([]*PullRequest, error) {
+	query := url.Values{
+		"state":    {"merged"},
+		"order_by": {"updated_at"},
+		"sort":     {"desc"},
+		"per_page": {strconv.Itoa(minInt(count, 100))},
+	}
+
+	var prs []*PullRequest
+	for page := "1"; page != "" && len(prs) < count; {
+		query.Set("page", page)
+		var mrs []gitlabMergeRequest
+		resp, err := c.rest.do(ctx, http.MethodGet, projectPath(repo)+"/merge_requests", query, nil, &mrs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get merge requests for %s: %w", repo, err)
+		}
+
+		for i := range mrs {
+			if len(prs) == count {
+				break
+			}
+			prs = append(prs, mrs[i].toPullRequest())
+		}
+		page = resp.Header.Get("X-Next-Page")
+	}
+	return prs, nil
+}
+
+func (c *GitLabClient) ReleaseURL(repo *Repository, tag string) string {
+	return fmt.Sprintf("%s%s/-/releases/%s", c.webURL, repo, url.PathEscape(tag))
+}
+
+func (c *GitLabClient) PullRequestURL(repo *Repository, number int) string {
+	return fmt.Sprintf("%s%s/-/merge_requests/%d", c.webURL, repo, number)
+}
+
+func (c *GitLabClient) ChangeRef(number int) string {
+	return fmt.Sprintf("!%d", number)
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseProjectPath(t *testing.T) {
+	tests := []struct {
+		path          string
+		expectedOwner string
+		expectedName  string
+		expectError   bool
+	}{
+		{path: "mobile/ios-app", expectedOwner: "mobile", expectedName: "ios-app"},
+		{path: "org/mobile/ios-app", expectedOwner: "org/mobile", expectedName: "ios-app"},
+		{path: "ios-app", expectError: true},
+		{path: "mobile/", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			repo, err := ParseProjectPath(tt.path)
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if repo.Owner != tt.expectedOwner || repo.Name != tt.expectedName {
+				t.Errorf("Expected %s / %s, got %s / %s", tt.expectedOwner, tt.expectedName, repo.Owner, repo.Name)
+			}
+		})
+	}
+}
+
+func TestGitLabURLs(t *testing.T) {
+	repo := &Repository{Owner: "org/mobile", Name: "ios-app"}
+
+	client, err := NewGitLabClient(GitLabOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := client.ReleaseURL(repo, "v1.2.3"); got != "https://gitlab.com/org/mobile/ios-app/-/releases/v1.2.3" {
+		t.Errorf("Unexpected gitlab.com release URL: %s", got)
+	}
+
+	selfHosted, err := NewGitLabClient(GitLabOptions{APIURL: "https://git.example.com/api/v4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := selfHosted.ReleaseURL(repo, "v1.2.3"); got != "https://git.example.com/org/mobile/ios-app/-/releases/v1.2.3" {
+		t.Errorf("Unexpected self-hosted release URL: %s", got)
+	}
+	if got := selfHosted.PullRequestURL(repo, 12); got != "https://git.example.com/org/mobile/ios-app/-/merge_requests/12" {
+		t.Errorf("Unexpected self-hosted merge request URL: %s", got)
+	}
+
+	if got := client.ChangeRef(12); got != "!12" {
+		t.Errorf("Expected merge requests to be referenced as !12, got %s", got)
+	}
+}
+
+// newGitLabTestServer serves a canned project, failing the test on requests
+// that aren't authenticated or don't address the project by its encoded path.
+func newGitLabTestServer(t *testing.T, handler http.HandlerFunc) *GitLabClient {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("Expected bearer token, got %q", r.Header.Get("Authorization"))
+		}
+		if got := r.URL.EscapedPath(); !strings.HasPrefix(got, "/api/v4/projects/mobile%2Fios-app") {
+			t.Errorf("Expected the project path to be encoded, got %s", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewGitLabClient(GitLabOptions{Token: "secret", APIURL: server.URL + "/api/v4", Logger: NewLoggerWithLevel(ErrorLevel)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestGitLabCompareAndMergeRequests(t *testing.T) {
+	client := newGitLabTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v4/projects/mobile/ios-app/repository/compare":
+			if r.URL.Query().Get("from") != "v1.0.0" || r.URL.Query().Get("to") != "main" {
+				t.Errorf("Unexpected compare query %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`{"commits": [
+			  {"id": "aaa", "message": "Merge branch 'login' into 'main'\n\nFix login\n\nSee merge request mobile/ios-app!12", "author_name": "Ann"},
+			  {"id": "bbb", "message": "Bump version", "author_name": "Bob"}
+			]}`))
+		case "/api/v4/projects/mobile/ios-app/merge_requests/12":
+			w.Write([]byte(`{"iid": 12, "title": "Fix login", "description": "Fixes TEST-1", "merged_at": "2024-05-01T10:00:00Z",
+			  "target_branch": "main", "labels": ["bug"], "author": {"username": "ann"}}`))
+		case "/api/v4/projects/mobile/ios-app/merge_requests/12/notes":
+			if r.URL.Query().Get("page") == "1" {
+				w.Header().Set("X-Next-Page", "2")
+				w.Write([]byte(`[{"body": "see PROJ-2", "system": false}, {"body": "added 1 commit", "system": true}]`))
+				return
+			}
+			w.Write([]byte(`[{"body": "LGTM", "system": false}]`))
+		default:
+			t.Errorf("Unexpected request %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	repo := &Repository{Owner: "mobile", Name: "ios-app"}
+
+	comparison, err := client.CompareCommits(context.Background(), repo, "v1.0.0", "main")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(comparison.Commits) != 2 || comparison.TotalCommits != 2 || comparison.Commits[0].SHA != "aaa" {
+		t.Fatalf("Unexpected comparison: %+v", comparison)
+	}
+	number, err := ParsePRNumber(comparison.Commits[0].Message)
+	if err != nil || number != 12 {
+		t.Fatalf("Expected merge request 12 to be found in the merge commit, got %d, %v", number, err)
+	}
+
+	pr, err := client.GetPullRequest(context.Background(), repo, number)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if pr.Title != "Fix login" || pr.Author != "ann" || pr.BaseBranch != "main" ||
+		pr.MergedAt.Format("2006-01-02") != "2024-05-01" || len(pr.Labels) != 1 {
+		t.Errorf("Unexpected merge request: %+v", pr)
+	}
+
+	comments, err := client.GetPullRequestComments(context.Background(), repo, number)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(comments) != 2 || comments[0] != "see PROJ-2" || comments[1] != "LGTM" {
+		t.Errorf("Expected user notes from both pages without system notes, got %q", comments)
+	}
+}
+
+func TestGitLabListReleases(t *testing.T) {
+	client := newGitLabTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v4/projects/mobile/ios-app/releases" {
+			t.Errorf("Unexpected request %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("page") == "1" {
+			w.Header().Set("X-Next-Page", "2")
+			w.Write([]byte(`[{"tag_name": "v1.3.0-rc.1"}]`))
+			return
+		}
+		w.Write([]byte(`[{"tag_name": "v1.2.0"}]`))
+	})
+
+	releases, err := client.ListReleases(context.Background(), &Repository{Owner: "mobile", Name: "ios-app"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(releases) != 2 || releases[0].TagName != "v1.3.0-rc.1" || releases[1].TagName != "v1.2.0" {
+		t.Fatalf("Expected releases from both pages, got %+v", releases)
+	}
+	if !releases[0].Prerelease || releases[1].Prerelease {
+		t.Errorf("Expected only v1.3.0-rc.1 to be flagged as a pre-release")
+	}
+}
+
+func TestGitLabCreateReleaseWithAsset(t *testing.T) {
+	var created map[string]string
+	var uploaded string
+	var link map[string]string
+	client := newGitLabTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v4/projects/mobile/ios-app":
+			w.Write([]byte(`{"default_branch": "develop"}`))
+		case "POST /api/v4/projects/mobile/ios-app/releases":
+			json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"tag_name": "v1.1.0", "name": "v1.1.0"}`))
+		case "PUT /api/v4/projects/mobile/ios-app/packages/generic/ios-app/v1.1.0/app.ipa":
+			body, _ := io.ReadAll(r.Body)
+			uploaded = string(body)
+			w.WriteHeader(http.StatusCreated)
+		case "POST /api/v4/projects/mobile/ios-app/releases/v1.1.0/assets/links":
+			json.NewDecoder(r.Body).Decode(&link)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{}`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	repo := &Repository{Owner: "mobile", Name: "ios-app"}
+
+	release, err := client.CreateRelease(context.Background(), repo, &ForgeRelease{TagName: "v1.1.0", Name: "v1.1.0", Body: "notes"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if created["ref"] != "develop" || created["description"] != "notes" {
+		t.Errorf("Expected the release to be tagged on the default branch with notes, got %v", created)
+	}
+
+	asset := filepath.Join(t.TempDir(), "app.ipa")
+	if err := os.WriteFile(asset, []byte("binary"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.UploadReleaseAsset(context.Background(), repo, release, asset); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if uploaded != "binary" {
+		t.Errorf("Expected the asset to be uploaded to the package registry, got %q", uploaded)
+	}
+	if link["name"] != "app.ipa" || link["link_type"] != "package" {
+		t.Errorf("Expected the release to link the package, got %v", link)
+	}
+}
+
+func TestGitLabReportsErrors(t *testing.T) {
+	client := newGitLabTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "404 Release Not Found"}`))
+	})
+
+	_, err := client.GetReleaseByTag(context.Background(), &Repository{Owner: "mobile", Name: "ios-app"}, "v9.9.9")
+	if err == nil {
+		t.Fatal("Expected error but got none")
+	}
+	if want := "404 Release Not Found"; !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected error to include %q, got: %v", want, err)
+	}
+}
+
+func TestGitLabGetPullRequestFiles(t *testing.T) {
+	client := newGitLabTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v4/projects/mobile/ios-app/merge_requests/12/diffs" {
+			t.Errorf("Unexpected request %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("page") == "1" {
+			w.Header().Set("X-Next-Page", "2")
+			w.Write([]byte(`[{"old_path": "packages/api/main.go", "new_path": "packages/api/main.go"}]`))
+			return
+		}
+		w.Write([]byte(`[{"old_path": "web/old.ts", "new_path": "packages/web/new.ts"}]`))
+	})
+
+	files, err := client.GetPullRequestFiles(context.Background(), &Repository{Owner: "mobile", Name: "ios-app"}, 12)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	want := []string{"packages/api/main.go", "packages/web/new.ts", "web/old.ts"}
+	if len(files) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, files)
+	}
+	for i := range want {
+		if files[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, files)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// LocalCommitsBetween lists the commits in base..head from the git checkout
+// at dir, oldest first, or every commit reachable from head when base is
+// empty. With fetch set, tags and the head branch are fetched from the remote
+// first (best-effort), so a stale local clone still sees the published
+// history; without it no network access happens and head resolves to the
+// last fetched remote-tracking branch when there is one.
+func LocalCommitsBetween(dir, base, head string, fetch bool) ([]Commit, error) {
+	head, err := resolveLocalHead(dir, head, fetch)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := head
+	if base != "" {
+		revisions = base + ".." + head
+	}
+
+	// Fields are NUL-separated and records end with a record separator, since
+	// commit bodies can contain anything else.
+	out, err := runGit(dir, "log", "--reverse", "--format=%H%x00%an%x00%aI%x00%P%x00%B%x1e", revisions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits %s in %q: %w", revisions, dir, err)
+	}
+	return parseGitLog(out), nil
+}
+
+// LatestLocalTags returns the highest released version tagged in the
+// history of head in the checkout at dir and the highest pre-release tagged
+// above it, or nil when there is none. Tags that aren't versions in scheme
+// are ignored.
+func LatestLocalTags(dir, head string, fetch bool, scheme VersionScheme) (final, prerelease *ReleaseTag, err error) {
+	head, err = resolveLocalHead(dir, head, fetch)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out, err := runGit(dir, "tag", "--merged", head)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list tags of %s in %q: %w", head, dir, err)
+	}
+
+	final, prerelease = scheme.LatestTags(strings.Fields(out))
+	return final, prerelease, nil
+}
+
+// LocalCommitFiles lists the paths commit sha changes in the checkout at
+// dir. A merge commit is compared with its first parent, so it lists what
+// the merged branch changed.
+func LocalCommitFiles(dir, sha string) ([]string, error) {
+	out, err := runGit(dir, "rev-list", "--parents", "-n", "1", sha)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit %s in %q: %w", shortSHA(sha), dir, err)
+	}
+
+	args := []string{"diff-tree", "-z", "--no-commit-id", "--name-only", "-r", "--root", sha}
+	if parents := strings.Fields(out); len(parents) > 1 {
+		args = []string{"diff", "-z", "--name-only", parents[1], sha}
+	}
+	out, err = runGit(dir, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files of commit %s in %q: %w", shortSHA(sha), dir, err)
+	}
+
+	var paths []string
+	for _, path := range strings.Split(out, "\x00") {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// LocalFile returns the contents of path at head in the checkout at dir, or
+// nil when there's no such file. Nothing is fetched.
+func LocalFile(dir, head, path string) ([]byte, error) {
+	head, err := resolveLocalHead(dir, head, false)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := runGit(dir, "cat-file", "-e", head+":"+path); err != nil {
+		return nil, nil
+	}
+	out, err := runGit(dir, "show", head+":"+path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s at %s in %q: %w", path, head, dir, err)
+	}
+	return []byte(out), nil
+}
+
+// resolveLocalHead checks that dir is a git checkout and returns the ref to
+// read head from, fetching first when fetch is set.
+func resolveLocalHead(dir, head string, fetch bool) (string, error) {
+	if _, err := runGit(dir, "rev-parse", "--is-inside-work-tree"); err != nil {
+		return "", fmt.Errorf("%q is not a git repository: %w", dir, err)
+	}
+
+	remote := defaultRemote(dir)
+	if fetch {
+		// A failed fetch leaves us with whatever is already local.
+		if remote != "" {
+			runGit(dir, "fetch", "--tags", remote)
+		}
+		return resolveCheckoutTarget(dir, head), nil
+	}
+
+	if remote != "" {
+		if _, err := runGit(dir, "rev-parse", "--verify", "-q", "refs/remotes/"+remote+"/"+head); err == nil {
+			return remote + "/" + head, nil
+		}
+	}
+	return head, nil
+}
+
+func parseGitLog(out string) []Commit {
+	var commits []Commit
+	for _, record := range strings.Split(out, "\x1e") {
+		record = strings.TrimLeft(record, "\n")
+		if record == "" {
+			continue
+		}
+		fields := strings.SplitN(record, "\x00", 5)
+		if len(fields) != 5 {
+			continue
+		}
+
+		commit := Commit{
+			SHA:     fields[0],
+			Author:  fields[1],
+			Parents: strings.Fields(fields[3]),
+			Message: strings.TrimRight(fields[4], "\n"),
+		}
+		if date, err := time.Parse(time.RFC3339, fields[2]); err == nil {
+			commit.Date = date
+		}
+		commits = append(commits, commit)
+	}
+	return commits
+}
+
+// PushTag points tag at sha in the checkout at dir and force-pushes it to the
+// default remote. It moves tags for forges whose API can't, so the checkout
+// needs push access.
+func PushTag(dir, tag, sha string) error {
+	remote := defaultRemote(dir)
+	if remote == "" {
+		return fmt.Errorf("%q has no remote to push tag %s to", dir, tag)
+	}
+
+	// Make sure sha is known locally; a failed fetch surfaces below.
+	runGit(dir, "fetch", remote)
+	if _, err := runGit(dir, "tag", "-f", tag, sha); err != nil {
+		return fmt.Errorf("failed to move tag %s to %s in %q: %w", tag, shortSHA(sha), dir, err)
+	}
+	if _, err := runGit(dir, "push", "--force", remote, "refs/tags/"+tag); err != nil {
+		return fmt.Errorf("failed to push tag %s from %q: %w", tag, dir, err)
+	}
+	return nil
+}
+
+// localTagMover moves tags by pushing them from the repository's local
+// checkout.
+type localTagMover struct {
+	dir string
+}
+
+func (l localTagMover) UpdateTagRef(ctx context.Context, repo *Repository, tag, sha string) error {
+	return PushTag(l.dir, tag, sha)
+}
+
+var prSuffixPattern = regexp.MustCompile(`\s*\((?:#|!)\d+\)$`)
+
+// pullRequestFromCommit describes the pull request merged by commit using
+// only the commit itself, for previews that make no API calls. The title is
+// the squash commit's subject without its "(#12)" suffix, or for a merge
+// commit the first line after its "Merge ..." subject, which is where GitHub
+// and GitLab put the pull request title.
+func pullRequestFromCommit(commit Commit, number int) *PullRequest {
+	lines := strings.Split(strings.TrimSpace(commit.Message), "\n")
+	title := strings.TrimSpace(lines[0])
+	rest := lines[1:]
+
+	if strings.HasPrefix(title, "Merge ") {
+		for i, line := range rest {
+			if line = strings.TrimSpace(line); line != "" {
+				title = line
+				rest = rest[i+1:]
+				break
+			}
+		}
+	}
+
+	return &PullRequest{
+		Number:   number,
+		Title:    prSuffixPattern.ReplaceAllString(title, ""),
+		Body:     strings.TrimSpace(strings.Join(rest, "\n")),
+		Author:   commit.Author,
+		MergedAt: commit.Date,
+		Comments: []string{},
+	}
+}
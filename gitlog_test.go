@@ -0,0 +1,234 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocalCommitsBetween(t *testing.T) {
+	dir, _ := initTestRepo(t)
+	gitIn(t, dir, "tag", "v1.0.0")
+
+	for i, message := range []string{"Fix login (#12)", "Merge pull request #13 from org/feature\n\nAdd feature"} {
+		name := filepath.Join(dir, "file"+string(rune('a'+i)))
+		if err := os.WriteFile(name, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		gitIn(t, dir, "add", ".")
+		gitIn(t, dir, "commit", "-q", "-m", message)
+	}
+	head := gitIn(t, dir, "rev-parse", "HEAD")
+
+	commits, err := LocalCommitsBetween(dir, "v1.0.0", head, true)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(commits) != 2 {
+		t.Fatalf("Expected 2 commits, got %d", len(commits))
+	}
+
+	if commits[0].Message != "Fix login (#12)" {
+		t.Errorf("Expected oldest commit first, got %q", commits[0].Message)
+	}
+	if commits[1].SHA != head {
+		t.Errorf("Expected last commit to be %s, got %s", head, commits[1].SHA)
+	}
+	if len(commits[1].Parents) != 1 || commits[1].Parents[0] != commits[0].SHA {
+		t.Errorf("Expected %s to be the parent of the last commit, got %v", commits[0].SHA, commits[1].Parents)
+	}
+	if commits[1].Message != "Merge pull request #13 from org/feature\n\nAdd feature" {
+		t.Errorf("Expected full multi-line message, got %q", commits[1].Message)
+	}
+	if commits[0].Author != "Test" || commits[0].Date.IsZero() {
+		t.Errorf("Expected author name and date, got %+v", commits[0])
+	}
+}
+
+func TestLocalCommitsBetweenRejectsNonGitDir(t *testing.T) {
+	if _, err := LocalCommitsBetween(t.TempDir(), "v1.0.0", "main", false); err == nil {
+		t.Error("Expected error for a directory that isn't a git repository")
+	}
+}
+
+func TestLocalCommitsBetweenOfflineUsesRemoteTrackingBranch(t *testing.T) {
+	remote := t.TempDir()
+	gitIn(t, remote, "init", "-q", "--bare")
+
+	dir, _ := initTestRepo(t)
+	gitIn(t, dir, "remote", "add", "origin", remote)
+	gitIn(t, dir, "tag", "v1.0.0")
+	gitIn(t, dir, "branch", "-M", "main")
+	if err := os.WriteFile(filepath.Join(dir, "fix"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitIn(t, dir, "add", ".")
+	gitIn(t, dir, "commit", "-q", "-m", "Fix (#2)")
+	gitIn(t, dir, "push", "-q", "origin", "main")
+	gitIn(t, dir, "fetch", "-q", "origin")
+	// The local branch falls behind what was last fetched from the remote.
+	gitIn(t, dir, "reset", "-q", "--hard", "v1.0.0")
+
+	commits, err := LocalCommitsBetween(dir, "v1.0.0", "main", false)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(commits) != 1 || commits[0].Message != "Fix (#2)" {
+		t.Errorf("Expected the commit on origin/main, got %+v", commits)
+	}
+}
+
+func TestLatestLocalTags(t *testing.T) {
+	dir, _ := initTestRepo(t)
+	if final, pre, err := LatestLocalTags(dir, "HEAD", false, VersionScheme{}); err != nil || final != nil || pre != nil {
+		t.Fatalf("Expected no tags, got %v, %v (err: %v)", final, pre, err)
+	}
+
+	for _, tag := range []string{"v1.2.0", "v1.10.0-rc.1", "v1.10.0", "v2.0.0-rc.1", "v2.0.0-rc.2", "nightly", "release-3.0.0"} {
+		gitIn(t, dir, "commit", "-q", "--allow-empty", "-m", tag)
+		gitIn(t, dir, "tag", tag)
+	}
+	final, pre, err := LatestLocalTags(dir, "HEAD", false, VersionScheme{})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if final == nil || final.Name != "v1.10.0" || final.Version.String() != "1.10.0" {
+		t.Errorf("Expected v1.10.0, got %+v", final)
+	}
+	if pre == nil || pre.Name != "v2.0.0-rc.2" {
+		t.Errorf("Expected pre-release v2.0.0-rc.2, got %+v", pre)
+	}
+
+	final, pre, err = LatestLocalTags(dir, "HEAD", false, VersionScheme{TagPattern: "release-{version}"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if final == nil || final.Name != "release-3.0.0" || pre != nil {
+		t.Errorf("Expected only release-3.0.0 to match the pattern, got %+v, %+v", final, pre)
+	}
+}
+
+func TestPullRequestFromCommit(t *testing.T) {
+	date := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		message   string
+		wantTitle string
+		wantBody  string
+	}{
+		{"squash", "Fix login (#12)\n\nUsers can log in again.", "Fix login", "Users can log in again."},
+		{"merge", "Merge pull request #13 from org/feature\n\nAdd feature\nwith details", "Add feature", "with details"},
+		{"gitlab merge", "Merge branch 'feature' into 'main'\n\nAdd feature\n\nSee merge request org/repo!14", "Add feature", "See merge request org/repo!14"},
+		{"subject only", "Bump deps (!15)", "Bump deps", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := pullRequestFromCommit(Commit{Message: tt.message, Author: "Ada", Date: date}, 12)
+			if pr.Title != tt.wantTitle {
+				t.Errorf("Expected title %q, got %q", tt.wantTitle, pr.Title)
+			}
+			if pr.Body != tt.wantBody {
+				t.Errorf("Expected body %q, got %q", tt.wantBody, pr.Body)
+			}
+			if pr.Number != 12 || pr.Author != "Ada" || !pr.MergedAt.Equal(date) {
+				t.Errorf("Expected number, author and date from the commit, got %+v", pr)
+			}
+			if pr.Comments == nil {
+				t.Error("Expected comments to be marked as fetched")
+			}
+		})
+	}
+}
+
+func TestPushTag(t *testing.T) {
+	remote := t.TempDir()
+	gitIn(t, remote, "init", "-q", "--bare")
+
+	dir, first := initTestRepo(t)
+	gitIn(t, dir, "remote", "add", "origin", remote)
+	gitIn(t, dir, "tag", "v1.0.0")
+	if err := os.WriteFile(filepath.Join(dir, "fix"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitIn(t, dir, "add", ".")
+	gitIn(t, dir, "commit", "-q", "-m", "Fix (#2)")
+	second := gitIn(t, dir, "rev-parse", "HEAD")
+	gitIn(t, dir, "push", "-q", "origin", "HEAD:refs/heads/main", "refs/tags/v1.0.0")
+
+	if got := gitIn(t, remote, "rev-parse", "v1.0.0"); got != first {
+		t.Fatalf("Expected the remote tag to start at %s, got %s", first, got)
+	}
+	if err := PushTag(dir, "v1.0.0", second); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if got := gitIn(t, remote, "rev-parse", "v1.0.0"); got != second {
+		t.Errorf("Expected the remote tag to move to %s, got %s", second, got)
+	}
+}
+
+func TestPushTagRequiresRemote(t *testing.T) {
+	dir, sha := initTestRepo(t)
+	if err := PushTag(dir, "v1.0.0", sha); err == nil {
+		t.Error("Expected error for a checkout without a remote")
+	}
+}
+
+func TestLocalCommitFiles(t *testing.T) {
+	dir, initial := initTestRepo(t)
+	gitIn(t, dir, "checkout", "-q", "-b", "feature")
+	if err := os.MkdirAll(filepath.Join(dir, "packages", "api"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "packages", "api", "main.go"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitIn(t, dir, "add", ".")
+	gitIn(t, dir, "commit", "-q", "-m", "Add api")
+	feature := gitIn(t, dir, "rev-parse", "HEAD")
+
+	gitIn(t, dir, "checkout", "-q", initial)
+	gitIn(t, dir, "merge", "-q", "--no-ff", "-m", "Merge pull request #4 from org/feature", "feature")
+	merge := gitIn(t, dir, "rev-parse", "HEAD")
+
+	tests := []struct {
+		name string
+		sha  string
+		want []string
+	}{
+		{name: "root commit", sha: initial, want: []string{"README"}},
+		{name: "regular commit", sha: feature, want: []string{"packages/api/main.go"}},
+		{name: "merge commit", sha: merge, want: []string{"packages/api/main.go"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := LocalCommitFiles(dir, tt.sha)
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if strings.Join(files, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Expected %v, got %v", tt.want, files)
+			}
+		})
+	}
+}
+
+func TestLocalFile(t *testing.T) {
+	dir, _ := initTestRepo(t)
+
+	data, err := LocalFile(dir, "HEAD", "README")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if data == nil {
+		t.Error("Expected README to be read")
+	}
+
+	data, err = LocalFile(dir, "HEAD", ".github/release.yml")
+	if err != nil || data != nil {
+		t.Errorf("Expected a missing file to return nil, got %q and %v", data, err)
+	}
+}
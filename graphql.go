@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// graphQLBatchSize is how many pull requests are requested per GraphQL query.
+// Each one pulls up to graphQLCommentLimit comments, keeping a query well
+// under GitHub's node limit.
+const (
+	graphQLBatchSize    = 50
+	graphQLCommentLimit = 100
+)
+
+// graphQLAssociatedLimit is how many of the pull requests containing a commit
+// are requested; it's rarely in more than one.
+const graphQLAssociatedLimit = 5
+
+const pullRequestFragment = `
+fragment pr on PullRequest {
+  number
+  title
+  body
+  mergedAt
+  baseRefName
+  author { login }
+  labels(first: 100) { nodes { name } }
+  comments(first: %d) { totalCount nodes { body } }
+}`
+
+type graphQLPullRequest struct {
+	Number      int        `json:"number"`
+	Title       string     `json:"title"`
+	Body        string     `json:"body"`
+	MergedAt    *time.Time `json:"mergedAt"`
+	BaseRefName string     `json:"baseRefName"`
+	Author      *struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	Labels struct {
+		Nodes []struct {
+			Name string `json:"name"`
+		} `json:"nodes"`
+	} `json:"labels"`
+	Comments struct {
+		TotalCount int `json:"totalCount"`
+		Nodes      []struct {
+			Body string `json:"body"`
+		} `json:"nodes"`
+	} `json:"comments"`
+}
+
+type graphQLCommit struct {
+	AssociatedPullRequests struct {
+		Nodes []*graphQLPullRequest `json:"nodes"`
+	} `json:"associatedPullRequests"`
+}
+
+type graphQLCommitsResponse struct {
+	Data struct {
+		Repository map[string]*graphQLCommit `json:"repository"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+type graphQLResponse struct {
+	Data struct {
+		Repository map[string]*graphQLPullRequest `json:"repository"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// graphQLURL returns the GraphQL endpoint that belongs with the REST root
+// apiURL: https://api.github.com/graphql for github.com, /api/graphql for
+// GitHub Enterprise Server, and <root>/graphql for anything else.
+func graphQLURL(apiURL *url.URL) *url.URL {
+	u := *apiURL
+	if strings.HasSuffix(u.Path, "/api/v3/") {
+		u.Path = strings.TrimSuffix(u.Path, "v3/") + "graphql"
+	} else {
+		u.Path += "graphql"
+	}
+	return &u
+}
+
+// GetPullRequestsBatch fetches the given pull requests, with their labels and
+// comments, through the GraphQL API in batches of graphQLBatchSize. Numbers
+// that don't resolve to a pull request are left out of the result. Pull
+// requests with more comments than a query returns come back with nil
+// Comments so the caller fetches them through the REST API.
+func (c *Client) GetPullRequestsBatch(ctx context.Context, repo *Repository, numbers []int) (map[int]*PullRequest, error) {
+	numbers = uniqueInts(numbers)
+	result := make(map[int]*PullRequest, len(numbers))
+	for start := 0; start < len(numbers); start += graphQLBatchSize {
+		end := minInt(start+graphQLBatchSize, len(numbers))
+		if err := c.getPullRequestsBatch(ctx, repo, numbers[start:end], result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (c *Client) getPullRequestsBatch(ctx context.Context, repo *Repository, numbers []int, result map[int]*PullRequest) error {
+	var query strings.Builder
+	query.WriteString("query($owner: String!, $name: String!) {\n  repository(owner: $owner, name: $name) {\n")
+	for _, number := range numbers {
+		fmt.Fprintf(&query, "    pr%d: pullRequest(number: %d) { ...pr }\n", number, number)
+	}
+	query.WriteString("  }\n}\n")
+	fmt.Fprintf(&query, pullRequestFragment, graphQLCommentLimit)
+
+	var resp graphQLResponse
+	if err := c.queryRepository(ctx, repo, query.String(), &resp); err != nil {
+		return fmt.Errorf("failed to query pull requests for %s: %w", repo, err)
+	}
+
+	// GitHub reports unknown numbers as NOT_FOUND errors alongside the data
+	// it could resolve, so errors only fail the batch when nothing came back.
+	if len(resp.Errors) > 0 && len(resp.Data.Repository) == 0 {
+		return fmt.Errorf("GraphQL query for %s failed: %s", repo, resp.Errors[0].Message)
+	}
+
+	for _, node := range resp.Data.Repository {
+		if node == nil {
+			continue
+		}
+		result[node.Number] = node.toPullRequest()
+	}
+	return nil
+}
+
+// GetCommitPullRequestsBatch lists the pull requests containing each of shas,
+// with their labels and comments, through the GraphQL API in batches of
+// graphQLBatchSize. The result is keyed by SHA; commits GitHub doesn't know
+// are left out. This finds the pull requests of squashed and rebased commits
+// like ListPullRequestsWithCommit, without a request per commit.
+func (c *Client) GetCommitPullRequestsBatch(ctx context.Context, repo *Repository, shas []string) (map[string][]*PullRequest, error) {
+	result := make(map[string][]*PullRequest, len(shas))
+	for start := 0; start < len(shas); start += graphQLBatchSize {
+		end := minInt(start+graphQLBatchSize, len(shas))
+		if err := c.getCommitPullRequestsBatch(ctx, repo, shas[start:end], result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (c *Client) getCommitPullRequestsBatch(ctx context.Context, repo *Repository, shas []string, result map[string][]*PullRequest) error {
+	var query strings.Builder
+	query.WriteString("query($owner: String!, $name: String!) {\n  repository(owner: $owner, name: $name) {\n")
+	for i, sha := range shas {
+		fmt.Fprintf(&query, "    c%d: object(oid: %q) { ... on Commit { associatedPullRequests(first: %d) { nodes { ...pr } } } }\n",
+			i, sha, graphQLAssociatedLimit)
+	}
+	query.WriteString("  }\n}\n")
+	fmt.Fprintf(&query, pullRequestFragment, graphQLCommentLimit)
+
+	var resp graphQLCommitsResponse
+	if err := c.queryRepository(ctx, repo, query.String(), &resp); err != nil {
+		return fmt.Errorf("failed to query the pull requests of commits for %s: %w", repo, err)
+	}
+	if len(resp.Errors) > 0 && len(resp.Data.Repository) == 0 {
+		return fmt.Errorf("GraphQL query for %s failed: %s", repo, resp.Errors[0].Message)
+	}
+
+	for i, sha := range shas {
+		commit := resp.Data.Repository[fmt.Sprintf("c%d", i)]
+		if commit == nil {
+			continue
+		}
+		prs := make([]*PullRequest, 0, len(commit.AssociatedPullRequests.Nodes))
+		for _, node := range commit.AssociatedPullRequests.Nodes {
+			if node != nil {
+				prs = append(prs, node.toPullRequest())
+			}
+		}
+		result[sha] = prs
+	}
+	return nil
+}
+
+// queryRepository runs a GraphQL query taking repo's owner and name as
+// variables, decoding the response into resp.
+func (c *Client) queryRepository(ctx context.Context, repo *Repository, query string, resp interface{}) error {
+	payload := map[string]interface{}{
+		"query": query,
+		"variables": map[string]string{
+			"owner": repo.Owner,
+			"name":  repo.Name,
+		},
+	}
+
+	req, err := c.NewRequest("POST", c.graphQLURL.String(), payload)
+	if err != nil {
+		return fmt.Errorf("failed to build GraphQL request: %w", err)
+	}
+	_, err = c.Do(ctx, req, resp)
+	return err
+}
+
+func (n *graphQLPullRequest) toPullRequest() *PullRequest {
+	pr := &PullRequest{
+		Number:     n.Number,
+		Title:      n.Title,
+		Body:       n.Body,
+		BaseBranch: n.BaseRefName,
+	}
+	if n.MergedAt != nil {
+		pr.MergedAt = *n.MergedAt
+	}
+	if n.Author != nil {
+		pr.Author = n.Author.Login
+	}
+	for _, label := range n.Labels.Nodes {
+		pr.Labels = append(pr.Labels, label.Name)
+	}
+
+	if n.Comments.TotalCount <= len(n.Comments.Nodes) {
+		pr.Comments = make([]string, 0, len(n.Comments.Nodes))
+		for _, comment := range n.Comments.Nodes {
+			pr.Comments = append(pr.Comments, comment.Body)
+		}
+	}
+	return pr
+}
+
+func uniqueInts(input []int) []int {
+	seen := make(map[int]struct{}, len(input))
+	var result []int
+	for _, n := range input {
+		if _, ok := seen[n]; !ok {
+			seen[n] = struct{}{}
+			result = append(result, n)
+		}
+	}
+	return result
+}
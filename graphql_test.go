@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestGraphQLURL(t *testing.T) {
+	tests := map[string]string{
+		"https://api.github.com/":         "https://api.github.com/graphql",
+		"https://ghe.example.com/api/v3/": "https://ghe.example.com/api/graphql",
+		"http://localhost:8080/":          "http://localhost:8080/graphql",
+	}
+
+	for in, want := range tests {
+		apiURL, _ := url.Parse(in)
+		if got := graphQLURL(apiURL).String(); got != want {
+			t.Errorf("graphQLURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestGetPullRequestsBatch(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/graphql" {
+			t.Errorf("Expected GraphQL request at /api/graphql, got %s", r.URL.Path)
+		}
+		var payload struct {
+			Query     string            `json:"query"`
+			Variables map[string]string `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		queries = append(queries, payload.Query)
+		if payload.Variables["owner"] != "org" || payload.Variables["name"] != "repo" {
+			t.Errorf("Unexpected variables: %v", payload.Variables)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+		  "data": {"repository": {
+		    "pr1": {"number": 1, "title": "Add feature", "body": "Fixes TEST-1", "mergedAt": "2024-05-01T10:00:00Z",
+		            "author": {"login": "alice"}, "labels": {"nodes": [{"name": "enhancement"}]},
+		            "comments": {"totalCount": 1, "nodes": [{"body": "see PROJ-2"}]}},
+		    "pr2": {"number": 2, "title": "Busy PR", "body": "", "mergedAt": "2024-05-02T10:00:00Z",
+		            "author": null, "labels": {"nodes": []},
+		            "comments": {"totalCount": 150, "nodes": [{"body": "first"}]}},
+		    "pr3": null
+		  }},
+		  "errors": [{"message": "Could not resolve to a PullRequest with the number of 3."}]
+		}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(ClientOptions{APIURL: server.URL + "/api/v3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prs, err := client.GetPullRequestsBatch(context.Background(), &Repository{Owner: "org", Name: "repo"}, []int{1, 2, 3, 1})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(queries) != 1 {
+		t.Fatalf("Expected a single batched query, got %d", len(queries))
+	}
+	if strings.Count(queries[0], "pullRequest(number:") != 3 {
+		t.Errorf("Expected duplicate numbers to be requested once, got query:\n%s", queries[0])
+	}
+
+	if len(prs) != 2 {
+		t.Fatalf("Expected 2 pull requests, got %d", len(prs))
+	}
+
+	pr := prs[1]
+	if pr.Title != "Add feature" || pr.Author != "alice" || pr.MergedAt.Format("2006-01-02") != "2024-05-01" {
+		t.Errorf("Unexpected pull request: %+v", pr)
+	}
+	if len(pr.Labels) != 1 || pr.Labels[0] != "enhancement" {
+		t.Errorf("Expected labels to be carried over, got %v", pr.Labels)
+	}
+	if len(pr.Comments) != 1 || pr.Comments[0] != "see PROJ-2" {
+		t.Errorf("Expected comments to be carried over, got %v", pr.Comments)
+	}
+
+	if prs[2].Comments != nil {
+		t.Errorf("Expected truncated comments to be left for REST, got %d", len(prs[2].Comments))
+	}
+}
+
+func TestGetPullRequestsBatchSplitsLargeRequests(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data": {"repository": {}}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(ClientOptions{APIURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	numbers := make([]int, graphQLBatchSize*2+1)
+	for i := range numbers {
+		numbers[i] = i + 1
+	}
+	if _, err := client.GetPullRequestsBatch(context.Background(), &Repository{Owner: "org", Name: "repo"}, numbers); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 batched queries, got %d", calls)
+	}
+}
+
+func TestGetCommitPullRequestsBatch(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		queries = append(queries, payload.Query)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+		  "data": {"repository": {
+		    "c0": {"associatedPullRequests": {"nodes": [
+		      {"number": 7, "title": "Add retries", "mergedAt": "2024-05-01T10:00:00Z", "baseRefName": "main",
+		       "labels": {"nodes": []}, "comments": {"totalCount": 1, "nodes": [{"body": "see PROJ-2"}]}}
+		    ]}},
+		    "c1": {"associatedPullRequests": {"nodes": []}},
+		    "c2": null
+		  }},
+		  "errors": [{"message": "Could not resolve to a Commit with the oid of ccc."}]
+		}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(ClientOptions{APIURL: server.URL + "/api/v3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prs, err := client.GetCommitPullRequestsBatch(context.Background(), &Repository{Owner: "org", Name: "repo"}, []string{"aaa", "bbb", "ccc"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(queries) != 1 || strings.Count(queries[0], "associatedPullRequests") != 3 || !strings.Contains(queries[0], `object(oid: "bbb")`) {
+		t.Fatalf("Expected a single query for the three commits, got:\n%s", strings.Join(queries, "\n"))
+	}
+
+	if len(prs["aaa"]) != 1 || prs["aaa"][0].Number != 7 || prs["aaa"][0].BaseBranch != "main" || len(prs["aaa"][0].Comments) != 1 {
+		t.Errorf("Expected aaa to be in #7 with its comment, got %+v", prs["aaa"])
+	}
+	if prs, ok := prs["bbb"]; !ok || len(prs) != 0 {
+		t.Errorf("Expected bbb to have no pull request, got %v, %v", prs, ok)
+	}
+	if _, ok := prs["ccc"]; ok {
+		t.Error("Expected the unknown commit to be left out")
+	}
+}
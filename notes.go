@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+// NotesTemplate lays out release notes from a text/template file, in place
+// of the built-in cross-links followed by the changelog table. Besides the
+// template's own functions, it can call:
+//
+//   - escape, which makes text safe for a Markdown table cell
+//   - ticketURL, which links a JIRA ticket in the jira_org_id instance
+//   - prURL, which links a pull or merge request number on the forge
+type NotesTemplate struct {
+	Path string
+	tmpl *template.Template
+}
+
+// NotesData is what a notes template is executed with.
+type NotesData struct {
+	// Repo is the repository's display name, RepoFullName its owner/name
+	// followed by :component for a monorepo component.
+	Repo         string
+	RepoFullName string
+	// Version is the tag being released, empty in review, and
+	// PreviousVersion the tag of the last release, empty before the first.
+	Version         string
+	PreviousVersion string
+	// Entries lists every pull request in the release, including those the
+	// categories exclude. Sections groups them by category, leaving those
+	// out, as a single untitled section without categories.
+	Entries  []Entry
+	Sections []EntrySection
+	// DirectCommits lists the commits made without a pull request, with
+	// direct_commits enabled. Their Ref is the short SHA.
+	DirectCommits []Entry
+	// Tickets lists the JIRA tickets of all entries, normalized, once each.
+	Tickets    []string
+	CrossLinks []CrossLink
+	// Changelog is the built-in changelog table (or tables), for templates
+	// that only add to it.
+	Changelog string
+}
+
+// LoadNotesTemplate parses the template file at path.
+func LoadNotesTemplate(path string) (*NotesTemplate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notes template: %w", err)
+	}
+
+	placeholder := func(int) string { return "" }
+	tmpl, err := template.New(filepath.Base(path)).Funcs(notesFuncs(placeholder, "")).Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse notes template %s: %w", path, err)
+	}
+	return &NotesTemplate{Path: path, tmpl: tmpl}, nil
+}
+
+// Render executes the template with data, linking pull requests with prURL
+// and tickets in the JIRA instance of jiraOrgId.
+func (t *NotesTemplate) Render(data NotesData, prURL func(number int) string, jiraOrgId string) (string, error) {
+	tmpl, err := t.tmpl.Clone()
+	if err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Funcs(notesFuncs(prURL, jiraOrgId)).Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to execute notes template %s: %w", t.Path, err)
+	}
+	return out.String(), nil
+}
+
+func notesFuncs(prURL func(number int) string, jiraOrgId string) template.FuncMap {
+	return template.FuncMap{
+		"escape":    escapeMarkdownTable,
+		"ticketURL": func(ticket string) string { return jiraTicketURL(jiraOrgId, ticket) },
+		"prURL":     prURL,
+	}
+}
+
+// NewNotesData gathers what a notes template is executed with, grouping
+// entries by categories (which may be nil).
+func NewNotesData(entries []Entry, categories *Categories, crossLinks []CrossLink) NotesData {
+	all := entries
+	entries, commits := splitDirectCommits(entries)
+	data := NotesData{Entries: entries, DirectCommits: commits, CrossLinks: crossLinks}
+
+	switch {
+	case categories == nil:
+		if len(entries) > 0 {
+			data.Sections = []EntrySection{{Entries: entries}}
+		}
+	case len(categories.Sections) == 0:
+		// Only exclusions, whose one section needs no title
+		data.Sections = categories.Group(entries)
+		for i := range data.Sections {
+			data.Sections[i].Title = ""
+		}
+	default:
+		data.Sections = categories.Group(entries)
+	}
+
+	var tickets []string
+	for _, entry := range all {
+		for _, ticket := range entry.Tickets {
+			tickets = append(tickets, normalizeTicket(ticket))
+		}
+	}
+	data.Tickets = removeDuplicates(tickets)
+	return data
+}
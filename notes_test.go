@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/semver"
+)
+
+func writeNotesTemplate(t *testing.T, text string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "notes.tmpl")
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNotesTemplateRender(t *testing.T) {
+	tmpl, err := LoadNotesTemplate(writeNotesTemplate(t, `Install with: go install example.com/{{ .Repo }}@{{ .Version }} (was {{ .PreviousVersion }})
+{{ range .Sections }}
+## {{ .Title }}
+{{ range .Entries }}- {{ escape .Title }} ([{{ .Ref }}]({{ prURL .Number }}))
+{{ end }}{{ end }}
+Tickets:{{ range .Tickets }} [{{ . }}]({{ ticketURL . }}){{ end }}
+{{ range .CrossLinks }}See also {{ .Name }} v{{ .Version }}
+{{ end }}`))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	entries := []Entry{
+		{Number: 1, Ref: "#1", Title: "feat: add | retries", Tickets: []string{"core 12"}},
+		{Number: 2, Ref: "#2", Title: "Update docs", Tickets: []string{"CORE-12", "web-3"}},
+	}
+	categories := &Categories{Sections: []Category{{Title: "Features", Prefixes: []string{"feat"}}}, Other: "Other"}
+	data := NewNotesData(entries, categories, []CrossLink{{Name: "web", Version: "2.0.0"}})
+	data.Repo = "deployer"
+	data.Version = "v1.3.0"
+	data.PreviousVersion = "v1.2.0"
+
+	prURL := func(number int) string { return "https://example.com/pull/" + strconv.Itoa(number) }
+	notes, err := tmpl.Render(data, prURL, "my-org")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	for _, want := range []string{
+		"Install with: go install example.com/deployer@v1.3.0 (was v1.2.0)",
+		"## Features\n- feat: add \\| retries ([#1](https://example.com/pull/1))\n",
+		"## Other\n- Update docs ([#2](https://example.com/pull/2))\n",
+		"Tickets: [CORE-12](https://my-org.atlassian.net/browse/CORE-12) [WEB-3](https://my-org.atlassian.net/browse/WEB-3)\n",
+		"See also web v2.0.0",
+	} {
+		if !strings.Contains(notes, want) {
+			t.Errorf("Expected %q in the notes, got:\n%s", want, notes)
+		}
+	}
+}
+
+func TestLoadNotesTemplateErrors(t *testing.T) {
+	if _, err := LoadNotesTemplate(filepath.Join(t.TempDir(), "missing.tmpl")); err == nil {
+		t.Error("Expected a missing template to be an error")
+	}
+	if _, err := LoadNotesTemplate(writeNotesTemplate(t, "{{ range .Entries }}")); err == nil {
+		t.Error("Expected an unterminated range to be an error")
+	}
+
+	tmpl, err := LoadNotesTemplate(writeNotesTemplate(t, "{{ .Unknown }}"))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if _, err := tmpl.Render(NotesData{}, nil, ""); err == nil {
+		t.Error("Expected an unknown field to fail when rendering")
+	}
+}
+
+func TestManagerReleaseNotes(t *testing.T) {
+	manager := NewManager(&githubForge{}, NewLoggerWithLevel(ErrorLevel), nil, "", false)
+	repo := NewRepository(&Repository{Owner: "org", Name: "app"}, RepoConfig{}, "main")
+	entries := []Entry{{Number: 1, Date: "2023-01-01", Author: "ann", Title: "Fix login"}}
+	crossLinks := []CrossLink{{Name: "web", Version: "2.0.0", URL: "https://example.com"}}
+
+	notes, err := manager.ReleaseNotes(repo, "v1.0.0", entries, crossLinks)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if want := BuildCrossLinksString(crossLinks) + BuildEntriesTableString(entries, false, ""); notes != want {
+		t.Errorf("Expected the built-in layout, got:\n%s", notes)
+	}
+
+	repo.NotesTemplate, err = LoadNotesTemplate(writeNotesTemplate(t, "{{ .RepoFullName }} {{ .Version }}\n{{ .Changelog }}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo.setLatest(&ReleaseTag{Name: "v0.9.0", Version: semver.MustParse("0.9.0")}, nil)
+	notes, err = manager.ReleaseNotes(repo, "v1.0.0", entries, crossLinks)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if want := "org/app v1.0.0\n" + BuildEntriesTableString(entries, false, ""); notes != want {
+		t.Errorf("Expected the template's layout, got:\n%s", notes)
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/Masterminds/semver"
 	"github.com/manifoldco/promptui"
@@ -11,6 +12,8 @@ type BumpChoice struct {
 	Label   string
 	Type    BumpType
 	Version *semver.Version
+	// Display is Version as the prompt shows it.
+	Display string
 }
 
 type HotfixInfo struct {
@@ -18,51 +21,168 @@ type HotfixInfo struct {
 	Suffix string
 }
 
-func PromptForVersionBump(repoName string, lastVersion *semver.Version, entries []Entry) (*semver.Version, BumpType, error) {
+// BumpOptions is what the version prompt offers beyond plain semver bumps:
+// the suggested bump, and pre-release choices on Channel. Prerelease is the
+// pre-release in flight, if any. Next is set for calendar versioned
+// repositories to the version of a release today, which the prompt offers
+// instead of any semver choice. CheckCustom vets a version entered with the
+// "Custom version…" choice; any version is accepted when it's nil.
+type BumpOptions struct {
+	Suggestion  BumpSuggestion
+	Prerelease  *semver.Version
+	Channel     string
+	Scheme      VersionScheme
+	Next        *semver.Version
+	CheckCustom func(*semver.Version) error
+}
+
+// bumpChoices lists the prompt's options: skip, patch, minor and major, then
+// the pre-release choices, with the suggested bump (if any) moved to the top
+// so it's the default. With a pre-release in flight it offers the next
+// pre-release and promoting it, and only those new pre-releases that sort
+// after it. Calendar versioned repositories are only offered skip and the
+// next release, which any suggested bump recommends. On a maintenance line,
+// bumps that would leave it aren't offered. The last choice lets the version
+// be entered by hand.
+func bumpChoices(lastVersion *semver.Version, opts BumpOptions) []BumpChoice {
+	choices := []BumpChoice{
+		{Label: "Skip release", Type: BumpType("skip"), Version: lastVersion},
+	}
+	recommended := opts.Suggestion.Type
+
+	if opts.Next != nil {
+		choices = append(choices, BumpChoice{Label: "Next release", Type: BumpNext, Version: opts.Next})
+		if recommended != "" {
+			recommended = BumpNext
+		}
+	} else {
+		choices = append(choices,
+			BumpChoice{Label: "Patch", Type: BumpPatch, Version: BumpVersion(lastVersion, BumpPatch)},
+			BumpChoice{Label: "Minor", Type: BumpMinor, Version: BumpVersion(lastVersion, BumpMinor)},
+			BumpChoice{Label: "Major", Type: BumpMajor, Version: BumpVersion(lastVersion, BumpMajor)},
+		)
+		choices = append(choices, prereleaseChoices(lastVersion, opts)...)
+	}
+
+	inLine := choices[:1]
+	for _, choice := range choices[1:] {
+		if opts.Scheme.InLine(choice.Version) {
+			inLine = append(inLine, choice)
+		}
+	}
+	choices = inLine
+
+	for i := range choices {
+		choices[i].Display = opts.Scheme.DisplayVersion(choices[i].Version)
+	}
+	choices = append(choices, BumpChoice{Label: "Custom version…", Type: BumpCustom})
+	for i, choice := range choices {
+		if choice.Type == recommended {
+			choice.Label += " (recommended)"
+			return append([]BumpChoice{choice}, append(choices[:i:i], choices[i+1:]...)...)
+		}
+	}
+	return choices
+}
+
+func prereleaseChoices(lastVersion *semver.Version, opts BumpOptions) []BumpChoice {
+	var choices []BumpChoice
+	if opts.Prerelease != nil {
+		choices = append(choices,
+			BumpChoice{Label: "Next " + channelLabel(PrereleaseChannel(opts.Prerelease)), Type: BumpPrerelease, Version: NextPrerelease(opts.Prerelease)},
+			BumpChoice{Label: "Promote to final", Type: BumpPromote, Version: PromoteVersion(opts.Prerelease)},
+		)
+	}
+	if opts.Channel != "" {
+		for _, bump := range []BumpType{BumpPatch, BumpMinor, BumpMajor} {
+			version, err := StartPrerelease(lastVersion, bump, opts.Channel)
+			if err != nil || (opts.Prerelease != nil && !version.GreaterThan(opts.Prerelease)) {
+				continue
+			}
+			label := fmt.Sprintf("Next %s %s", bump, channelLabel(opts.Channel))
+			choices = append(choices, BumpChoice{Label: label, Type: BumpPrerelease, Version: version})
+		}
+	}
+	return choices
+}
+
+// channelLabel names a pre-release channel in prompt labels: "RC" for rc,
+// otherwise capitalized ("Beta").
+func channelLabel(channel string) string {
+	if channel == "" || strings.EqualFold(channel, "rc") {
+		return "RC"
+	}
+	return strings.ToUpper(channel[:1]) + channel[1:]
+}
+
+// bumpWarning explains why choosing chosen goes against the PR labels, or
+// returns "" when it satisfies them.
+func bumpWarning(chosen BumpType, suggestion BumpSuggestion) string {
+	if chosen == BumpPrerelease || chosen == BumpPromote || chosen == BumpNext || bumpRank(chosen) >= bumpRank(suggestion.Minimum) {
+		return ""
+	}
+	if chosen == "skip" {
+		return fmt.Sprintf("PR labels call for a %s release", suggestion.Minimum)
+	}
+	return fmt.Sprintf("PR labels call for a %s release, not %s", suggestion.Minimum, chosen)
+}
+
+// countPullRequests counts the entries made from a pull request, leaving out
+// direct commits.
+func countPullRequests(entries []Entry) int {
+	count := 0
+	for _, entry := range entries {
+		if entry.SHA == "" {
+			count++
+		}
+	}
+	return count
+}
+
+func PromptForVersionBump(repoName string, lastVersion *semver.Version, entries []Entry, opts BumpOptions) (*semver.Version, BumpType, error) {
 	fmt.Printf("\n=== %s ===\n", repoName)
 	
 	// Check if this is a new project (no previous releases)
 	isNewProject := lastVersion.String() == "0.0.0"
+	pullRequests := countPullRequests(entries)
 	if isNewProject {
-		fmt.Printf("No previous releases found, %d PR's for initial release\n", len(entries))
+		fmt.Printf("No previous releases found, %d PR's for initial release\n", pullRequests)
 	} else {
-		fmt.Printf("Last version: %s, %d PR's since then\n", FormatVersion(lastVersion), len(entries))
+		fmt.Printf("Last version: %s, %d PR's since then\n", opts.Scheme.FormatVersion(lastVersion), pullRequests)
 	}
 	
 	// Show recent PRs
 	for _, entry := range entries {
-		fmt.Printf(" - #%d %s\n", entry.Number, entry.Title)
+		fmt.Printf(" - %s %s\n", entry.DisplayRef(), entry.Title)
+	}
+	if opts.Prerelease != nil {
+		fmt.Printf("Pre-release in flight: %s\n", opts.Scheme.FormatVersion(opts.Prerelease))
+	}
+	if opts.Suggestion.Type != "" {
+		fmt.Printf("Suggested bump: %s\n", opts.Suggestion.Reason)
 	}
 
 	templates := &promptui.SelectTemplates{
 		Label:    "{{ . }}?",
-		Active:   fmt.Sprintf("%s {{ .Label | cyan | underline }} ({{ .Version | green }})", promptui.Styler(promptui.FGGreen)("⇨")),
-		Inactive: "  {{ .Label | cyan }} ({{ .Version | green }})",
-		Selected: fmt.Sprintf("%s {{ .Label}} to {{ .Version | green | cyan }}", promptui.IconGood),
+		Active:   fmt.Sprintf("%s {{ .Label | cyan | underline }}{{ if .Display }} ({{ .Display | green }}){{ end }}", promptui.Styler(promptui.FGGreen)("⇨")),
+		Inactive: "  {{ .Label | cyan }}{{ if .Display }} ({{ .Display | green }}){{ end }}",
+		Selected: fmt.Sprintf("%s {{ .Label}}{{ if .Display }} to {{ .Display | green | cyan }}{{ end }}", promptui.IconGood),
 	}
 
-	major := BumpVersion(lastVersion, BumpMajor)
-	minor := BumpVersion(lastVersion, BumpMinor)
-	patch := BumpVersion(lastVersion, BumpPatch)
-
-	choices := []BumpChoice{
-		{Label: "Skip release", Type: BumpType("skip"), Version: lastVersion},
-		{Label: "Patch", Type: BumpPatch, Version: patch},
-		{Label: "Minor", Type: BumpMinor, Version: minor},
-		{Label: "Major", Type: BumpMajor, Version: major},
-	}
+	choices := bumpChoices(lastVersion, opts)
 
 	var promptLabel string
 	if isNewProject {
 		promptLabel = "Create initial release version"
 	} else {
-		promptLabel = fmt.Sprintf("Last version was %s, shall we bump", FormatVersion(lastVersion))
+		promptLabel = fmt.Sprintf("Last version was %s, shall we bump", opts.Scheme.FormatVersion(lastVersion))
 	}
 
 	prompt := promptui.Select{
 		Label:     promptLabel,
 		Items:     choices,
 		Templates: templates,
+		Size:      len(choices),
 	}
 
 	i, _, err := prompt.Run()
@@ -72,16 +192,64 @@ func PromptForVersionBump(repoName string, lastVersion *semver.Version, entries
 	}
 
 	choice := choices[i]
-	if i == 0 { // skip release
+	bump := choice.Type
+	if choice.Type == BumpCustom {
+		choice.Version, err = promptForCustomVersion(opts.CheckCustom)
+		if err != nil {
+			return nil, "", err
+		}
+		// A custom version is held to the labels like the bump it amounts to
+		bump = BumpNext
+		if opts.Next == nil {
+			bump = bumpBetween(lastVersion, choice.Version)
+		}
+	}
+	if warning := bumpWarning(bump, opts.Suggestion); warning != "" {
+		fmt.Printf("%s %s\n", promptui.Styler(promptui.FGYellow)("Warning:"), warning)
+	}
+	if choice.Type == "skip" {
 		return nil, BumpType("skip"), nil
 	}
 
 	return choice.Version, choice.Type, nil
 }
 
+func promptForCustomVersion(check func(*semver.Version) error) (*semver.Version, error) {
+	prompt := promptui.Prompt{
+		Label: "Version to release",
+		Validate: func(input string) error {
+			_, err := parseCustomVersion(input, check)
+			return err
+		},
+	}
+	input, err := prompt.Run()
+	if err != nil {
+		fmt.Printf("Prompt failed %v\n", err)
+		return nil, err
+	}
+	return parseCustomVersion(input, check)
+}
+
+// parseCustomVersion parses a version entered at the prompt, with or
+// without a "v" prefix, and vets it with check.
+func parseCustomVersion(input string, check func(*semver.Version) error) (*semver.Version, error) {
+	version, err := ParseVersion(strings.TrimSpace(input))
+	if err != nil {
+		return nil, err
+	}
+	if check != nil {
+		if err := check(version); err != nil {
+			return nil, err
+		}
+	}
+	return version, nil
+}
+
 
-func PromptForHotfixSuffix(lastVersion *semver.Version, sha string) (string, error) {
-	fmt.Printf("Last version: %s\n", FormatVersion(lastVersion))
+// PromptForHotfixSuffix asks for the build metadata of a hotfix of
+// lastVersion, the tag of the repository's latest release.
+func PromptForHotfixSuffix(lastVersion string, sha string) (string, error) {
+	fmt.Printf("Last version: %s\n", lastVersion)
 	fmt.Printf("Hotfix SHA: %s\n", sha)
 
 	prompt := promptui.Prompt{
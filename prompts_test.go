@@ -1,6 +1,8 @@
 package main
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/Masterminds/semver"
@@ -34,9 +36,205 @@ func TestPromptFunctionsExist(t *testing.T) {
 	
 	// These functions would normally prompt for user input, so we can't call them in tests
 	// But we can verify they exist and have the correct signatures
-	var f1 func(string, *semver.Version, []Entry) (*semver.Version, BumpType, error) = PromptForVersionBump
-	var f3 func(*semver.Version, string) (string, error) = PromptForHotfixSuffix
+	var f1 func(string, *semver.Version, []Entry, BumpOptions) (*semver.Version, BumpType, error) = PromptForVersionBump
+	var f3 func(string, string) (string, error) = PromptForHotfixSuffix
 	
 	_ = f1
 	_ = f3
-}
\ No newline at end of file
+}
+func TestBumpChoices(t *testing.T) {
+	v := semver.MustParse("1.2.3")
+
+	tests := []struct {
+		name       string
+		suggestion BumpSuggestion
+		wantLabels []string
+	}{
+		{"no suggestion", BumpSuggestion{}, []string{"Skip release", "Patch", "Minor", "Major", "Custom version…"}},
+		{"minor", BumpSuggestion{Type: BumpMinor, Reason: "minor: 1 feat PR"}, []string{"Minor (recommended)", "Skip release", "Patch", "Major", "Custom version…"}},
+		{"major", BumpSuggestion{Type: BumpMajor}, []string{"Major (recommended)", "Skip release", "Patch", "Minor", "Custom version…"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			choices := bumpChoices(v, BumpOptions{Suggestion: tt.suggestion})
+			if len(choices) != len(tt.wantLabels) {
+				t.Fatalf("Expected %d choices, got %d", len(tt.wantLabels), len(choices))
+			}
+			for i, label := range tt.wantLabels {
+				if choices[i].Label != label {
+					t.Errorf("Expected choice %d to be %q, got %q", i, label, choices[i].Label)
+				}
+			}
+		})
+	}
+
+	choices := bumpChoices(v, BumpOptions{Suggestion: BumpSuggestion{Type: BumpMinor}})
+	if choices[0].Version.String() != "1.3.0" {
+		t.Errorf("Expected the recommended choice to carry its version, got %s", choices[0].Version)
+	}
+}
+
+func TestBumpChoicesPrerelease(t *testing.T) {
+	v := semver.MustParse("1.2.3")
+
+	tests := []struct {
+		name       string
+		opts       BumpOptions
+		wantLabels []string
+		wantVers   []string
+	}{
+		{
+			name:       "no pre-release in flight",
+			opts:       BumpOptions{Channel: "rc"},
+			wantLabels: []string{"Skip release", "Patch", "Minor", "Major", "Next patch RC", "Next minor RC", "Next major RC"},
+			wantVers:   []string{"1.2.3", "1.2.4", "1.3.0", "2.0.0", "1.2.4-rc.1", "1.3.0-rc.1", "2.0.0-rc.1"},
+		},
+		{
+			name:       "rc in flight",
+			opts:       BumpOptions{Channel: "rc", Prerelease: semver.MustParse("1.3.0-rc.2")},
+			wantLabels: []string{"Skip release", "Patch", "Minor", "Major", "Next RC", "Promote to final", "Next major RC"},
+			wantVers:   []string{"1.2.3", "1.2.4", "1.3.0", "2.0.0", "1.3.0-rc.3", "1.3.0", "2.0.0-rc.1"},
+		},
+		{
+			name:       "beta channel",
+			opts:       BumpOptions{Channel: "beta", Prerelease: semver.MustParse("1.2.4-beta.1")},
+			wantLabels: []string{"Skip release", "Patch", "Minor", "Major", "Next Beta", "Promote to final", "Next minor Beta", "Next major Beta"},
+			wantVers:   []string{"1.2.3", "1.2.4", "1.3.0", "2.0.0", "1.2.4-beta.2", "1.2.4", "1.3.0-beta.1", "2.0.0-beta.1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The custom version choice always comes last
+			choices := bumpChoices(v, tt.opts)
+			if len(choices) != len(tt.wantLabels)+1 || choices[len(choices)-1].Type != BumpCustom {
+				t.Fatalf("Expected %d choices and a custom version, got %+v", len(tt.wantLabels), choices)
+			}
+			for i := range tt.wantLabels {
+				if choices[i].Label != tt.wantLabels[i] || choices[i].Version.String() != tt.wantVers[i] {
+					t.Errorf("Expected choice %d to be %q (%s), got %q (%s)", i, tt.wantLabels[i], tt.wantVers[i], choices[i].Label, choices[i].Version)
+				}
+			}
+		})
+	}
+}
+
+func TestBumpChoicesCalVer(t *testing.T) {
+	calver, err := ParseCalVer("YY.0M.MICRO")
+	if err != nil {
+		t.Fatal(err)
+	}
+	last, _ := ParseVersion("26.09.2")
+	next, _ := ParseVersion("26.10.0")
+	opts := BumpOptions{Scheme: VersionScheme{CalVer: calver}, Next: next, Channel: "rc"}
+
+	choices := bumpChoices(last, opts)
+	if len(choices) != 3 || choices[0].Label != "Skip release" || choices[1].Label != "Next release" || choices[2].Type != BumpCustom {
+		t.Fatalf("Expected only skip, the next release and a custom version, got %+v", choices)
+	}
+	if choices[1].Display != "26.10.0" {
+		t.Errorf("Expected the next release to be shown as 26.10.0, got %s", choices[1].Display)
+	}
+
+	opts.Suggestion = BumpSuggestion{Type: BumpMinor}
+	choices = bumpChoices(last, opts)
+	if choices[0].Label != "Next release (recommended)" {
+		t.Errorf("Expected any suggestion to recommend the next release, got %q", choices[0].Label)
+	}
+}
+
+func TestBumpChoicesLine(t *testing.T) {
+	line, err := ParseVersionLine("1.4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	last, _ := ParseVersion("v1.4.7")
+	opts := BumpOptions{Scheme: VersionScheme{Line: line}, Channel: "rc", Suggestion: BumpSuggestion{Type: BumpMinor}}
+
+	var labels []string
+	for _, choice := range bumpChoices(last, opts) {
+		labels = append(labels, choice.Label)
+	}
+	if got := strings.Join(labels, ", "); got != "Skip release, Patch, Next patch RC, Custom version…" {
+		t.Errorf("Expected only the bumps staying on 1.4, got %s", got)
+	}
+}
+
+func TestBumpWarning(t *testing.T) {
+	tests := []struct {
+		chosen  BumpType
+		minimum BumpType
+		want    string
+	}{
+		{BumpMajor, BumpMajor, ""},
+		{BumpMajor, BumpMinor, ""},
+		{BumpPatch, "", ""},
+		{BumpPatch, BumpMinor, "PR labels call for a minor release, not patch"},
+		{BumpType("skip"), BumpPatch, "PR labels call for a patch release"},
+		{BumpPrerelease, BumpMajor, ""},
+		{BumpPromote, BumpMajor, ""},
+		{bumpBetween(semver.MustParse("1.4.7"), semver.MustParse("1.4.9")), BumpMinor, "PR labels call for a minor release, not patch"},
+		{bumpBetween(semver.MustParse("1.4.7"), semver.MustParse("2.0.0-beta.1")), BumpMinor, ""},
+	}
+
+	for _, tt := range tests {
+		if got := bumpWarning(tt.chosen, BumpSuggestion{Minimum: tt.minimum}); got != tt.want {
+			t.Errorf("bumpWarning(%s, %s): expected %q, got %q", tt.chosen, tt.minimum, tt.want, got)
+		}
+	}
+}
+
+func TestParseCustomVersion(t *testing.T) {
+	newerThan := func(latest string) func(*semver.Version) error {
+		return func(v *semver.Version) error {
+			if !v.GreaterThan(semver.MustParse(latest)) {
+				return fmt.Errorf("%s isn't newer than %s", v, latest)
+			}
+			return nil
+		}
+	}
+
+	tests := []struct {
+		name        string
+		input       string
+		check       func(*semver.Version) error
+		expected    string
+		expectError bool
+	}{
+		{name: "any version", input: "4.1.0", expected: "4.1.0"},
+		{name: "v prefix and spaces", input: " v4.1.0 ", expected: "4.1.0"},
+		{name: "pre-release", input: "4.1.0-beta.1", check: newerThan("3.0.0"), expected: "4.1.0-beta.1"},
+		{name: "not a version", input: "four", expectError: true},
+		{name: "rejected by check", input: "2.9.0", check: newerThan("3.0.0"), expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := parseCustomVersion(tt.input, tt.check)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got %s", version)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if version.String() != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, version)
+			}
+		})
+	}
+}
+
+func TestCountPullRequests(t *testing.T) {
+	entries := []Entry{
+		{Number: 1, Title: "Fix login"},
+		{SHA: "abc1234", Ref: "abc1234", Title: "Bump dependencies"},
+		{Number: 2, Title: "Add search"},
+	}
+	if got := countPullRequests(entries); got != 2 {
+		t.Errorf("Expected 2 pull requests, got %d", got)
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMaxRetries    = 5
+	defaultRetryDelay    = time.Second
+	defaultMaxRetryDelay = time.Minute
+	// maxRateLimitWait bounds how long a request may block waiting for the
+	// primary rate limit window to reset; GitHub's window is one hour.
+	maxRateLimitWait = time.Hour
+)
+
+// rateLimitTransport sits under the GitHub client and keeps long project
+// releases alive through GitHub's rate limits. It:
+//
+//   - waits out primary rate limits (X-RateLimit-Remaining: 0) until the
+//     X-RateLimit-Reset time, and retries the rejected request;
+//   - waits out secondary (abuse) rate limits for Retry-After, or a jittered
+//     backoff when GitHub doesn't say, and retries the rejected request;
+//   - retries 5xx responses and network errors with jittered backoff, but only
+//     for idempotent methods, so a POST such as CreateRelease that may already
+//     have been applied is never sent twice.
+//
+// Rate-limited requests are retried regardless of method because GitHub
+// rejects them before doing any work.
+type rateLimitTransport struct {
+	base       http.RoundTripper
+	logger     *Logger
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+	maxWait    time.Duration
+	now        func() time.Time
+	sleep      func(ctx context.Context, d time.Duration) error
+}
+
+func newRateLimitTransport(base http.RoundTripper, logger *Logger) *rateLimitTransport {
+	return &rateLimitTransport{
+		base:       base,
+		logger:     logger,
+		maxRetries: defaultMaxRetries,
+		baseDelay:  defaultRetryDelay,
+		maxDelay:   defaultMaxRetryDelay,
+		maxWait:    maxRateLimitWait,
+		now:        time.Now,
+		sleep:      sleepContext,
+	}
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		outgoing := req
+		if attempt > 0 {
+			var err error
+			if outgoing, err = rewindBody(req); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := t.base.RoundTrip(outgoing)
+		if err != nil {
+			if !isIdempotent(req.Method) || !canRewind(req) || attempt >= t.maxRetries || req.Context().Err() != nil {
+				return nil, err
+			}
+			delay := t.backoff(attempt)
+			t.logger.Debug("%s %s failed (%v), retrying in %s", req.Method, req.URL.Path, err, delay)
+			if err := t.sleep(req.Context(), delay); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		t.logBudget(resp)
+
+		delay, reason, retry := t.retryDelay(req, resp, attempt)
+		if !retry || attempt >= t.maxRetries || !canRewind(req) {
+			if err := t.waitForReset(req, resp); err != nil {
+				resp.Body.Close()
+				return nil, err
+			}
+			return resp, nil
+		}
+
+		t.logger.Warn("%s %s: %s, retrying in %s (attempt %d of %d)",
+			req.Method, req.URL.Path, reason, delay.Round(time.Second), attempt+1, t.maxRetries)
+		drainAndClose(resp)
+		if err := t.sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryDelay decides whether resp should be retried and how long to wait
+// first. reason describes the failure for the log.
+func (t *rateLimitTransport) retryDelay(req *http.Request, resp *http.Response, attempt int) (time.Duration, string, bool) {
+	switch {
+	case resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests:
+		if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			wait, ok := t.untilReset(resp)
+			if !ok {
+				return 0, "", false
+			}
+			return wait, "primary rate limit exceeded", true
+		}
+		if after, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			if after > t.maxWait {
+				return 0, "", false
+			}
+			return after, "secondary rate limit exceeded", true
+		}
+		if resp.StatusCode == http.StatusTooManyRequests || isAbuseResponse(resp) {
+			return t.backoff(attempt), "secondary rate limit exceeded", true
+		}
+		return 0, "", false
+	case resp.StatusCode >= 500 && isIdempotent(req.Method):
+		return t.backoff(attempt), fmt.Sprintf("server error %d", resp.StatusCode), true
+	default:
+		return 0, "", false
+	}
+}
+
+// waitForReset blocks after a successful response that used up the last of
+// the budget. go-github refuses to send anything until the reset time once it
+// has seen Remaining: 0, so waiting here lets the next call go through.
+func (t *rateLimitTransport) waitForReset(req *http.Request, resp *http.Response) error {
+	if resp.StatusCode >= 300 || resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return nil
+	}
+	wait, ok := t.untilReset(resp)
+	if !ok || wait <= 0 {
+		return nil
+	}
+	t.logger.Warn("GitHub rate limit exhausted, waiting %s for it to reset", wait.Round(time.Second))
+	return t.sleep(req.Context(), wait)
+}
+
+// untilReset returns how long until the X-RateLimit-Reset time of resp, or
+// false when the header is missing or the wait exceeds maxWait.
+func (t *rateLimitTransport) untilReset(resp *http.Response) (time.Duration, bool) {
+	reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	// A second of slack covers clock skew between us and GitHub.
+	wait := time.Unix(reset, 0).Sub(t.now()) + time.Second
+	if wait < 0 {
+		wait = 0
+	}
+	if wait > t.maxWait {
+		return 0, false
+	}
+	return wait, true
+}
+
+// backoff returns an exponentially growing delay for attempt with jitter
+// between half and the full value, capped at maxDelay.
+func (t *rateLimitTransport) backoff(attempt int) time.Duration {
+	delay := t.baseDelay << uint(attempt)
+	if delay <= 0 || delay > t.maxDelay {
+		delay = t.maxDelay
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+func (t *rateLimitTransport) logBudget(resp *http.Response) {
+	remaining := resp.Header.Get("X-RateLimit-Remaining")
+	if remaining == "" {
+		return
+	}
+	reset := "unknown"
+	if secs, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		reset = time.Unix(secs, 0).Format("15:04:05")
+	}
+	t.logger.Debug("GitHub rate limit: %s of %s requests remaining, resets at %s",
+		remaining, resp.Header.Get("X-RateLimit-Limit"), reset)
+}
+
+// isAbuseResponse reports whether a 403 is GitHub's secondary rate limit
+// rather than a permissions problem. The body is restored for the caller.
+func isAbuseResponse(resp *http.Response) bool {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	text := strings.ToLower(string(body))
+	return strings.Contains(text, "secondary rate limit") || strings.Contains(text, "abuse")
+}
+
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	secs, err := strconv.Atoi(value)
+	if err != nil || secs < 0 {
+		return 0, false
+	}
+	return time.Duration(secs) * time.Second, true
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// canRewind reports whether req can be sent again: it either has no body or a
+// body that can be recreated. Streamed bodies such as asset uploads can't.
+func canRewind(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// rewindBody returns a copy of req to send again, with a fresh body. req
+// itself is left alone, as RoundTrip mustn't modify its request.
+func rewindBody(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	if req.GetBody == nil {
+		return nil, fmt.Errorf("request body for %s %s cannot be replayed", req.Method, req.URL.Path)
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("failed to rewind request body for retry: %w", err)
+	}
+	retry := req.Clone(req.Context())
+	retry.Body = body
+	return retry, nil
+}
+
+func drainAndClose(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestRateLimitTransport returns a transport that records its sleeps
+// instead of waiting.
+func newTestRateLimitTransport(slept *[]time.Duration) *rateLimitTransport {
+	transport := newRateLimitTransport(http.DefaultTransport, NewLoggerWithLevel(ErrorLevel))
+	transport.sleep = func(ctx context.Context, d time.Duration) error {
+		*slept = append(*slept, d)
+		return nil
+	}
+	return transport
+}
+
+func TestRateLimitTransportRetries(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        string
+		firstStatus   int
+		firstHeaders  map[string]string
+		firstBody     string
+		expectedCalls int
+		expectedCode  int
+	}{
+		{
+			name:          "GET retried after server error",
+			method:        http.MethodGet,
+			firstStatus:   http.StatusBadGateway,
+			expectedCalls: 2,
+			expectedCode:  http.StatusOK,
+		},
+		{
+			name:          "POST not retried after server error",
+			method:        http.MethodPost,
+			firstStatus:   http.StatusBadGateway,
+			expectedCalls: 1,
+			expectedCode:  http.StatusBadGateway,
+		},
+		{
+			name:          "POST retried after secondary rate limit with Retry-After",
+			method:        http.MethodPost,
+			firstStatus:   http.StatusForbidden,
+			firstHeaders:  map[string]string{"Retry-After": "3"},
+			expectedCalls: 2,
+			expectedCode:  http.StatusOK,
+		},
+		{
+			name:          "GET retried after abuse message without Retry-After",
+			method:        http.MethodGet,
+			firstStatus:   http.StatusForbidden,
+			firstBody:     `{"message": "You have exceeded a secondary rate limit."}`,
+			expectedCalls: 2,
+			expectedCode:  http.StatusOK,
+		},
+		{
+			name:          "GET retried after primary rate limit",
+			method:        http.MethodGet,
+			firstStatus:   http.StatusForbidden,
+			firstHeaders:  map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": fmt.Sprint(time.Now().Add(30 * time.Second).Unix())},
+			expectedCalls: 2,
+			expectedCode:  http.StatusOK,
+		},
+		{
+			name:          "permission error not retried",
+			method:        http.MethodGet,
+			firstStatus:   http.StatusForbidden,
+			firstBody:     `{"message": "Resource not accessible by integration"}`,
+			expectedCalls: 1,
+			expectedCode:  http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			var bodies []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				body, _ := io.ReadAll(r.Body)
+				bodies = append(bodies, string(body))
+				if calls == 1 {
+					for k, v := range tt.firstHeaders {
+						w.Header().Set(k, v)
+					}
+					w.WriteHeader(tt.firstStatus)
+					w.Write([]byte(tt.firstBody))
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			var slept []time.Duration
+			client := &http.Client{Transport: newTestRateLimitTransport(&slept)}
+
+			req, err := http.NewRequest(tt.method, server.URL, strings.NewReader(`{"tag_name":"v1.0.0"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			original := req.Body
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			resp.Body.Close()
+
+			if req.Body != original {
+				t.Error("Expected the caller's request body to be left alone")
+			}
+			if calls != tt.expectedCalls {
+				t.Errorf("Expected %d calls, got %d", tt.expectedCalls, calls)
+			}
+			if resp.StatusCode != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, resp.StatusCode)
+			}
+			for i, body := range bodies {
+				if body != `{"tag_name":"v1.0.0"}` {
+					t.Errorf("Call %d: expected the request body to be resent, got %q", i+1, body)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitTransportHonorsRetryAfter(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var slept []time.Duration
+	client := &http.Client{Transport: newTestRateLimitTransport(&slept)}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	resp.Body.Close()
+
+	if len(slept) != 1 || slept[0] != 7*time.Second {
+		t.Errorf("Expected a single 7s wait, got %v", slept)
+	}
+}
+
+func TestRateLimitTransportGivesUp(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var slept []time.Duration
+	transport := newTestRateLimitTransport(&slept)
+	transport.maxRetries = 2
+	client := &http.Client{Transport: transport}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	resp.Body.Close()
+
+	if calls != 3 {
+		t.Errorf("Expected 1 call plus 2 retries, got %d calls", calls)
+	}
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected the final 503 to be returned, got %d", resp.StatusCode)
+	}
+}
+
+func TestRateLimitTransportWaitsWhenBudgetExhausted(t *testing.T) {
+	reset := time.Now().Add(20 * time.Second)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", fmt.Sprint(reset.Unix()))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var slept []time.Duration
+	client := &http.Client{Transport: newTestRateLimitTransport(&slept)}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	resp.Body.Close()
+
+	if len(slept) != 1 || slept[0] < 15*time.Second || slept[0] > 25*time.Second {
+		t.Errorf("Expected a wait of about 20s until reset, got %v", slept)
+	}
+}
+
+func TestBackoffIsJitteredAndCapped(t *testing.T) {
+	transport := newRateLimitTransport(http.DefaultTransport, NewLogger())
+	for attempt := 0; attempt < 10; attempt++ {
+		full := transport.baseDelay << uint(attempt)
+		if full > transport.maxDelay {
+			full = transport.maxDelay
+		}
+		delay := transport.backoff(attempt)
+		if delay < full/2 || delay > full {
+			t.Errorf("attempt %d: expected delay between %v and %v, got %v", attempt, full/2, full, delay)
+		}
+	}
+}
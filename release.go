@@ -2,12 +2,12 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/Masterminds/semver"
-	"github.com/google/go-github/v28/github"
 )
 
 type Type string
@@ -19,16 +19,28 @@ const (
 )
 
 type Manager struct {
-	client    *Client
+	forge     Forge
 	logger    *Logger
 	generator *Generator
 	jiraOrgId string
 	dryRun    bool
+	graphQL   bool
+	offline   bool
+	autoBump  bool
+	// force accepts any custom version entered at the prompt.
+	force bool
+	// prereleaseChannel names new pre-releases, e.g. "rc".
+	prereleaseChannel string
+	// bumpLabels maps lower-case PR labels to the bump they demand.
+	bumpLabels map[string]BumpType
+	// categories are the configured release note sections, for repositories
+	// without a release.yml of their own.
+	categories *Categories
 }
 
-func NewManager(client *Client, logger *Logger, jiraBoards []string, jiraOrgId string, dryRun bool) *Manager {
+func NewManager(forge Forge, logger *Logger, jiraBoards []string, jiraOrgId string, dryRun bool) *Manager {
 	return &Manager{
-		client:    client,
+		forge:     forge,
 		logger:    logger,
 		generator: NewGenerator(jiraBoards),
 		jiraOrgId: jiraOrgId,
@@ -45,9 +57,60 @@ type ReleaseRepository struct {
 	AssetPath       string
 	LatestRelease   *semver.Version
 	CommitSHA       string
+	LocalGit        bool
+	Forge           Forge
+	Scheme          VersionScheme
+	// LatestTag is the tag LatestRelease was released under, empty when
+	// there is no release yet.
+	LatestTag string
+	// LatestPrerelease is the highest pre-release above LatestRelease, or
+	// nil when no pre-release is in flight.
+	LatestPrerelease    *semver.Version
+	LatestPrereleaseTag string
+	// Commits lists the commits since LatestRelease, as of the last
+	// GenerateChangelog. It's empty for repositories without a release.
+	Commits []Commit
+	// Component names the monorepo component this is, released from the
+	// pull requests changing files under Paths. Both are empty for a
+	// repository released as a whole.
+	Component string
+	Paths     []string
+	// VersionSource is where the latest version is read from, the forge's
+	// latest release by default or VersionSourceTags.
+	VersionSource string
+	// Categories groups the release notes into sections, or is nil for a
+	// single table. The first changelog generated for the repository loads it
+	// from its .github/release.yml, falling back to the configured categories.
+	Categories       *Categories
+	categoriesLoaded bool
+	// NotesTemplate lays out the release notes, or is nil for the built-in
+	// layout.
+	NotesTemplate *NotesTemplate
+	// ChangelogFile is the path, in the checkout at AssetPath, of a
+	// CHANGELOG.md that releases add a section to, and ChangelogMessage the
+	// message of the commit doing so. ChangelogFile is empty when there's no
+	// such file.
+	ChangelogFile    string
+	ChangelogMessage string
+	// DirectCommits lists the commits made on the branch without a pull
+	// request in the changelog, instead of leaving them out.
+	DirectCommits bool
+	// changes holds the pull requests and direct commits last found for the
+	// changelog, so that HasChanges and GenerateChangelog share the lookup.
+	changes *changeSet
 }
 
+// changeSet is what getPRsForChangelog found between the refs in span.
+type changeSet struct {
+	span   string
+	prs    []*PullRequest
+	direct []Commit
+}
+
+// NewRepository builds a release repository from its validated
+// configuration.
 func NewRepository(repo *Repository, cfg RepoConfig, commitSHA string) *ReleaseRepository {
+	scheme, _ := cfg.Scheme()
 	return &ReleaseRepository{
 		Repository:       repo,
 		Alias:            cfg.Alias,
@@ -56,14 +119,107 @@ func NewRepository(repo *Repository, cfg RepoConfig, commitSHA string) *ReleaseR
 		GenerateAssets:   cfg.GenerateAssets,
 		AssetPath:        cfg.Path,
 		CommitSHA:        commitSHA,
+		LocalGit:         cfg.LocalGit,
+		Scheme:           scheme,
+		VersionSource:    cfg.VersionSource,
+		ChangelogFile:    cfg.ChangelogFile,
+		ChangelogMessage: cfg.GetChangelogMessage(),
+		DirectCommits:    cfg.DirectCommits,
+	}
+}
+
+// NewRepositories returns the release repositories configured by cfg: the
+// repository itself, or one per component in a monorepo. Each component's
+// tags are the repository's tag pattern behind its prefix.
+func NewRepositories(repo *Repository, cfg RepoConfig, commitSHA string) []*ReleaseRepository {
+	if len(cfg.Components) == 0 {
+		return []*ReleaseRepository{NewRepository(repo, cfg, commitSHA)}
+	}
+
+	var repos []*ReleaseRepository
+	for _, component := range cfg.Components {
+		r := NewRepository(repo, cfg, commitSHA)
+		prefix := component.TagPrefix
+		if prefix == "" {
+			prefix = component.Name + "/"
+		}
+		r.Scheme.TagPattern = prefix + r.Scheme.tagPattern()
+		r.Component = component.Name
+		r.Paths = component.Paths
+		repos = append(repos, r)
+	}
+	return repos
+}
+
+// forgeFor returns the forge repo is hosted on, falling back to the
+// manager's default forge when the repo has none of its own.
+func (m *Manager) forgeFor(repo *ReleaseRepository) Forge {
+	if repo.Forge != nil {
+		return repo.Forge
 	}
+	return m.forge
+}
+
+// readsLocalGit reports whether commits for repo are listed from its local
+// checkout instead of the forge's compare API, which is always the case
+// offline.
+func (m *Manager) readsLocalGit(repo *ReleaseRepository) bool {
+	return m.offline || repo.LocalGit
+}
+
+// localCommits lists base..head (everything up to head when base is empty)
+// from repo's local checkout, fetching first unless offline.
+func (m *Manager) localCommits(repo *ReleaseRepository, base, head string) ([]Commit, error) {
+	if repo.AssetPath == "" {
+		return nil, fmt.Errorf("%s has no local checkout (path) to read commits from", repo.Repository)
+	}
+	return LocalCommitsBetween(repo.AssetPath, base, head, !m.offline)
 }
 
 func (r *ReleaseRepository) GetDisplayName() string {
+	name := r.Name
 	if r.Alias != "" {
-		return r.Alias
+		name = r.Alias
+	}
+	if r.Component != "" {
+		name += ":" + r.Component
+	}
+	return name
+}
+
+// FullName returns owner/name, followed by :component for a monorepo
+// component.
+func (r *ReleaseRepository) FullName() string {
+	name := r.Repository.String()
+	if r.Component != "" {
+		name += ":" + r.Component
+	}
+	return name
+}
+
+// matchesName reports whether name, either the short name (e.g. "qa-review")
+// or the full "owner/repo" spec, selects r. A monorepo's name selects every
+// component in it, and name:component a single one.
+func (r *ReleaseRepository) matchesName(name string) bool {
+	name, component, hasComponent := strings.Cut(name, ":")
+	if hasComponent && component != r.Component {
+		return false
 	}
-	return r.Name
+	return r.Name == name || r.Repository.String() == name
+}
+
+// touchesPaths reports whether any of files is one of paths or is under one
+// of them.
+func touchesPaths(files, paths []string) bool {
+	for _, file := range files {
+		for _, path := range paths {
+			path = strings.Trim(path, "/")
+			if path == "" || file == path || strings.HasPrefix(file, path+"/") {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 type Release struct {
@@ -72,27 +228,210 @@ type Release struct {
 	Changelog  []Entry
 }
 
+// ResolveVersions finds repo's latest release and any pre-release in flight
+// above it. A maintenance line must already have a release to continue
+// from.
 func (m *Manager) ResolveVersions(ctx context.Context, repo *ReleaseRepository) error {
-	latestRelease, err := m.client.GetLatestRelease(repo.Repository)
+	if err := m.resolveVersions(ctx, repo); err != nil {
+		return err
+	}
+	if repo.Scheme.Line != nil && repo.LatestTag == "" {
+		return fmt.Errorf("%s has no release in the %s line", repo.GetDisplayName(), repo.Scheme.Line)
+	}
+	return nil
+}
+
+func (m *Manager) resolveVersions(ctx context.Context, repo *ReleaseRepository) error {
+	if m.offline {
+		if repo.AssetPath == "" {
+			return fmt.Errorf("%s has no local checkout (path) to read tags from", repo.Repository)
+		}
+		final, prerelease, err := LatestLocalTags(repo.AssetPath, repo.CommitSHA, false, repo.Scheme)
+		if err != nil {
+			return err
+		}
+		repo.setLatest(final, prerelease)
+		return nil
+	}
+
+	if repo.VersionSource == VersionSourceTags {
+		final, prerelease, err := m.latestTagsOnBranch(ctx, repo)
+		if err != nil {
+			return err
+		}
+		repo.setLatest(final, prerelease)
+		return nil
+	}
+
+	var latest *ReleaseTag
+	latestRelease, err := m.forgeFor(repo).GetLatestRelease(ctx, repo.Repository)
+	if err == nil {
+		v, err := repo.Scheme.ParseTag(latestRelease.TagName)
+		if err != nil && repo.Scheme.TagPattern == "" {
+			return fmt.Errorf("failed to parse latest release version: %w", err)
+		}
+		if err == nil && repo.Scheme.InLine(v) {
+			latest = &ReleaseTag{Name: latestRelease.TagName, Version: v}
+		}
+	}
+
+	// The forge's latest release may not be this repository's: with a
+	// tag_pattern it can belong to another package, with a maintenance line
+	// it's usually on a newer line, and forges without a pre-release flag
+	// (GitLab) may report a pre-release. The highest final release in the
+	// list of releases replaces it then, and the list also gives the
+	// pre-release in flight. When the list can't be read, a usable latest
+	// release is kept without pre-release choices; without one the lookup
+	// fails, since treating the repository as never released would release
+	// it as v0.0.1.
+	final, prerelease, err := m.listReleaseTags(ctx, repo)
 	if err != nil {
-		v, _ := semver.NewVersion("0.0.0")
-		repo.LatestRelease = v
+		if latest == nil {
+			return err
+		}
+		m.logger.Warn("Failed to list releases for %s, pre-releases won't be offered: %v", repo.Repository, err)
+		repo.setLatest(latest, nil)
 		return nil
 	}
+	if latest == nil || latest.Version.Prerelease() != "" {
+		latest = final
+	}
+	repo.setLatest(latest, prerelease)
+	return nil
+}
 
-	v, err := ParseVersion(latestRelease.GetTagName())
+// listReleaseTags returns repo's highest published final release and the
+// highest pre-release above it.
+func (m *Manager) listReleaseTags(ctx context.Context, repo *ReleaseRepository) (final, prerelease *ReleaseTag, err error) {
+	releases, err := m.forgeFor(repo).ListReleases(ctx, repo.Repository)
 	if err != nil {
-		return fmt.Errorf("failed to parse latest release version: %w", err)
+		return nil, nil, fmt.Errorf("failed to list releases for %s: %w", repo.Repository, err)
 	}
 
-	repo.LatestRelease = v
-	return nil
+	var tags []string
+	for _, release := range releases {
+		if !release.Draft {
+			tags = append(tags, release.TagName)
+		}
+	}
+	final, prerelease = repo.Scheme.LatestTags(tags)
+	return final, prerelease, nil
+}
+
+// maxOffBranchTags bounds how many tags from other branches
+// latestTagsOnBranch compares with the release branch before giving up.
+const maxOffBranchTags = 20
+
+// latestTagsOnBranch returns the highest final version tag reachable from
+// repo's release branch and the highest pre-release tag above it, or nil when
+// there is none. Unlike the forge's latest release, this ignores releases cut
+// from other branches and counts tags whose release is still a draft or was
+// never created. Repos with a local checkout read the tags from it; otherwise
+// tags are compared with the branch from the highest version down, which
+// fails after maxOffBranchTags tags from other branches.
+func (m *Manager) latestTagsOnBranch(ctx context.Context, repo *ReleaseRepository) (final, prerelease *ReleaseTag, err error) {
+	if repo.AssetPath != "" {
+		final, prerelease, err := LatestLocalTags(repo.AssetPath, repo.CommitSHA, true, repo.Scheme)
+		if err == nil {
+			return final, prerelease, nil
+		}
+		m.logger.Warn("Failed to read tags from local clone at %s, comparing them through the API: %v", repo.AssetPath, err)
+	}
+
+	forge := m.forgeFor(repo)
+	names, err := forge.ListTags(ctx, repo.Repository)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list tags for %s: %w", repo.Repository, err)
+	}
+
+	offBranch := 0
+	for _, tag := range repo.Scheme.SortTags(names) {
+		isPrerelease := tag.Version.Prerelease() != ""
+		if isPrerelease && prerelease != nil {
+			continue
+		}
+		if offBranch == maxOffBranchTags {
+			return nil, nil, fmt.Errorf("gave up after %d tags of %s that aren't on %s, set its path to read tags from a local clone",
+				offBranch, repo.Repository, repo.CommitSHA)
+		}
+
+		// The tag is on the branch when it has no commits the branch lacks
+		comparison, err := forge.CompareCommits(ctx, repo.Repository, repo.CommitSHA, tag.Name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to check whether %s is on %s: %w", tag.Name, repo.CommitSHA, err)
+		}
+		if comparison.TotalCommits > 0 {
+			m.logger.Debug("Skipping %s for %s, it isn't on %s", tag.Name, repo.Repository, repo.CommitSHA)
+			offBranch++
+			continue
+		}
+
+		if !isPrerelease {
+			return tag, prerelease, nil
+		}
+		prerelease = tag
+	}
+	return nil, prerelease, nil
+}
+
+// latestRef returns the tag of the latest release, or the tag a 0.0.0
+// release would have when there is none.
+func (r *ReleaseRepository) latestRef() string {
+	if r.LatestTag != "" {
+		return r.LatestTag
+	}
+	return r.Scheme.FormatVersion(r.LatestRelease)
+}
+
+// branchAt returns the branch the pull requests in a range ending at head
+// were merged into: r's branch when head is its head, and "" for an explicit
+// target such as a hotfix, whose branch isn't known.
+func (r *ReleaseRepository) branchAt(head string) string {
+	if head == r.CommitSHA {
+		return r.CommitSHA
+	}
+	return ""
+}
+
+// setLatest records repo's latest final release, 0.0.0 when there is none,
+// and the pre-release in flight above it, if any.
+func (r *ReleaseRepository) setLatest(final, prerelease *ReleaseTag) {
+	r.LatestRelease, _ = semver.NewVersion("0.0.0")
+	r.LatestTag = ""
+	if final != nil {
+		r.LatestRelease = final.Version
+		r.LatestTag = final.Name
+	}
+
+	r.LatestPrerelease = nil
+	r.LatestPrereleaseTag = ""
+	if prerelease != nil && prerelease.Version.GreaterThan(r.LatestRelease) {
+		r.LatestPrerelease = prerelease.Version
+		r.LatestPrereleaseTag = prerelease.Name
+	}
 }
 
 func (m *Manager) HasChanges(ctx context.Context, repo *ReleaseRepository) (bool, error) {
+	// A component only has changes if a pull request touched its paths
+	if len(repo.Paths) > 0 {
+		prs, _, err := m.getPRsForChangelog(ctx, repo, "")
+		if err != nil {
+			return false, err
+		}
+		return len(prs) > 0, nil
+	}
+
+	if m.offline && repo.LatestRelease.String() == "0.0.0" {
+		commits, err := m.localCommits(repo, "", repo.CommitSHA)
+		if err != nil {
+			return false, err
+		}
+		return len(commits) > 0, nil
+	}
+
 	// For v0.0.0 (no previous release), check if there are any merged PRs (last 10)
 	if repo.LatestRelease.String() == "0.0.0" {
-		prs, err := m.client.GetLastNMergedPRs(repo.Repository, 10)
+		prs, err := m.forgeFor(repo).GetLastNMergedPRs(ctx, repo.Repository, 10)
 		if err != nil {
 			// If we can't get recent PRs, assume there are changes to avoid blocking
 			m.logger.Debug("Failed to get last 10 PRs for %s, assuming changes exist: %v", repo.Repository, err)
@@ -102,10 +441,18 @@ func (m *Manager) HasChanges(ctx context.Context, repo *ReleaseRepository) (bool
 	}
 
 	// Normal flow - compare with last release
-	baseRef := FormatVersion(repo.LatestRelease)
+	baseRef := repo.latestRef()
 	headRef := repo.CommitSHA
 
-	comparison, err := m.client.CompareCommits(repo.Repository, baseRef, headRef)
+	if m.readsLocalGit(repo) {
+		commits, err := m.localCommits(repo, baseRef, headRef)
+		if err != nil {
+			return false, err
+		}
+		return len(commits) > 0, nil
+	}
+
+	comparison, err := m.forgeFor(repo).CompareCommits(ctx, repo.Repository, baseRef, headRef)
 	if err != nil {
 		return false, err
 	}
@@ -118,30 +465,77 @@ func (m *Manager) GenerateChangelog(ctx context.Context, repo *ReleaseRepository
 }
 
 func (m *Manager) GenerateChangelogFromSHA(ctx context.Context, repo *ReleaseRepository, targetSHA string) ([]Entry, error) {
-	prs, err := m.getPRsForChangelog(repo, targetSHA)
+	m.loadCategories(ctx, repo)
+	prs, direct, err := m.getPRsForChangelog(ctx, repo, targetSHA)
 	if err != nil {
 		return nil, err
 	}
 
 	var entries []Entry
 	for _, pr := range prs {
-		entry := m.createEntryFromPR(repo, pr)
+		entry := m.createEntryFromPR(ctx, repo, pr)
 		entries = append(entries, entry)
 	}
+	entries = append(entries, m.directCommitEntries(repo, direct)...)
 
 	return entries, nil
 }
 
-func (m *Manager) getPRsForChangelog(repo *ReleaseRepository, targetSHA string) ([]*github.PullRequest, error) {
+// getPRsForChangelog returns the pull requests since the last release, and
+// the commits made on the branch since then without one. A fresh repository
+// has no such commits; its changelog is the last 10 pull requests. The result
+// is kept on repo until its latest release or head moves, as a component's
+// lookup fetches the files of every pull request.
+func (m *Manager) getPRsForChangelog(ctx context.Context, repo *ReleaseRepository, targetSHA string) ([]*PullRequest, []Commit, error) {
+	head := targetSHA
+	if head == "" {
+		head = repo.CommitSHA
+	}
+	span := repo.latestRef() + "..." + head
+	if repo.changes != nil && repo.changes.span == span {
+		return repo.changes.prs, repo.changes.direct, nil
+	}
+
+	prs, direct, err := m.findPRsForChangelog(ctx, repo, targetSHA)
+	if err != nil {
+		return nil, nil, err
+	}
+	repo.changes = &changeSet{span: span, prs: prs, direct: direct}
+	return prs, direct, nil
+}
+
+func (m *Manager) findPRsForChangelog(ctx context.Context, repo *ReleaseRepository, targetSHA string) ([]*PullRequest, []Commit, error) {
+	// Offline, a fresh repository uses the last 10 PRs merged into its history
+	if m.offline && repo.LatestRelease.String() == "0.0.0" {
+		commits, err := m.localCommits(repo, "", repo.CommitSHA)
+		if err != nil {
+			return nil, nil, err
+		}
+		prs, _ := m.pullRequestsForCommits(ctx, repo, commits, repo.CommitSHA)
+		if len(prs) > 10 {
+			prs = prs[len(prs)-10:]
+		}
+		return prs, nil, nil
+	}
+
 	// Check if this is a fresh repository (v0.0.0) - use last 10 PRs
 	if repo.LatestRelease.String() == "0.0.0" {
 		m.logger.Debug("No previous releases found for %s, using last 10 PRs", repo.Repository)
 		
-		prs, err := m.client.GetLastNMergedPRs(repo.Repository, 10)
+		prs, err := m.forgeFor(repo).GetLastNMergedPRs(ctx, repo.Repository, 10)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get last 10 PRs: %w", err)
+			return nil, nil, fmt.Errorf("failed to get last 10 PRs: %w", err)
+		}
+		if len(repo.Paths) > 0 {
+			var kept []*PullRequest
+			for _, pr := range prs {
+				if m.pullRequestTouchesPaths(ctx, repo, pr, nil) {
+					kept = append(kept, pr)
+				}
+			}
+			prs = kept
 		}
-		return prs, nil
+		return prs, nil, nil
 	}
 
 	// Determine the head reference
@@ -154,62 +548,484 @@ func (m *Manager) getPRsForChangelog(repo *ReleaseRepository, targetSHA string)
 	}
 
 	// Compare with last release
-	baseRef := FormatVersion(repo.LatestRelease)
-	comparison, err := m.client.CompareCommits(repo.Repository, baseRef, headRef)
+	baseRef := repo.latestRef()
+	commits, err := m.commitsBetween(ctx, repo, baseRef, headRef)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	prs, direct := m.pullRequestsForCommits(ctx, repo, commits, repo.branchAt(headRef))
+	repo.Commits = componentCommits(repo, commits, prs)
+	return prs, direct, nil
+}
+
+// directCommitEntries lists commits made on the branch without a pull
+// request as changelog entries for repos with direct_commits enabled. Other
+// repos leave them out with a warning, so that an operator notices the
+// missing changes before publishing. A monorepo component only counts the
+// commits changing its paths, which takes its local checkout.
+func (m *Manager) directCommitEntries(repo *ReleaseRepository, commits []Commit) []Entry {
+	if len(repo.Paths) > 0 && len(commits) > 0 {
+		if repo.AssetPath == "" {
+			m.logger.Debug("Ignoring %d commits without a pull request in %s, which has no local checkout to list their files", len(commits), repo.GetDisplayName())
+			return nil
+		}
+		var kept []Commit
+		for _, commit := range commits {
+			files, err := LocalCommitFiles(repo.AssetPath, commit.SHA)
+			if err != nil || touchesPaths(files, repo.Paths) {
+				kept = append(kept, commit)
+			}
+		}
+		commits = kept
+	}
+	if len(commits) == 0 {
+		return nil
+	}
+
+	if !repo.DirectCommits {
+		m.logger.Warn("Left %d commits without a pull request out of the changelog of %s (set direct_commits to list them)",
+			len(commits), repo.GetDisplayName())
+		return nil
 	}
 
-	var prs []*github.PullRequest
-	for _, commit := range comparison.Commits {
-		prNumber, err := ParsePRNumber(commit.GetCommit().GetMessage())
+	entries := make([]Entry, 0, len(commits))
+	for _, commit := range commits {
+		entries = append(entries, m.createEntryFromCommit(repo, commit))
+	}
+	return entries
+}
+
+// componentCommits narrows commits down to those of prs for a monorepo
+// component, so that its bump suggestion ignores other components' changes.
+func componentCommits(repo *ReleaseRepository, commits []Commit, prs []*PullRequest) []Commit {
+	if len(repo.Paths) == 0 {
+		return commits
+	}
+	kept := make(map[int]bool, len(prs))
+	for _, pr := range prs {
+		kept[pr.Number] = true
+	}
+	var filtered []Commit
+	for _, commit := range commits {
+		if kept[commit.PullRequest] {
+			filtered = append(filtered, commit)
+		}
+	}
+	return filtered
+}
+
+// commitsBetween returns every commit in base...head, oldest first. Repos in
+// local-git mode (and every repo offline) read them from the local clone.
+// Otherwise the compare API is used when it can list the whole range; larger
+// ranges fall back to the local clone at the repo's path, then to walking the
+// commit list API back to the merge base.
+func (m *Manager) commitsBetween(ctx context.Context, repo *ReleaseRepository, base, head string) ([]Commit, error) {
+	if m.readsLocalGit(repo) {
+		commits, err := m.localCommits(repo, base, head)
 		if err != nil {
+			return nil, err
+		}
+		m.logger.Debug("Listed %d commits %s..%s for %s from the local clone at %s",
+			len(commits), base, head, repo.Repository, repo.AssetPath)
+		return commits, nil
+	}
+
+	forge := m.forgeFor(repo)
+	comparison, err := forge.CompareCommits(ctx, repo.Repository, base, head)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(comparison.Commits) >= comparison.TotalCommits {
+		m.logger.Debug("Listed %d commits %s...%s for %s using the compare API",
+			len(comparison.Commits), base, head, repo.Repository)
+		return comparison.Commits, nil
+	}
+
+	m.logger.Info("Compare API listed only %d of %d commits %s...%s for %s, walking history instead",
+		len(comparison.Commits), comparison.TotalCommits, base, head, repo.Repository)
+
+	if repo.AssetPath != "" {
+		commits, err := LocalCommitsBetween(repo.AssetPath, base, head, true)
+		if err == nil {
+			m.logger.Info("Listed %d commits for %s using the local clone at %s", len(commits), repo.Repository, repo.AssetPath)
+			return commits, nil
+		}
+		m.logger.Warn("Failed to list commits from local clone at %s: %v", repo.AssetPath, err)
+	}
+
+	commits, err := forge.ListCommitsSince(ctx, repo.Repository, head, comparison.MergeBaseSHA)
+	if err != nil {
+		return nil, err
+	}
+	m.logger.Info("Listed %d commits for %s using the commit list API", len(commits), repo.Repository)
+	return commits, nil
+}
+
+// pullRequestsForCommits returns the pull requests commits were merged with
+// into branch (any branch when it's empty), in commit order, and the commits
+// made on the branch itself that have none. For a monorepo component, only
+// the pull requests touching its paths are returned.
+func (m *Manager) pullRequestsForCommits(ctx context.Context, repo *ReleaseRepository, commits []Commit, branch string) ([]*PullRequest, []Commit) {
+	prs, prCommits, unmatched := m.lookupPullRequests(ctx, repo, commits, branch)
+
+	line := firstParentLine(commits)
+	var direct []Commit
+	for _, commit := range unmatched {
+		if line[commit.SHA] {
+			direct = append(direct, commit)
+		}
+	}
+
+	if len(repo.Paths) == 0 {
+		return prs, direct
+	}
+
+	var kept []*PullRequest
+	for _, pr := range prs {
+		if m.pullRequestTouchesPaths(ctx, repo, pr, prCommits[pr.Number]) {
+			kept = append(kept, pr)
+		}
+	}
+	return kept, direct
+}
+
+// firstParentLine returns the SHAs of the commits reached from the newest of
+// commits by following first parents: the commits made on the branch, as
+// opposed to those brought in by merging another branch. Without parent
+// information, every commit is on the line.
+func firstParentLine(commits []Commit) map[string]bool {
+	line := make(map[string]bool, len(commits))
+	bySHA := make(map[string]Commit, len(commits))
+	isParent := make(map[string]bool)
+	for _, commit := range commits {
+		bySHA[commit.SHA] = commit
+		for _, parent := range commit.Parents {
+			isParent[parent] = true
+		}
+	}
+	if len(isParent) == 0 {
+		for _, commit := range commits {
+			line[commit.SHA] = true
+		}
+		return line
+	}
+
+	// The head is the newest commit that isn't another one's parent
+	var sha string
+	for i := len(commits) - 1; i >= 0; i-- {
+		if !isParent[commits[i].SHA] {
+			sha = commits[i].SHA
+			break
+		}
+	}
+	for {
+		commit, ok := bySHA[sha]
+		if !ok || line[sha] {
+			return line
+		}
+		line[sha] = true
+		if len(commit.Parents) == 0 {
+			return line
+		}
+		sha = commit.Parents[0]
+	}
+}
+
+// pullRequestTouchesPaths reports whether pr, merged as commitSHAs, changes
+// any file under repo's paths. Files are read from the local checkout for
+// repos in local-git mode (and every repo offline), and from the forge
+// otherwise. Pull requests whose files can't be listed are kept.
+func (m *Manager) pullRequestTouchesPaths(ctx context.Context, repo *ReleaseRepository, pr *PullRequest, commitSHAs []string) bool {
+	var files []string
+	var err error
+	if m.readsLocalGit(repo) && len(commitSHAs) > 0 && repo.AssetPath != "" {
+		for _, sha := range commitSHAs {
+			var commitFiles []string
+			if commitFiles, err = LocalCommitFiles(repo.AssetPath, sha); err != nil {
+				break
+			}
+			files = append(files, commitFiles...)
+		}
+	} else {
+		files, err = m.forgeFor(repo).GetPullRequestFiles(ctx, repo.Repository, pr.Number)
+	}
+	if err != nil {
+		m.logger.Warn("Failed to list the files of %s %s, keeping it in %s: %v",
+			repo.Repository, m.forgeFor(repo).ChangeRef(pr.Number), repo.GetDisplayName(), err)
+		return true
+	}
+	return touchesPaths(files, repo.Paths)
+}
+
+// lookupPullRequests returns the pull requests commits were merged with into
+// branch, once each, with the SHAs of their commits, and the commits left
+// without one. Commits the forge can't be asked about fall back to the number
+// in their message.
+func (m *Manager) lookupPullRequests(ctx context.Context, repo *ReleaseRepository, commits []Commit, branch string) ([]*PullRequest, map[int][]string, []Commit) {
+	forge := m.forgeFor(repo)
+	found := make(map[int]*PullRequest)
+	asked := m.associatePullRequests(ctx, repo, commits, branch, found)
+
+	var numbers []int
+	for i := range commits {
+		commit := &commits[i]
+		if asked[commit.SHA] {
 			continue
 		}
+		commit.PullRequest = 0
+		if number, err := ParsePRNumber(commit.Message); err == nil {
+			commit.PullRequest = number
+			if found[number] == nil {
+				numbers = append(numbers, number)
+			}
+		}
+	}
+	numbers = uniqueInts(numbers)
 
-		pr, err := m.client.GetPullRequest(repo.Repository, prNumber)
-		if err != nil {
+	switch batcher, ok := forge.(pullRequestBatcher); {
+	case m.offline:
+		for _, commit := range commits {
+			if commit.PullRequest != 0 && found[commit.PullRequest] == nil {
+				found[commit.PullRequest] = pullRequestFromCommit(commit, commit.PullRequest)
+			}
+		}
+	case ok && m.graphQL && len(numbers) > 0:
+		batch, err := batcher.GetPullRequestsBatch(ctx, repo.Repository, numbers)
+		if err == nil {
+			for _, number := range numbers {
+				if pr, ok := batch[number]; ok {
+					found[number] = pr
+				}
+			}
+			break
+		}
+		m.logger.Warn("GraphQL lookup failed for %s, falling back to REST: %v", repo.Repository, err)
+		fallthrough
+	default:
+		for _, number := range numbers {
+			pr, err := forge.GetPullRequest(ctx, repo.Repository, number)
+			if err != nil {
+				m.logger.Debug("Failed to get %s %s: %v", repo.Repository, forge.ChangeRef(number), err)
+				continue
+			}
+			found[number] = pr
+		}
+	}
+
+	var prs []*PullRequest
+	var unmatched []Commit
+	shas := make(map[int][]string)
+	for i := range commits {
+		commit := &commits[i]
+		pr := found[commit.PullRequest]
+		if pr != nil && branch != "" && pr.BaseBranch != "" && pr.BaseBranch != branch {
+			m.logger.Debug("Ignoring %s %s, which was merged into %s rather than %s",
+				repo.Repository, forge.ChangeRef(pr.Number), pr.BaseBranch, branch)
+			pr = nil
+		}
+		if pr == nil {
+			commit.PullRequest = 0
+			unmatched = append(unmatched, *commit)
 			continue
 		}
+		if len(shas[pr.Number]) == 0 {
+			prs = append(prs, pr)
+		}
+		shas[pr.Number] = append(shas[pr.Number], commit.SHA)
+	}
+	return prs, shas, unmatched
+}
 
-		prs = append(prs, pr)
+// associatePullRequests records in the PullRequest of commits the pull
+// request the forge lists each one under that was merged into branch,
+// adding it to found, and returns the SHAs of the commits it settled. With
+// GraphQL, every commit is looked up in a few queries that also bring the
+// pull requests' comments. Otherwise commits are looked up one at a time,
+// newest first, so that the commits a merge commit brought in take its pull
+// request without a request of their own; after a failure the rest are left
+// to their messages.
+func (m *Manager) associatePullRequests(ctx context.Context, repo *ReleaseRepository, commits []Commit, branch string, found map[int]*PullRequest) map[string]bool {
+	asked := make(map[string]bool, len(commits))
+	if m.offline {
+		return asked
 	}
+	forge := m.forgeFor(repo)
 
-	return prs, nil
+	if batcher, ok := forge.(commitPullRequestBatcher); ok && m.graphQL && len(commits) > 0 {
+		shas := make([]string, 0, len(commits))
+		for _, commit := range commits {
+			shas = append(shas, commit.SHA)
+		}
+		batch, err := batcher.GetCommitPullRequestsBatch(ctx, repo.Repository, shas)
+		if err == nil {
+			for i := range commits {
+				assignPullRequest(&commits[i], batch[commits[i].SHA], branch, found)
+				asked[commits[i].SHA] = true
+			}
+			return asked
+		}
+		m.logger.Warn("GraphQL lookup of the pull requests of %s commits failed, falling back to REST: %v", repo.Repository, err)
+	}
+
+	lister, ok := forge.(commitPullRequestLister)
+	if !ok {
+		return asked
+	}
+	for i := len(commits) - 1; i >= 0; i-- {
+		commit := &commits[i]
+		if asked[commit.SHA] {
+			continue
+		}
+		prs, err := lister.ListCommitPullRequests(ctx, repo.Repository, commit.SHA)
+		if err != nil {
+			m.logger.Warn("Failed to list the pull requests of %s commits, reading their numbers from commit messages instead: %v", repo.Repository, err)
+			return asked
+		}
+		asked[commit.SHA] = true
+		if pr := assignPullRequest(commit, prs, branch, found); pr != nil {
+			for _, j := range mergedCommits(commits, i) {
+				commits[j].PullRequest = pr.Number
+				asked[commits[j].SHA] = true
+			}
+		}
+	}
+	return asked
+}
+
+// assignPullRequest sets commit's PullRequest to the first of prs merged into
+// branch, or into any branch when it's empty, which it adds to found and
+// returns, or to 0 when there is none.
+func assignPullRequest(commit *Commit, prs []*PullRequest, branch string, found map[int]*PullRequest) *PullRequest {
+	commit.PullRequest = 0
+	for _, pr := range prs {
+		if !pr.MergedAt.IsZero() && (branch == "" || pr.BaseBranch == branch) {
+			commit.PullRequest = pr.Number
+			found[pr.Number] = pr
+			return pr
+		}
+	}
+	return nil
+}
+
+// mergedCommits returns the indexes of the commits that merging commits[i]
+// brought in: those reached from its other parents but not from its first.
+// It's empty for a commit that isn't a merge, or without parent information.
+func mergedCommits(commits []Commit, i int) []int {
+	if len(commits[i].Parents) < 2 {
+		return nil
+	}
+	index := make(map[string]int, len(commits))
+	for j, commit := range commits {
+		index[commit.SHA] = j
+	}
+	reach := func(parents []string) map[string]bool {
+		seen := make(map[string]bool)
+		from := append([]string(nil), parents...)
+		for len(from) > 0 {
+			sha := from[len(from)-1]
+			from = from[:len(from)-1]
+			j, ok := index[sha]
+			if !ok || seen[sha] {
+				continue
+			}
+			seen[sha] = true
+			from = append(from, commits[j].Parents...)
+		}
+		return seen
+	}
+
+	mainline := reach(commits[i].Parents[:1])
+	var merged []int
+	for sha := range reach(commits[i].Parents[1:]) {
+		if !mainline[sha] {
+			merged = append(merged, index[sha])
+		}
+	}
+	return merged
+}
+
+// releaseConfigPaths are where GitHub looks for the configuration of its
+// generated release notes.
+var releaseConfigPaths = []string{".github/release.yml", ".github/release.yaml"}
+
+// loadCategories sets repo.Categories once, from the release.yml at the
+// repository's head when there is one and from the configured categories
+// otherwise. A release.yml that can't be read or parsed is skipped with a
+// warning rather than failing the release.
+func (m *Manager) loadCategories(ctx context.Context, repo *ReleaseRepository) {
+	if repo.categoriesLoaded {
+		return
+	}
+	repo.categoriesLoaded = true
+	repo.Categories = m.categories
+
+	for _, path := range releaseConfigPaths {
+		var data []byte
+		var err error
+		if m.offline {
+			if repo.AssetPath == "" {
+				return
+			}
+			data, err = LocalFile(repo.AssetPath, repo.CommitSHA, path)
+		} else {
+			data, err = m.forgeFor(repo).GetFile(ctx, repo.Repository, path, repo.CommitSHA)
+		}
+		if err != nil {
+			m.logger.Warn("Failed to read %s for %s, ignoring it: %v", path, repo.GetDisplayName(), err)
+			return
+		}
+		if data == nil {
+			continue
+		}
+
+		categories, err := ParseReleaseConfig(data)
+		if err != nil {
+			m.logger.Warn("Ignoring %s for %s: %v", path, repo.GetDisplayName(), err)
+			return
+		}
+		m.logger.Debug("Grouping release notes for %s by %s", repo.GetDisplayName(), path)
+		repo.Categories = categories
+		return
+	}
 }
 
 // GenerateChangelogBetween returns changelog entries for PRs whose merge commits
 // are in the range base..head (exclusive of base, inclusive of head).
 func (m *Manager) GenerateChangelogBetween(ctx context.Context, repo *ReleaseRepository, base, head string) ([]Entry, error) {
-	comparison, err := m.client.CompareCommits(repo.Repository, base, head)
+	m.loadCategories(ctx, repo)
+	commits, err := m.commitsBetween(ctx, repo, base, head)
 	if err != nil {
 		return nil, err
 	}
 
+	prs, direct := m.pullRequestsForCommits(ctx, repo, commits, repo.branchAt(head))
 	var entries []Entry
-	for _, commit := range comparison.Commits {
-		prNumber, err := ParsePRNumber(commit.GetCommit().GetMessage())
-		if err != nil {
-			continue
-		}
-		pr, err := m.client.GetPullRequest(repo.Repository, prNumber)
-		if err != nil {
-			continue
-		}
-		entries = append(entries, m.createEntryFromPR(repo, pr))
+	for _, pr := range prs {
+		entries = append(entries, m.createEntryFromPR(ctx, repo, pr))
 	}
+	entries = append(entries, m.directCommitEntries(repo, direct)...)
 	return entries, nil
 }
 
 // AppendToRelease adds entries for commits between the release's current tag SHA
 // and newSHA to the release body, then force-updates the tag to newSHA.
 func (m *Manager) AppendToRelease(ctx context.Context, repo *ReleaseRepository, tag, newSHA string) error {
-	release, err := m.client.GetReleaseByTag(repo.Repository, tag)
+	// Forges whose API can't move a tag have it pushed from the local checkout.
+	mover, ok := m.forgeFor(repo).(tagMover)
+	if !ok {
+		if repo.AssetPath == "" {
+			return fmt.Errorf("appending to a release of %s needs its local checkout (path) to move the tag", repo.Repository)
+		}
+		mover = localTagMover{dir: repo.AssetPath}
+	}
+
+	release, err := m.forgeFor(repo).GetReleaseByTag(ctx, repo.Repository, tag)
 	if err != nil {
 		return err
 	}
 
-	prevSHA, err := m.client.GetTagSHA(repo.Repository, tag)
+	prevSHA, err := m.forgeFor(repo).GetTagSHA(ctx, repo.Repository, tag)
 	if err != nil {
 		return err
 	}
@@ -230,7 +1046,7 @@ func (m *Manager) AppendToRelease(ctx context.Context, repo *ReleaseRepository,
 	}
 
 	header := fmt.Sprintf("\n## Appended %s (%s)\n\n", time.Now().Format("2006-01-02"), shortSHA(newSHA))
-	newBody := release.GetBody() + header + BuildEntriesTableString(entries, repo.JiraEnabled, m.jiraOrgId)
+	newBody := release.Body + header + BuildChangelogString(entries, repo.Categories, repo.JiraEnabled, m.jiraOrgId)
 
 	if m.dryRun {
 		m.logger.Info("[DRY RUN] Would append %d entries to release %s for %s and move tag to %s",
@@ -239,11 +1055,10 @@ func (m *Manager) AppendToRelease(ctx context.Context, repo *ReleaseRepository,
 		return nil
 	}
 
-	update := &github.RepositoryRelease{Body: &newBody}
-	if _, err := m.client.EditRelease(repo.Repository, release.GetID(), update); err != nil {
+	if err := m.forgeFor(repo).EditReleaseNotes(ctx, repo.Repository, release, newBody); err != nil {
 		return err
 	}
-	if err := m.client.UpdateTagRef(repo.Repository, tag, newSHA); err != nil {
+	if err := mover.UpdateTagRef(ctx, repo.Repository, tag, newSHA); err != nil {
 		return fmt.Errorf("release body updated but tag move failed: %w", err)
 	}
 
@@ -259,43 +1074,67 @@ func shortSHA(sha string) string {
 	return sha
 }
 
-func (m *Manager) createEntryFromPR(repo *ReleaseRepository, pr *github.PullRequest) Entry {
+func (m *Manager) createEntryFromPR(ctx context.Context, repo *ReleaseRepository, pr *PullRequest) Entry {
 	entry := Entry{
-		Number:      pr.GetNumber(),
-		Date:        pr.GetMergedAt().Format("2006-01-02"),
-		Author:      pr.GetUser().GetLogin(),
-		Title:       pr.GetTitle(),
-		Description: pr.GetBody(),
+		Number:      pr.Number,
+		Ref:         m.forgeFor(repo).ChangeRef(pr.Number),
+		Date:        pr.MergedAt.Format("2006-01-02"),
+		Author:      pr.Author,
+		Title:       pr.Title,
+		Description: pr.Body,
+		Labels:      pr.Labels,
 	}
 
 	if repo.JiraEnabled {
-		entry.Tickets = m.extractTicketsFromPR(repo, pr)
+		entry.Tickets = m.extractTicketsFromPR(ctx, repo, pr)
 	}
 
 	return entry
 }
 
-func (m *Manager) extractTicketsFromPR(repo *ReleaseRepository, pr *github.PullRequest) []string {
+// createEntryFromCommit describes a commit made without a pull request.
+func (m *Manager) createEntryFromCommit(repo *ReleaseRepository, commit Commit) Entry {
+	subject, _, _ := strings.Cut(commit.Message, "\n")
+	entry := Entry{
+		SHA:    commit.SHA,
+		Ref:    shortSHA(commit.SHA),
+		Author: commit.Author,
+		Title:  strings.TrimSpace(subject),
+	}
+	if !commit.Date.IsZero() {
+		entry.Date = commit.Date.Format("2006-01-02")
+	}
+	if repo.JiraEnabled {
+		entry.Tickets = m.generator.ExtractTickets(commit.Message)
+	}
+	return entry
+}
+
+func (m *Manager) extractTicketsFromPR(ctx context.Context, repo *ReleaseRepository, pr *PullRequest) []string {
 	var allText []string
 
 	// Collect title
-	if pr.GetTitle() != "" {
-		allText = append(allText, pr.GetTitle())
+	if pr.Title != "" {
+		allText = append(allText, pr.Title)
 	}
 	
 	// Collect body
-	if pr.GetBody() != "" {
-		allText = append(allText, pr.GetBody())
+	if pr.Body != "" {
+		allText = append(allText, pr.Body)
 	}
 
-	// Collect comments
-	comments, err := m.client.GetPullRequestComments(repo.Repository, pr.GetNumber())
+	// Collect comments, unless they were fetched along with the PR
+	comments := pr.Comments
+	var err error
+	if comments == nil {
+		comments, err = m.forgeFor(repo).GetPullRequestComments(ctx, repo.Repository, pr.Number)
+	}
 	if err != nil {
-		m.logger.Debug("Failed to get comments for PR #%d: %v", pr.GetNumber(), err)
+		m.logger.Debug("Failed to get comments for PR #%d: %v", pr.Number, err)
 	} else {
 		for _, comment := range comments {
-			if comment.GetBody() != "" {
-				allText = append(allText, comment.GetBody())
+			if comment != "" {
+				allText = append(allText, comment)
 			}
 		}
 	}
@@ -304,7 +1143,7 @@ func (m *Manager) extractTicketsFromPR(repo *ReleaseRepository, pr *github.PullR
 	combinedText := strings.Join(allText, " ")
 	tickets := m.generator.ExtractTickets(combinedText)
 
-	m.logger.Debug("Found %d tickets for %d\n%s\n", len(tickets), pr.GetNumber(), allText)
+	m.logger.Debug("Found %d tickets for %d\n%s\n", len(tickets), pr.Number, allText)
 	
 	// Remove any duplicates
 	return removeDuplicates(tickets)
@@ -312,8 +1151,17 @@ func (m *Manager) extractTicketsFromPR(repo *ReleaseRepository, pr *github.PullR
 
 func (m *Manager) CreateRelease(ctx context.Context, repo *ReleaseRepository, newVersion *semver.Version, 
 	releaseNotes string, releaseType Type) error {
-	
-	tagName := FormatVersion(newVersion)
+	return m.createRelease(ctx, repo, newVersion, releaseNotes, nil, "")
+}
+
+// createRelease publishes newVersion at target, or at the default branch
+// when target is empty. Versions with a pre-release part are flagged as
+// pre-releases. Releases of the default branch first add entries to the
+// repository's changelog file, if it has one, and tag the commit doing so.
+func (m *Manager) createRelease(ctx context.Context, repo *ReleaseRepository, newVersion *semver.Version,
+	releaseNotes string, entries []Entry, target string) error {
+
+	tagName := repo.Scheme.FormatVersion(newVersion)
 	isDraft := false
 
 	if m.dryRun {
@@ -322,45 +1170,131 @@ func (m *Manager) CreateRelease(ctx context.Context, repo *ReleaseRepository, ne
 		if repo.GenerateAssets != "" {
 			m.logger.Info("[DRY RUN] Would run generate-assets for %s with version %s", repo.Repository, tagName)
 		}
+		if repo.ChangelogFile != "" && target == "" {
+			diff, err := PreviewChangelogSection(repo.AssetPath, repo.CommitSHA, repo.ChangelogFile, m.changelogFileSection(repo, newVersion, entries))
+			switch {
+			case err != nil:
+				m.logger.Warn("[DRY RUN] Failed to preview %s for %s: %v", repo.ChangelogFile, repo.GetDisplayName(), err)
+			case diff == "":
+				fmt.Printf("[DRY RUN] %s already has a section for %s, whose commit would be tagged\n", repo.ChangelogFile, tagName)
+			default:
+				// Printed rather than logged, so it shows at the default log level
+				fmt.Printf("[DRY RUN] Would commit and push to %s for %s:\n%s", repo.CommitSHA, repo.GetDisplayName(), diff)
+			}
+		}
 		return nil
 	}
 
+	// The changelog commit is what gets tagged, so the assets are built from
+	// it. A release failing after the commit is pushed reuses it when retried.
+	if repo.ChangelogFile != "" {
+		if target != "" {
+			m.logger.Warn("Not updating %s for %s, since %s isn't released from the head of %s",
+				repo.ChangelogFile, repo.GetDisplayName(), tagName, repo.CommitSHA)
+		} else {
+			var err error
+			target, err = m.commitChangelogFile(repo, newVersion, entries)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	// Generate assets before creating the release so a failing generate-assets
-	// command aborts without leaving an orphaned release on GitHub.
-	assetPaths, err := m.generateAssets(repo, tagName)
+	// command aborts without leaving an orphaned release on the forge.
+	assetRef := repo.CommitSHA
+	if target != "" {
+		assetRef = target
+	}
+	assetPaths, err := m.generateAssets(ctx, repo, tagName, assetRef)
 	if err != nil {
 		return err
 	}
 
-	release := &github.RepositoryRelease{
-		TagName:    &tagName,
-		Name:       &tagName,
-		Body:       &releaseNotes,
-		Draft:      &isDraft,
+	// From here on the release is published, which an interrupt mustn't cut
+	// short: the run stops before the next repository instead.
+	ctx = context.WithoutCancel(ctx)
+
+	release := &ForgeRelease{
+		TagName:    tagName,
+		Name:       tagName,
+		Body:       releaseNotes,
+		Draft:      isDraft,
+		Prerelease: newVersion.Prerelease() != "",
+		NotLatest:  repo.Scheme.Line != nil,
+		Target:     target,
 	}
 
-	created, err := m.client.CreateRelease(repo.Repository, release)
+	created, err := m.forgeFor(repo).CreateRelease(ctx, repo.Repository, release)
 	if err != nil {
 		return fmt.Errorf("failed to create release: %w", err)
 	}
 
 	m.logger.Info("Successfully created release %s for %s", tagName, repo.Repository)
 
-	if err := m.uploadAssets(repo, created.GetID(), tagName, assetPaths); err != nil {
-		return err
+	if err := m.uploadAssets(ctx, repo, created, assetPaths); err != nil {
+		return &publishedError{Tag: tagName, Err: err}
 	}
 	return nil
 }
 
-// generateAssets runs the repo's generate-assets command (if configured) and
-// returns the file paths it emits. Returns nil when no command is configured.
-func (m *Manager) generateAssets(repo *ReleaseRepository, version string) ([]string, error) {
+// publishedError is a failure after the release tagged Tag was created, such
+// as an asset upload, so the release is reported as made despite it.
+type publishedError struct {
+	Tag string
+	Err error
+}
+
+func (e *publishedError) Error() string {
+	return fmt.Sprintf("release %s was created, but: %v", e.Tag, e.Err)
+}
+
+func (e *publishedError) Unwrap() error {
+	return e.Err
+}
+
+// changelogFileSection renders the section repo's changelog file gets for
+// newVersion. Components are listed under their tag, so they can share one
+// file.
+func (m *Manager) changelogFileSection(repo *ReleaseRepository, newVersion *semver.Version, entries []Entry) string {
+	version := repo.Scheme.DisplayVersion(newVersion)
+	if repo.Component != "" {
+		version = repo.Scheme.FormatVersion(newVersion)
+	}
+	prURL := func(number int) string {
+		return m.forgeFor(repo).PullRequestURL(repo.Repository, number)
+	}
+	return BuildChangelogFileSection(version, time.Now(), entries, repo.Categories, prURL)
+}
+
+// commitChangelogFile adds newVersion's section to repo's changelog file,
+// commits and pushes it to the release branch, and returns the commit to tag:
+// that one, or the one that already added the section.
+func (m *Manager) commitChangelogFile(repo *ReleaseRepository, newVersion *semver.Version, entries []Entry) (string, error) {
+	message := strings.ReplaceAll(repo.ChangelogMessage, "{version}", repo.Scheme.FormatVersion(newVersion))
+	sha, reused, err := CommitChangelogSection(repo.AssetPath, repo.CommitSHA, repo.ChangelogFile, m.changelogFileSection(repo, newVersion, entries), message)
+	if err != nil {
+		return "", fmt.Errorf("failed to update %s for %s: %w", repo.ChangelogFile, repo.GetDisplayName(), err)
+	}
+	if reused {
+		m.logger.Info("%s for %s already has a section for %s, tagging %s", repo.ChangelogFile, repo.GetDisplayName(),
+			repo.Scheme.FormatVersion(newVersion), shortSHA(sha))
+	} else {
+		m.logger.Info("Pushed %s for %s in %s", repo.ChangelogFile, repo.GetDisplayName(), shortSHA(sha))
+	}
+	return sha, nil
+}
+
+// generateAssets runs the repo's generate-assets command (if configured) at
+// ref and returns the file paths it emits. Returns nil when no command is
+// configured.
+func (m *Manager) generateAssets(ctx context.Context, repo *ReleaseRepository, version, ref string) ([]string, error) {
 	if repo.GenerateAssets == "" {
 		return nil, nil
 	}
 
 	m.logger.Info("Generating assets for %s...", repo.GetDisplayName())
-	paths, err := GenerateAssets(repo.GenerateAssets, version, repo.AssetPath, repo.CommitSHA)
+	paths, err := GenerateAssets(ctx, repo.GenerateAssets, version, repo.AssetPath, ref)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate assets for %s: %w", repo.Repository, err)
 	}
@@ -368,12 +1302,12 @@ func (m *Manager) generateAssets(repo *ReleaseRepository, version string) ([]str
 }
 
 // uploadAssets uploads previously generated asset files to the given release.
-func (m *Manager) uploadAssets(repo *ReleaseRepository, releaseID int64, version string, paths []string) error {
+func (m *Manager) uploadAssets(ctx context.Context, repo *ReleaseRepository, release *ForgeRelease, paths []string) error {
 	for _, path := range paths {
-		if _, err := m.client.UploadReleaseAsset(repo.Repository, releaseID, path); err != nil {
+		if err := m.forgeFor(repo).UploadReleaseAsset(ctx, repo.Repository, release, path); err != nil {
 			return err
 		}
-		m.logger.Info("Uploaded asset %s to release %s for %s", path, version, repo.Repository)
+		m.logger.Info("Uploaded asset %s to release %s for %s", path, release.TagName, repo.Repository)
 	}
 	return nil
 }
@@ -381,19 +1315,45 @@ func (m *Manager) uploadAssets(repo *ReleaseRepository, releaseID int64, version
 func (m *Manager) CreateReleaseFromEntries(ctx context.Context, repo *ReleaseRepository, newVersion *semver.Version, 
 	entries []Entry, crossLinks []CrossLink, releaseType Type) error {
 	
-	var builder strings.Builder
-	builder.WriteString(BuildCrossLinksString(crossLinks))
+	releaseNotes, err := m.ReleaseNotes(repo, repo.Scheme.FormatVersion(newVersion), entries, crossLinks)
+	if err != nil {
+		return err
+	}
+	return m.createRelease(ctx, repo, newVersion, releaseNotes, entries, "")
+}
+
+// ReleaseNotes renders the notes of repo's release tagged tag, with its notes
+// template when it has one and as the cross-links followed by the changelog
+// otherwise.
+func (m *Manager) ReleaseNotes(repo *ReleaseRepository, tag string, entries []Entry, crossLinks []CrossLink) (string, error) {
+	changelog := ""
 	if len(entries) > 0 {
-		builder.WriteString(BuildEntriesTableString(entries, repo.JiraEnabled, m.jiraOrgId))
+		changelog = BuildChangelogString(entries, repo.Categories, repo.JiraEnabled, m.jiraOrgId)
+	}
+	if repo.NotesTemplate == nil {
+		return BuildCrossLinksString(crossLinks) + changelog, nil
+	}
+
+	data := NewNotesData(entries, repo.Categories, crossLinks)
+	data.Repo = repo.GetDisplayName()
+	data.RepoFullName = repo.FullName()
+	data.Version = tag
+	data.PreviousVersion = repo.LatestTag
+	data.Changelog = changelog
+	prURL := func(number int) string {
+		return m.forgeFor(repo).PullRequestURL(repo.Repository, number)
 	}
-	releaseNotes := builder.String()
-	return m.CreateRelease(ctx, repo, newVersion, releaseNotes, releaseType)
+	notes, err := repo.NotesTemplate.Render(data, prURL, m.jiraOrgId)
+	if err != nil {
+		return "", fmt.Errorf("failed to render release notes for %s: %w", repo.GetDisplayName(), err)
+	}
+	return notes, nil
 }
 
 func (m *Manager) CreateHotfixRelease(ctx context.Context, repo *ReleaseRepository, newVersion *semver.Version,
 	releaseNotes string, targetSHA string) error {
 
-	tagName := FormatVersion(newVersion)
+	tagName := repo.Scheme.FormatVersion(newVersion)
 	isDraft := false
 
 	if m.dryRun {
@@ -402,14 +1362,16 @@ func (m *Manager) CreateHotfixRelease(ctx context.Context, repo *ReleaseReposito
 		return nil
 	}
 
-	release := &github.RepositoryRelease{
-		TagName: &tagName,
-		Name:    &tagName,
-		Body:    &releaseNotes,
-		Draft:   &isDraft,
+	release := &ForgeRelease{
+		TagName:    tagName,
+		Name:       tagName,
+		Body:       releaseNotes,
+		Draft:      isDraft,
+		Prerelease: newVersion.Prerelease() != "",
+		Target:     targetSHA,
 	}
 
-	_, err := m.client.CreateReleaseFromSHA(repo.Repository, release, targetSHA)
+	_, err := m.forgeFor(repo).CreateRelease(ctx, repo.Repository, release)
 	if err != nil {
 		return fmt.Errorf("failed to create hotfix release: %w", err)
 	}
@@ -432,7 +1394,7 @@ func (m *Manager) ProcessRelease(ctx context.Context, repo *ReleaseRepository, r
 	}
 
 	if !hasChanges {
-		m.logger.Info("No changes found for %s since %s", repo.Repository, FormatVersion(repo.LatestRelease))
+		m.logger.Info("No changes found for %s since %s", repo.Repository, repo.latestRef())
 		return &Release{
 			Repository: repo,
 			Version:    repo.LatestRelease,
@@ -445,7 +1407,10 @@ func (m *Manager) ProcessRelease(ctx context.Context, repo *ReleaseRepository, r
 		return nil, fmt.Errorf("failed to generate changelog: %w", err)
 	}
 
-	newVersion := BumpVersion(repo.LatestRelease, bumpType)
+	newVersion, err := repo.Scheme.BumpVersion(repo.LatestRelease, bumpType, time.Now())
+	if err != nil {
+		return nil, err
+	}
 
 	var crossLinks []CrossLink
 	if repo.CrossLinkEnabled && len(allRepos) > 1 {
@@ -504,7 +1469,7 @@ func (m *Manager) ProcessReleaseInteractiveWithEntries(ctx context.Context, repo
 		}
 		
 		if !hasChanges {
-			m.logger.Info("No changes found for %s since %s", repo.Repository, FormatVersion(repo.LatestRelease))
+			m.logger.Info("No changes found for %s since %s", repo.Repository, repo.latestRef())
 			return &Release{
 				Repository: repo,
 				Version:    repo.LatestRelease,
@@ -513,11 +1478,61 @@ func (m *Manager) ProcessReleaseInteractiveWithEntries(ctx context.Context, repo
 		}
 	}
 
-	// Interactive prompt for version bump
+	// Calendar versions don't depend on the bump, only on today's date
 	repoDisplayName := repo.GetDisplayName()
-	newVersion, bumpType, err := PromptForVersionBump(repoDisplayName, repo.LatestRelease, entries)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get version bump choice: %w", err)
+	var nextCalVer *semver.Version
+	if repo.Scheme.CalVer != nil {
+		var err error
+		nextCalVer, err = repo.Scheme.CalVer.Next(repo.LatestRelease, time.Now())
+		if err != nil {
+			m.logger.Warn("Skipping release for %s: %v", repoDisplayName, err)
+			return &Release{
+				Repository: repo,
+				Version:    repo.LatestRelease,
+				Changelog:  entries,
+			}, nil
+		}
+	}
+
+	// Interactive prompt for version bump, or the suggested bump with --auto
+	suggestion := m.SuggestBump(repo, entries)
+	var newVersion *semver.Version
+	var bumpType BumpType
+	if m.autoBump {
+		bumpType = suggestion.Type
+		if bumpType == "" {
+			bumpType = "skip"
+			m.logger.Info("No Conventional Commits call for a release of %s", repoDisplayName)
+		} else {
+			newVersion = nextCalVer
+			if newVersion == nil {
+				var err error
+				newVersion, err = repo.Scheme.BumpVersion(repo.LatestRelease, bumpType, time.Now())
+				if err != nil {
+					m.logger.Warn("Skipping release for %s: %v (%s)", repoDisplayName, err, suggestion.Reason)
+					return &Release{
+						Repository: repo,
+						Version:    repo.LatestRelease,
+						Changelog:  entries,
+					}, nil
+				}
+			}
+			m.logger.Info("Releasing %s as %s (%s)", repoDisplayName, repo.Scheme.FormatVersion(newVersion), suggestion.Reason)
+		}
+	} else {
+		var err error
+		opts := BumpOptions{
+			Suggestion:  suggestion,
+			Prerelease:  repo.LatestPrerelease,
+			Channel:     m.prereleaseChannel,
+			Scheme:      repo.Scheme,
+			Next:        nextCalVer,
+			CheckCustom: m.customVersionCheck(ctx, repo),
+		}
+		newVersion, bumpType, err = PromptForVersionBump(repoDisplayName, repo.LatestRelease, entries, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get version bump choice: %w", err)
+		}
 	}
 
 	// If user chose to skip release
@@ -530,31 +1545,93 @@ func (m *Manager) ProcessReleaseInteractiveWithEntries(ctx context.Context, repo
 		}, nil
 	}
 
+	// Promoting releases the pre-release's commit as is, with notes covering
+	// everything since the last final release rather than since HEAD.
+	var target string
+	if bumpType == BumpPromote {
+		var err error
+		target, err = m.forgeFor(repo).GetTagSHA(ctx, repo.Repository, repo.LatestPrereleaseTag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve pre-release %s: %w", repo.LatestPrereleaseTag, err)
+		}
+		if repo.LatestRelease.String() != "0.0.0" {
+			entries, err = m.GenerateChangelogBetween(ctx, repo, repo.latestRef(), target)
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate changelog for promotion: %w", err)
+			}
+		}
+	}
+
 	var crossLinks []CrossLink
 	if repo.CrossLinkEnabled && len(allRepos) > 1 {
 		crossLinks = m.generateCrossLinks(repo, allRepos)
 	}
 
-	var builder strings.Builder
-	builder.WriteString(BuildCrossLinksString(crossLinks))
-	if len(entries) > 0 {
-		builder.WriteString(BuildEntriesTableString(entries, repo.JiraEnabled, m.jiraOrgId))
-	}
-	releaseNotes := builder.String()
-
-	if err := m.CreateRelease(ctx, repo, newVersion, releaseNotes, releaseType); err != nil {
+	releaseNotes, err := m.ReleaseNotes(repo, repo.Scheme.FormatVersion(newVersion), entries, crossLinks)
+	if err != nil {
 		return nil, err
 	}
 
-	return &Release{
+	release := &Release{
 		Repository: repo,
 		Version:    newVersion,
 		Changelog:  entries,
-	}, nil
+	}
+	if err := m.createRelease(ctx, repo, newVersion, releaseNotes, entries, target); err != nil {
+		var published *publishedError
+		if errors.As(err, &published) {
+			return release, err
+		}
+		return nil, err
+	}
+	return release, nil
 }
 
 
 
+// customVersionCheck returns the check a version entered at the prompt must
+// pass: it must be newer than repo's latest release, stay on its line, and
+// not be tagged yet. There is none with --force. The tags are only listed
+// the first time a version gets that far.
+func (m *Manager) customVersionCheck(ctx context.Context, repo *ReleaseRepository) func(*semver.Version) error {
+	if m.force {
+		return nil
+	}
+
+	var tags []string
+	var listErr error
+	listed := false
+	return func(v *semver.Version) error {
+		if !v.GreaterThan(repo.LatestRelease) {
+			return fmt.Errorf("%s isn't newer than %s (use --force to release it anyway)", repo.Scheme.DisplayVersion(v), repo.latestRef())
+		}
+		if !repo.Scheme.InLine(v) {
+			return fmt.Errorf("%s isn't on the %s line (use --force to release it anyway)", repo.Scheme.DisplayVersion(v), repo.Scheme.Line)
+		}
+
+		if !listed {
+			tags, listErr = m.forgeFor(repo).ListTags(ctx, repo.Repository)
+			listed = true
+		}
+		if listErr != nil {
+			return fmt.Errorf("failed to check for an existing tag (use --force to skip the check): %w", listErr)
+		}
+		name := repo.Scheme.FormatVersion(v)
+		for _, tag := range tags {
+			if existing, err := repo.Scheme.ParseTag(tag); tag == name || (err == nil && existing.String() == v.String()) {
+				return fmt.Errorf("%s is already tagged as %s (use --force to release it anyway)", repo.Scheme.DisplayVersion(v), tag)
+			}
+		}
+		return nil
+	}
+}
+
+// SuggestBump recommends a version bump for releasing entries, which were
+// generated for repo by GenerateChangelog.
+func (m *Manager) SuggestBump(repo *ReleaseRepository, entries []Entry) BumpSuggestion {
+	return SuggestBump(entries, repo.Commits, m.bumpLabels)
+}
+
 func (m *Manager) generateCrossLinks(currentRepo *ReleaseRepository, repos []*ReleaseRepository) []CrossLink {
 	var links []CrossLink
 
@@ -564,22 +1641,17 @@ func (m *Manager) generateCrossLinks(currentRepo *ReleaseRepository, repos []*Re
 			continue
 		}
 
-		var repoName string
-		if repo.Alias != "" {
-			repoName = repo.Alias
-		} else {
-			repoName = repo.Name
-		}
+		// Components are linked separately, as repo:component
+		repoName := repo.GetDisplayName()
 
 		// Use the latest release version for cross-links
 		version := repo.LatestRelease
 
-		releaseURL := fmt.Sprintf("https://github.com/%s/%s/releases/tag/v%s",
-			repo.Owner, repo.Name, version.String())
+		releaseURL := m.forgeFor(repo).ReleaseURL(repo.Repository, repo.latestRef())
 
 		links = append(links, CrossLink{
 			Name:    repoName,
-			Version: version.String(),
+			Version: repo.Scheme.DisplayVersion(version),
 			URL:     releaseURL,
 		})
 	}
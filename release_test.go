@@ -0,0 +1,625 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver"
+)
+
+// fakeForge is an in-memory Forge for testing the Manager. Every method
+// fails with err when it's set; methods a test has no use for panic through
+// the nil embedded Forge.
+type fakeForge struct {
+	Forge
+	err error
+
+	releases    []*ForgeRelease
+	tags        []string
+	files       map[string]string      // "path@ref"
+	comparisons map[string]*Comparison // "base...head"
+	pulls       map[int]*PullRequest
+	pullFiles   map[int][]string
+
+	// onCreate runs when a release is created, before it's recorded.
+	onCreate func(ctx context.Context, release *ForgeRelease)
+	created  []*ForgeRelease
+	uploaded []string
+
+	calls map[string]int
+}
+
+func (f *fakeForge) called(method string) error {
+	if f.calls == nil {
+		f.calls = make(map[string]int)
+	}
+	f.calls[method]++
+	return f.err
+}
+
+func (f *fakeForge) GetLatestRelease(ctx context.Context, repo *Repository) (*ForgeRelease, error) {
+	if err := f.called("GetLatestRelease"); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("no releases found for %s", repo)
+}
+
+func (f *fakeForge) ListReleases(ctx context.Context, repo *Repository) ([]*ForgeRelease, error) {
+	if err := f.called("ListReleases"); err != nil {
+		return nil, err
+	}
+	return f.releases, nil
+}
+
+func (f *fakeForge) ListTags(ctx context.Context, repo *Repository) ([]string, error) {
+	if err := f.called("ListTags"); err != nil {
+		return nil, err
+	}
+	return f.tags, nil
+}
+
+func (f *fakeForge) GetFile(ctx context.Context, repo *Repository, path, ref string) ([]byte, error) {
+	if err := f.called("GetFile"); err != nil {
+		return nil, err
+	}
+	content, ok := f.files[path+"@"+ref]
+	if !ok {
+		return nil, nil
+	}
+	return []byte(content), nil
+}
+
+func (f *fakeForge) CreateRelease(ctx context.Context, repo *Repository, release *ForgeRelease) (*ForgeRelease, error) {
+	if err := f.called("CreateRelease"); err != nil {
+		return nil, err
+	}
+	if f.onCreate != nil {
+		f.onCreate(ctx, release)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f.created = append(f.created, release)
+	return release, nil
+}
+
+func (f *fakeForge) UploadReleaseAsset(ctx context.Context, repo *Repository, release *ForgeRelease, path string) error {
+	if err := f.called("UploadReleaseAsset"); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.uploaded = append(f.uploaded, path)
+	return nil
+}
+
+func (f *fakeForge) CompareCommits(ctx context.Context, repo *Repository, base, head string) (*Comparison, error) {
+	if err := f.called("CompareCommits"); err != nil {
+		return nil, err
+	}
+	comparison, ok := f.comparisons[base+"..."+head]
+	if !ok {
+		return nil, fmt.Errorf("no comparison %s...%s for %s", base, head, repo)
+	}
+	return comparison, nil
+}
+
+func (f *fakeForge) GetPullRequest(ctx context.Context, repo *Repository, number int) (*PullRequest, error) {
+	if err := f.called("GetPullRequest"); err != nil {
+		return nil, err
+	}
+	pr, ok := f.pulls[number]
+	if !ok {
+		return nil, fmt.Errorf("failed to get pull request #%d for %s: 404 Not Found", number, repo)
+	}
+	return pr, nil
+}
+
+func (f *fakeForge) GetPullRequestFiles(ctx context.Context, repo *Repository, number int) ([]string, error) {
+	if err := f.called("GetPullRequestFiles"); err != nil {
+		return nil, err
+	}
+	return f.pullFiles[number], nil
+}
+
+func (f *fakeForge) GetPullRequestComments(ctx context.Context, repo *Repository, number int) ([]string, error) {
+	if err := f.called("GetPullRequestComments"); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (f *fakeForge) ReleaseURL(repo *Repository, tag string) string {
+	return fmt.Sprintf("https://forge.example.com/%s/releases/%s", repo, tag)
+}
+
+func (f *fakeForge) PullRequestURL(repo *Repository, number int) string {
+	return fmt.Sprintf("https://forge.example.com/%s/pull/%d", repo, number)
+}
+
+func (f *fakeForge) ChangeRef(number int) string {
+	return fmt.Sprintf("#%d", number)
+}
+
+// fakeGitHubForge adds GitHub's lookups of the pull requests containing a
+// commit to fakeForge. Commits missing from associations fail to be looked up
+// one at a time, as GitHub fails for commits it doesn't know.
+type fakeGitHubForge struct {
+	*fakeForge
+	associations map[string][]*PullRequest
+	batchErr     error
+	batched      []int
+}
+
+func (f *fakeGitHubForge) ListCommitPullRequests(ctx context.Context, repo *Repository, sha string) ([]*PullRequest, error) {
+	if err := f.called("ListCommitPullRequests"); err != nil {
+		return nil, err
+	}
+	prs, ok := f.associations[sha]
+	if !ok {
+		return nil, fmt.Errorf("422 No commit found for SHA: %s", sha)
+	}
+	return prs, nil
+}
+
+func (f *fakeGitHubForge) GetCommitPullRequestsBatch(ctx context.Context, repo *Repository, shas []string) (map[string][]*PullRequest, error) {
+	if err := f.called("GetCommitPullRequestsBatch"); err != nil {
+		return nil, err
+	}
+	if f.batchErr != nil {
+		return nil, f.batchErr
+	}
+	result := make(map[string][]*PullRequest)
+	for _, sha := range shas {
+		if prs, ok := f.associations[sha]; ok {
+			result[sha] = prs
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeGitHubForge) GetPullRequestsBatch(ctx context.Context, repo *Repository, numbers []int) (map[int]*PullRequest, error) {
+	if err := f.called("GetPullRequestsBatch"); err != nil {
+		return nil, err
+	}
+	f.batched = append(f.batched, numbers...)
+	result := make(map[int]*PullRequest)
+	for _, number := range numbers {
+		if pr, ok := f.pulls[number]; ok {
+			result[number] = pr
+		}
+	}
+	return result, nil
+}
+
+func TestLookupPullRequestsByCommit(t *testing.T) {
+	merged := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	pr := func(number int, base string, mergedAt time.Time) *PullRequest {
+		return &PullRequest{Number: number, Title: fmt.Sprintf("PR %d", number), BaseBranch: base, MergedAt: mergedAt, Comments: []string{}}
+	}
+	// Oldest first. #30 and #31 were merged with merge commits, #7 too after
+	// b1 and b2, #15 was squashed, #20 went into develop and #21 wasn't merged.
+	commits := []Commit{
+		{SHA: "e1", Message: "Export", Parents: []string{"base"}},
+		{SHA: "f1", Message: "Merge pull request #30 from org/export", Parents: []string{"base", "e1"}},
+		{SHA: "f2", Message: "Merge pull request #31 from org/develop", Parents: []string{"f1", "dev"}},
+		{SHA: "b1", Message: "Add retries", Parents: []string{"f2"}},
+		{SHA: "b2", Message: "Retry on timeouts too", Parents: []string{"b1"}},
+		{SHA: "m1", Message: "Merge branch 'retries'", Parents: []string{"f2", "b2"}},
+		{SHA: "s1", Message: "Fix typo, fixes #12", Parents: []string{"m1"}},
+		{SHA: "d1", Message: "Bump deps (#20)", Parents: []string{"s1"}},
+		{SHA: "d2", Message: "Spike", Parents: []string{"d1"}},
+	}
+	// Only the commits GitHub is asked about one at a time; e1, f1 and f2
+	// fail, so their messages are read instead.
+	rest := map[string][]*PullRequest{
+		"m1": {pr(7, "main", merged)},
+		"s1": {pr(15, "main", merged)},
+		"d1": {pr(20, "develop", merged)},
+		"d2": {pr(21, "main", time.Time{})},
+	}
+	every := map[string][]*PullRequest{
+		"e1": {pr(30, "main", merged)},
+		"f1": {pr(30, "main", merged)},
+		"f2": {pr(31, "develop", merged)},
+		"b1": {pr(7, "main", merged)},
+		"b2": {pr(7, "main", merged)},
+	}
+	for sha, prs := range rest {
+		every[sha] = prs
+	}
+
+	tests := []struct {
+		name         string
+		graphQL      bool
+		batchErr     error
+		branch       string
+		associations map[string][]*PullRequest
+		wantPRs      string
+		wantDirect   string
+		wantAssigned string
+		wantCalls    map[string]int
+		wantBatched  string
+	}{
+		{
+			name:         "rest skips the commits of a merged pull request",
+			branch:       "main",
+			associations: rest,
+			wantPRs:      "30,7,15",
+			wantDirect:   "f2,d1,d2",
+			wantAssigned: "0,30,0,7,7,7,15,0,0",
+			wantCalls:    map[string]int{"ListCommitPullRequests": 5, "GetPullRequest": 2},
+		},
+		{
+			name:         "graphql looks every commit up at once",
+			graphQL:      true,
+			branch:       "main",
+			associations: every,
+			wantPRs:      "30,7,15",
+			wantDirect:   "f2,d1,d2",
+			wantAssigned: "30,30,0,7,7,7,15,0,0",
+			wantCalls:    map[string]int{"GetCommitPullRequestsBatch": 1},
+		},
+		{
+			name:         "graphql doesn't fetch the pull requests found by commit again",
+			graphQL:      true,
+			batchErr:     errors.New("502 Bad Gateway"),
+			branch:       "main",
+			associations: rest,
+			wantPRs:      "30,7,15",
+			wantDirect:   "f2,d1,d2",
+			wantAssigned: "0,30,0,7,7,7,15,0,0",
+			wantCalls:    map[string]int{"GetCommitPullRequestsBatch": 1, "ListCommitPullRequests": 5, "GetPullRequestsBatch": 1},
+			wantBatched:  "30,31",
+		},
+		{
+			name:         "a hotfix range keeps pull requests merged into any branch",
+			graphQL:      true,
+			associations: every,
+			wantPRs:      "30,31,7,15,20",
+			wantDirect:   "d2",
+			wantAssigned: "30,30,31,7,7,7,15,20,0",
+			wantCalls:    map[string]int{"GetCommitPullRequestsBatch": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forge := &fakeGitHubForge{
+				fakeForge: &fakeForge{pulls: map[int]*PullRequest{
+					30: pr(30, "main", merged),
+					31: pr(31, "develop", merged),
+				}},
+				associations: tt.associations,
+				batchErr:     tt.batchErr,
+			}
+			manager := NewManager(forge, NewLoggerWithLevel(ErrorLevel), nil, "", false)
+			manager.graphQL = tt.graphQL
+			repo := NewRepository(&Repository{Owner: "org", Name: "repo"}, RepoConfig{}, "main")
+
+			commits := append([]Commit(nil), commits...)
+			prs, direct := manager.pullRequestsForCommits(context.Background(), repo, commits, tt.branch)
+
+			var numbers []string
+			for _, pr := range prs {
+				numbers = append(numbers, strconv.Itoa(pr.Number))
+			}
+			if got := strings.Join(numbers, ","); got != tt.wantPRs {
+				t.Errorf("Expected PRs %s, got %s", tt.wantPRs, got)
+			}
+
+			var shas []string
+			for _, commit := range direct {
+				shas = append(shas, commit.SHA)
+			}
+			if got := strings.Join(shas, ","); got != tt.wantDirect {
+				t.Errorf("Expected %s to be direct commits, got %s", tt.wantDirect, got)
+			}
+
+			var assigned []string
+			for _, commit := range commits {
+				assigned = append(assigned, strconv.Itoa(commit.PullRequest))
+			}
+			if got := strings.Join(assigned, ","); got != tt.wantAssigned {
+				t.Errorf("Expected the commits' PRs to be %s, got %s", tt.wantAssigned, got)
+			}
+
+			if fmt.Sprint(forge.calls) != fmt.Sprint(tt.wantCalls) {
+				t.Errorf("Expected calls %v, got %v", tt.wantCalls, forge.calls)
+			}
+			var batched []string
+			for _, number := range forge.batched {
+				batched = append(batched, strconv.Itoa(number))
+			}
+			if got := strings.Join(batched, ","); got != tt.wantBatched {
+				t.Errorf("Expected %q to be fetched in a batch, got %q", tt.wantBatched, got)
+			}
+		})
+	}
+}
+
+func TestResolveVersionsFromTags(t *testing.T) {
+	forge := &fakeForge{
+		tags: []string{"v2.0.0", "v1.6.0-rc.1", "v1.5.0", "v1.4.0", "nightly"},
+		comparisons: map[string]*Comparison{
+			// Released from another branch
+			"main...v2.0.0":      {Commits: []Commit{{SHA: "ccc", Message: "Start 2.x"}}, TotalCommits: 1},
+			"main...v1.6.0-rc.1": {},
+			"main...v1.5.0":      {},
+		},
+	}
+	manager := NewManager(forge, NewLoggerWithLevel(ErrorLevel), nil, "", false)
+	repo := NewRepository(&Repository{Owner: "tools", Name: "deployer"}, RepoConfig{VersionSource: VersionSourceTags}, "main")
+
+	if err := manager.ResolveVersions(context.Background(), repo); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if repo.LatestTag != "v1.5.0" || repo.LatestPrereleaseTag != "v1.6.0-rc.1" {
+		t.Errorf("Expected v1.5.0 with v1.6.0-rc.1 in flight, got %q and %q", repo.LatestTag, repo.LatestPrereleaseTag)
+	}
+	if forge.calls["CompareCommits"] != 3 {
+		t.Errorf("Expected the tags to be checked from the highest down, got %d comparisons", forge.calls["CompareCommits"])
+	}
+}
+
+func TestResolveVersionsFromTagsBoundsComparisons(t *testing.T) {
+	forge := &fakeForge{comparisons: map[string]*Comparison{}}
+	for i := 0; i < maxOffBranchTags+5; i++ {
+		tag := fmt.Sprintf("v3.%d.0", i)
+		forge.tags = append(forge.tags, tag)
+		forge.comparisons["main..."+tag] = &Comparison{TotalCommits: 1}
+	}
+	manager := NewManager(forge, NewLoggerWithLevel(ErrorLevel), nil, "", false)
+	repo := NewRepository(&Repository{Owner: "tools", Name: "deployer"}, RepoConfig{VersionSource: VersionSourceTags}, "main")
+
+	if err := manager.ResolveVersions(context.Background(), repo); err == nil {
+		t.Fatal("Expected an error when no tag is found on the branch")
+	}
+	if forge.calls["CompareCommits"] != maxOffBranchTags {
+		t.Errorf("Expected %d comparisons, got %d", maxOffBranchTags, forge.calls["CompareCommits"])
+	}
+}
+
+func TestResolveVersionsFromLocalTags(t *testing.T) {
+	dir, _ := initTestRepo(t)
+	gitIn(t, dir, "tag", "v1.5.0")
+	gitIn(t, dir, "commit", "-q", "--allow-empty", "-m", "Start 2.x")
+	gitIn(t, dir, "tag", "v2.0.0-rc.1")
+
+	forge := &fakeForge{}
+	manager := NewManager(forge, NewLoggerWithLevel(ErrorLevel), nil, "", false)
+	repo := NewRepository(&Repository{Owner: "tools", Name: "deployer"}, RepoConfig{VersionSource: VersionSourceTags, Path: dir}, "HEAD")
+
+	if err := manager.ResolveVersions(context.Background(), repo); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if repo.LatestTag != "v1.5.0" || repo.LatestPrereleaseTag != "v2.0.0-rc.1" {
+		t.Errorf("Expected v1.5.0 with v2.0.0-rc.1 in flight, got %q and %q", repo.LatestTag, repo.LatestPrereleaseTag)
+	}
+	if len(forge.calls) != 0 {
+		t.Errorf("Expected no API calls, got %v", forge.calls)
+	}
+}
+
+func TestResolveVersionsFailsOnAPIErrors(t *testing.T) {
+	forge := &fakeForge{err: errors.New("401 Unauthorized: bad token")}
+	manager := NewManager(forge, NewLoggerWithLevel(ErrorLevel), nil, "", false)
+
+	for _, source := range []string{VersionSourceRelease, VersionSourceTags} {
+		repo := NewRepository(&Repository{Owner: "tools", Name: "deployer"}, RepoConfig{VersionSource: source}, "main")
+		if err := manager.ResolveVersions(context.Background(), repo); err == nil {
+			t.Errorf("Expected %s resolution to fail instead of starting from %s", source, repo.LatestRelease)
+		}
+	}
+}
+
+func TestCustomVersionCheck(t *testing.T) {
+	forge := &fakeForge{tags: []string{"v3.0.0", "4.0.0"}}
+	manager := NewManager(forge, NewLoggerWithLevel(ErrorLevel), nil, "", false)
+	repo := NewRepository(&Repository{Owner: "tools", Name: "deployer"}, RepoConfig{}, "main")
+	repo.setLatest(&ReleaseTag{Name: "v3.0.0", Version: semver.MustParse("3.0.0")}, nil)
+
+	check := manager.customVersionCheck(context.Background(), repo)
+	tests := []struct {
+		version     string
+		expectError bool
+	}{
+		{version: "4.1.0"},
+		{version: "2.9.0", expectError: true},
+		{version: "3.0.0", expectError: true},
+		{version: "4.0.0", expectError: true},
+		{version: "3.0.1"},
+	}
+	for _, tt := range tests {
+		err := check(semver.MustParse(tt.version))
+		if tt.expectError && err == nil {
+			t.Errorf("Expected %s to be rejected", tt.version)
+		}
+		if !tt.expectError && err != nil {
+			t.Errorf("Expected %s to be accepted, got: %v", tt.version, err)
+		}
+	}
+	if forge.calls["ListTags"] != 1 {
+		t.Errorf("Expected the tags to be listed once, got %d", forge.calls["ListTags"])
+	}
+
+	manager.force = true
+	if check := manager.customVersionCheck(context.Background(), repo); check != nil {
+		t.Error("Expected --force to skip the check")
+	}
+}
+
+func TestLoadCategoriesFromReleaseConfig(t *testing.T) {
+	forge := &fakeForge{files: map[string]string{
+		".github/release.yml@main": "changelog:\n  categories:\n    - title: Fixes\n      labels: [bug]\n",
+	}}
+	manager := NewManager(forge, NewLoggerWithLevel(ErrorLevel), nil, "", false)
+	manager.categories = &Categories{Sections: []Category{{Title: "Features", Prefixes: []string{"feat"}}}, Other: "Other"}
+
+	withConfig := NewRepository(&Repository{Owner: "tools", Name: "deployer"}, RepoConfig{}, "main")
+	manager.loadCategories(context.Background(), withConfig)
+	if withConfig.Categories == nil || len(withConfig.Categories.Sections) != 1 || withConfig.Categories.Sections[0].Title != "Fixes" {
+		t.Errorf("Expected the categories of release.yml, got %+v", withConfig.Categories)
+	}
+
+	forge.files = nil
+	without := NewRepository(&Repository{Owner: "tools", Name: "cli"}, RepoConfig{}, "main")
+	manager.loadCategories(context.Background(), without)
+	if without.Categories != manager.categories {
+		t.Errorf("Expected the configured categories, got %+v", without.Categories)
+	}
+}
+
+func TestDirectCommits(t *testing.T) {
+	forge := &fakeForge{
+		comparisons: map[string]*Comparison{"v1.0.0...main": {
+			// Merging the retries branch brings in its WIP commit, which isn't
+			// a direct commit, and #99 is an issue rather than a pull request.
+			Commits: []Commit{
+				{SHA: "aaa1111", Message: "Hotfix config\n\nOPS-9", Author: "Ann", Date: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Parents: []string{"base"}},
+				{SHA: "bbb2222", Message: "WIP", Author: "Bob", Parents: []string{"aaa1111"}},
+				{SHA: "ccc3333", Message: "Merge pull request 'Add retries' (#7) from retries into main", Author: "Bob", Parents: []string{"aaa1111", "bbb2222"}},
+				{SHA: "ddd4444", Message: "Fix typo, fixes #99", Author: "Cy", Date: time.Date(2024, 5, 3, 10, 0, 0, 0, time.UTC), Parents: []string{"ccc3333"}},
+			},
+			TotalCommits: 4,
+		}},
+		pulls: map[int]*PullRequest{
+			7: {Number: 7, Title: "Add retries", Author: "bob", BaseBranch: "main", MergedAt: time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC)},
+		},
+	}
+	manager := NewManager(forge, NewLoggerWithLevel(ErrorLevel), []string{"OPS"}, "", false)
+
+	for _, enabled := range []bool{true, false} {
+		repo := NewRepository(&Repository{Owner: "tools", Name: "deployer"}, RepoConfig{DirectCommits: enabled, Jira: true}, "main")
+		repo.setLatest(&ReleaseTag{Name: "v1.0.0", Version: semver.MustParse("1.0.0")}, nil)
+
+		entries, err := manager.GenerateChangelog(context.Background(), repo)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		var got []string
+		for _, entry := range entries {
+			got = append(got, entry.Ref+" "+entry.Title)
+		}
+		want := "#7 Add retries"
+		if enabled {
+			want += ",aaa1111 Hotfix config,ddd4444 Fix typo, fixes #99"
+		}
+		if strings.Join(got, ",") != want {
+			t.Errorf("direct_commits %v: expected %s, got %s", enabled, want, strings.Join(got, ","))
+		}
+		if enabled && (entries[1].Author != "Ann" || entries[1].Date != "2024-05-01" || strings.Join(entries[1].Tickets, ",") != "OPS-9") {
+			t.Errorf("Unexpected direct commit entry %+v", entries[1])
+		}
+	}
+}
+
+func TestCreateReleaseFinishesAfterInterrupt(t *testing.T) {
+	dir, sha := initTestRepo(t)
+	ctx, interrupt := context.WithCancel(context.Background())
+	defer interrupt()
+
+	// Ctrl-C while the release is being created
+	forge := &fakeForge{onCreate: func(context.Context, *ForgeRelease) { interrupt() }}
+	manager := NewManager(forge, NewLoggerWithLevel(ErrorLevel), nil, "", false)
+	repo := NewRepository(&Repository{Owner: "org", Name: "repo"}, RepoConfig{GenerateAssets: "echo README #", Path: dir}, sha)
+
+	if err := manager.createRelease(ctx, repo, semver.MustParse("1.1.0"), "notes", nil, ""); err != nil {
+		t.Fatalf("Expected the release to be finished, got: %v", err)
+	}
+	if len(forge.created) != 1 || len(forge.uploaded) != 1 {
+		t.Errorf("Expected the release and its asset, got %d releases and assets %v", len(forge.created), forge.uploaded)
+	}
+}
+
+func TestPublishedErrorKeepsTheRelease(t *testing.T) {
+	dir, sha := initTestRepo(t)
+	forge := &fakeForge{}
+	manager := NewManager(forge, NewLoggerWithLevel(ErrorLevel), nil, "", false)
+	manager.autoBump = true
+	repo := NewRepository(&Repository{Owner: "org", Name: "repo"}, RepoConfig{GenerateAssets: "echo missing-asset #", Path: dir}, sha)
+	repo.setLatest(&ReleaseTag{Name: "v1.0.0", Version: semver.MustParse("1.0.0")}, nil)
+
+	upload := errors.New("upload failed")
+	forge.onCreate = func(context.Context, *ForgeRelease) { forge.err = upload }
+	entries := []Entry{{Number: 1, Title: "fix: handle nil"}}
+
+	release, err := manager.ProcessReleaseInteractiveWithEntries(context.Background(), repo, TypeRegular, nil, entries)
+	if !errors.Is(err, upload) {
+		t.Fatalf("Expected the upload error, got: %v", err)
+	}
+	if release == nil || release.Version.String() != "1.0.1" {
+		t.Errorf("Expected the created 1.0.1 release to be returned, got %+v", release)
+	}
+}
+
+func TestCreateReleaseBuildsAssetsFromTheChangelogCommit(t *testing.T) {
+	remote := t.TempDir()
+	gitIn(t, remote, "init", "-q", "--bare")
+	dir, _ := initTestRepo(t)
+	gitIn(t, dir, "remote", "add", "origin", remote)
+	gitIn(t, dir, "push", "-q", "origin", "HEAD:refs/heads/main")
+
+	forge := &fakeForge{}
+	manager := NewManager(forge, NewLoggerWithLevel(ErrorLevel), nil, "", false)
+	repo := NewRepository(&Repository{Owner: "org", Name: "repo"}, RepoConfig{
+		Path:             dir,
+		ChangelogFile:    "CHANGELOG.md",
+		ChangelogMessage: "Update changelog for {version}",
+		// Fails unless run on the changelog commit
+		GenerateAssets: `test "$(git log -1 --format=%s)" = "Update changelog for $1" && echo README #`,
+	}, "main")
+
+	entries := []Entry{{Number: 1, Ref: "#1", Title: "Fix login"}}
+	if err := manager.createRelease(context.Background(), repo, semver.MustParse("1.1.0"), "notes", entries, ""); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if tip := gitIn(t, remote, "rev-parse", "main"); len(forge.created) != 1 || forge.created[0].Target != tip {
+		t.Errorf("Expected the changelog commit %s to be tagged, got %+v", tip, forge.created)
+	}
+}
+
+func TestComponentChangesAreLookedUpOnce(t *testing.T) {
+	forge := &fakeForge{
+		comparisons: map[string]*Comparison{"web-v1.0.0...main": {
+			Commits: []Commit{
+				{SHA: "a1", Message: "Merge pull request #1 from org/api"},
+				{SHA: "a2", Message: "Merge pull request #2 from org/web"},
+			},
+			TotalCommits: 2,
+		}},
+		pulls: map[int]*PullRequest{
+			1: {Number: 1, Title: "Fix the API", BaseBranch: "main"},
+			2: {Number: 2, Title: "Fix the page", BaseBranch: "main"},
+		},
+		pullFiles: map[int][]string{
+			1: {"packages/api/main.go"},
+			2: {"packages/web/index.html"},
+		},
+	}
+	manager := NewManager(forge, NewLoggerWithLevel(ErrorLevel), nil, "", false)
+	repo := NewRepository(&Repository{Owner: "org", Name: "repo"}, RepoConfig{}, "main")
+	repo.Component, repo.Paths = "web", []string{"packages/web"}
+	repo.setLatest(&ReleaseTag{Name: "web-v1.0.0", Version: semver.MustParse("1.0.0")}, nil)
+
+	if hasChanges, err := manager.HasChanges(context.Background(), repo); err != nil || !hasChanges {
+		t.Fatalf("Expected changes, got %v, %v", hasChanges, err)
+	}
+	entries, err := manager.GenerateChangelog(context.Background(), repo)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Number != 2 {
+		t.Errorf("Expected only #2 in the changelog, got %+v", entries)
+	}
+	if forge.calls["CompareCommits"] != 1 || forge.calls["GetPullRequestFiles"] != 2 {
+		t.Errorf("Expected the changes to be looked up once, got %v", forge.calls)
+	}
+}
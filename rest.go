@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// restClient sends requests to a REST API root for the forges that don't have
+// a client library of their own. It shares the rate limit and cache
+// transports with the GitHub client.
+type restClient struct {
+	httpClient    *http.Client
+	baseURL       *url.URL
+	authorization string
+}
+
+// newRestClient returns a client for baseURL that sends authorization (when
+// not empty) as the Authorization header of every request. A non-zero timeout
+// bounds each request.
+func newRestClient(baseURL *url.URL, authorization, cacheDir string, timeout time.Duration, logger *Logger) *restClient {
+	var transport http.RoundTripper = newRateLimitTransport(http.DefaultTransport, logger)
+	if cacheDir != "" {
+		transport = newCacheTransport(transport, cacheDir, logger)
+	}
+	return &restClient{
+		httpClient:    &http.Client{Transport: transport, Timeout: timeout},
+		baseURL:       baseURL,
+		authorization: authorization,
+	}
+}
+
+// rawBody is a request body sent as-is instead of being JSON-encoded.
+type rawBody struct {
+	contentType string
+	data        []byte
+}
+
+// do sends a request to path (relative to the API root) and decodes a JSON
+// response into out when it isn't nil. body is JSON-encoded unless it's a
+// rawBody. Responses outside the 2xx range are returned, alongside an error
+// that includes the response body, which is where these APIs explain
+// themselves.
+func (c *restClient) do(ctx context.Context, method, path string, query url.Values, body interface{}, out interface{}) (*http.Response, error) {
+	u, err := c.baseURL.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	if query != nil {
+		u.RawQuery = query.Encode()
+	}
+
+	var reader io.Reader
+	contentType := ""
+	switch b := body.(type) {
+	case nil:
+	case rawBody:
+		reader = bytes.NewReader(b.data)
+		contentType = b.contentType
+	default:
+		encoded, err := json.Marshal(b)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(encoded)
+		contentType = "application/json"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.authorization != "" {
+		req.Header.Set("Authorization", c.authorization)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp, fmt.Errorf("%s %s: %s: %s", method, u.Path, resp.Status, bytes.TrimSpace(data))
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return nil, fmt.Errorf("failed to parse response from %s: %w", u.Path, err)
+		}
+	}
+	return resp, nil
+}
+
+// decodeFileContent decodes a file's base64 content as the GitLab and Gitea
+// file APIs return it.
+func decodeFileContent(content, path, ref string, repo *Repository) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s at %s for %s: %w", path, ref, repo, err)
+	}
+	return data, nil
+}
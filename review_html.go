@@ -104,6 +104,7 @@ const htmlShell = `<!doctype html>
   h1 { font-size: 1.6rem; margin: 0 0 .25rem; }
   h2 { font-size: 1.2rem; margin: 2rem 0 .75rem; padding-bottom: .25rem;
        border-bottom: 1px solid color-mix(in srgb, currentColor 15%%, transparent); }
+  h3 { font-size: 1rem; margin: 1.25rem 0 .25rem; }
   .meta { color: color-mix(in srgb, currentColor 60%%, transparent); margin-bottom: 1.5rem; }
   .ver { font-family: ui-monospace, SFMono-Regular, Menlo, monospace;
          font-size: .85em; color: color-mix(in srgb, currentColor 60%%, transparent); font-weight: normal; }
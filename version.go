@@ -2,17 +2,20 @@ package main
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Masterminds/semver"
 )
 
+// ParseVersion parses a release tag, with or without a "v" prefix. The
+// version's Original() is the tag itself.
 func ParseVersion(versionStr string) (*semver.Version, error) {
-	versionStr = strings.TrimPrefix(versionStr, "v")
-	
 	version, err := semver.NewVersion(versionStr)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse version %s: %w", versionStr, err)
+		return nil, fmt.Errorf("failed to parse version %s: %w", strings.TrimPrefix(versionStr, "v"), err)
 	}
 	return version, nil
 }
@@ -24,8 +27,24 @@ const (
 	BumpMinor BumpType = "minor"
 	BumpMajor BumpType = "major"
 	BumpHotfix BumpType = "hotfix"
+
+	// BumpPrerelease cuts the next pre-release of the one in flight
+	// (1.3.0-rc.1 to 1.3.0-rc.2) and BumpPromote releases it as final
+	// (1.3.0-rc.2 to 1.3.0).
+	BumpPrerelease BumpType = "prerelease"
+	BumpPromote    BumpType = "promote"
+
+	// BumpNext releases the next calendar version.
+	BumpNext BumpType = "next"
+
+	// BumpCustom releases a version entered at the prompt.
+	BumpCustom BumpType = "custom"
 )
 
+// DefaultPrereleaseChannel names new pre-releases when prerelease_channel
+// isn't configured.
+const DefaultPrereleaseChannel = "rc"
+
 func BumpVersion(current *semver.Version, bumpType BumpType) *semver.Version {
 	// Special case: if current version is 0.0.0 (no previous release), default to 0.0.1
 	if current.String() == "0.0.0" {
@@ -49,6 +68,223 @@ func BumpVersion(current *semver.Version, bumpType BumpType) *semver.Version {
 	}
 }
 
+// bumpBetween returns the bump that takes from to to, judged by the first
+// version component that differs.
+func bumpBetween(from, to *semver.Version) BumpType {
+	switch {
+	case to.Major() != from.Major():
+		return BumpMajor
+	case to.Minor() != from.Minor():
+		return BumpMinor
+	}
+	return BumpPatch
+}
+
+// StartPrerelease returns the first pre-release on channel of the version
+// bumpType would produce, e.g. 1.2.3, minor and "rc" give 1.3.0-rc.1.
+func StartPrerelease(current *semver.Version, bumpType BumpType, channel string) (*semver.Version, error) {
+	next, err := BumpVersion(current, bumpType).SetPrerelease(channel + ".1")
+	if err != nil {
+		return nil, fmt.Errorf("invalid pre-release channel %q: %w", channel, err)
+	}
+	return &next, nil
+}
+
+// NextPrerelease increments the last numeric identifier of a pre-release,
+// e.g. 1.3.0-rc.1 gives 1.3.0-rc.2. A pre-release without one, such as
+// 1.3.0-beta, gets ".1" appended, which still sorts after it.
+func NextPrerelease(current *semver.Version) *semver.Version {
+	parts := strings.Split(current.Prerelease(), ".")
+	last := parts[len(parts)-1]
+	if n, err := strconv.ParseInt(last, 10, 64); err == nil {
+		parts[len(parts)-1] = strconv.FormatInt(n+1, 10)
+	} else {
+		parts = append(parts, "1")
+	}
+	next, _ := current.SetPrerelease(strings.Join(parts, "."))
+	next, _ = next.SetMetadata("")
+	return &next
+}
+
+// PromoteVersion returns the final version a pre-release leads up to, e.g.
+// 1.3.0-rc.2 gives 1.3.0.
+func PromoteVersion(current *semver.Version) *semver.Version {
+	return semver.MustParse(fmt.Sprintf("%d.%d.%d", current.Major(), current.Minor(), current.Patch()))
+}
+
+// PrereleaseChannel returns the channel of a pre-release, its first
+// identifier ("rc" for 1.3.0-rc.2), or "" for a final version.
+func PrereleaseChannel(v *semver.Version) string {
+	channel, _, _ := strings.Cut(v.Prerelease(), ".")
+	return channel
+}
+
+// VersionScheme is how a repository numbers and tags its releases:
+// semantic versioning when CalVer is nil, calendar versioning otherwise.
+// TagPattern places the version in tag names, e.g. "api/v{version}"; when
+// empty, semantic versions are tagged v1.2.3 and calendar versions 2026.10.0.
+// A Line restricts the releases read and made to one maintenance line.
+type VersionScheme struct {
+	CalVer     *CalVer
+	TagPattern string
+	Line       *VersionLine
+}
+
+// BumpVersion returns the version after current. Calendar versions ignore
+// bumpType and depend on the release date instead. Bumps that would leave
+// the scheme's Line fail.
+func (s VersionScheme) BumpVersion(current *semver.Version, bumpType BumpType, date time.Time) (*semver.Version, error) {
+	if s.CalVer != nil {
+		return s.CalVer.Next(current, date)
+	}
+	next := BumpVersion(current, bumpType)
+	if !s.InLine(next) {
+		return nil, fmt.Errorf("a %s release of %s would leave the %s line", bumpType, current, s.Line)
+	}
+	return next, nil
+}
+
+// InLine reports whether v belongs to the scheme's Line, which every version
+// does when there is none.
+func (s VersionScheme) InLine(v *semver.Version) bool {
+	return s.Line == nil || s.Line.Contains(v)
+}
+
+// VersionLine is a maintenance line of semantic versions: a major version
+// such as 1 (1.x.y) or a minor version such as 1.4 (1.4.x).
+type VersionLine struct {
+	Major int64
+	// Minor is -1 for a major line.
+	Minor int64
+}
+
+// ParseVersionLine parses a line such as "1.4", "v1.4" or "1.4.x".
+func ParseVersionLine(s string) (*VersionLine, error) {
+	parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(s, "v"), ".x"), ".")
+	if len(parts) > 2 {
+		return nil, fmt.Errorf("version line %q must be a major or minor version, e.g. 1 or 1.4", s)
+	}
+
+	line := &VersionLine{Minor: -1}
+	for i, part := range parts {
+		n, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("version line %q must be a major or minor version, e.g. 1 or 1.4", s)
+		}
+		if i == 0 {
+			line.Major = n
+		} else {
+			line.Minor = n
+		}
+	}
+	return line, nil
+}
+
+// Contains reports whether v is one of the line's versions.
+func (l *VersionLine) Contains(v *semver.Version) bool {
+	return v.Major() == l.Major && (l.Minor < 0 || v.Minor() == l.Minor)
+}
+
+func (l *VersionLine) String() string {
+	if l.Minor < 0 {
+		return strconv.FormatInt(l.Major, 10)
+	}
+	return fmt.Sprintf("%d.%d", l.Major, l.Minor)
+}
+
+// FormatVersion returns the tag a release of v gets.
+func (s VersionScheme) FormatVersion(v *semver.Version) string {
+	if v == nil {
+		return FormatVersion(v)
+	}
+	return strings.Replace(s.tagPattern(), "{version}", s.DisplayVersion(v), 1)
+}
+
+// ParseTag returns the version tag was released as. Without a TagPattern,
+// tags may or may not have a "v" prefix; with one, tags that don't match it
+// are rejected.
+func (s VersionScheme) ParseTag(tag string) (*semver.Version, error) {
+	if s.TagPattern == "" {
+		return ParseVersion(tag)
+	}
+	prefix, suffix, _ := strings.Cut(s.TagPattern, "{version}")
+	if len(tag) <= len(prefix)+len(suffix) || !strings.HasPrefix(tag, prefix) || !strings.HasSuffix(tag, suffix) {
+		return nil, fmt.Errorf("tag %s doesn't match %s", tag, s.TagPattern)
+	}
+	return ParseVersion(tag[len(prefix) : len(tag)-len(suffix)])
+}
+
+// DisplayVersion returns v as release notes and prompts show it, without a
+// "v" prefix. Calendar versions keep the spelling they were parsed from or
+// rendered to, since zero-padded versions aren't valid semver.
+func (s VersionScheme) DisplayVersion(v *semver.Version) string {
+	if s.CalVer == nil {
+		return v.String()
+	}
+	return strings.TrimPrefix(v.Original(), "v")
+}
+
+func (s VersionScheme) tagPattern() string {
+	switch {
+	case s.TagPattern != "":
+		return s.TagPattern
+	case s.CalVer != nil:
+		return "{version}"
+	}
+	return "v{version}"
+}
+
+// ReleaseTag is a released version and the tag it was released under, which
+// may not be what FormatVersion would name it.
+type ReleaseTag struct {
+	Name    string
+	Version *semver.Version
+}
+
+// LatestTags picks the highest final version among tags (nil when there is
+// none) and the highest pre-release above it (nil when there is none). Tags
+// that aren't versions in this scheme, or in its line, are ignored.
+func (s VersionScheme) LatestTags(tags []string) (final, prerelease *ReleaseTag) {
+	var parsed []*ReleaseTag
+	for _, name := range tags {
+		v, err := s.ParseTag(name)
+		if err != nil || !s.InLine(v) {
+			continue
+		}
+		tag := &ReleaseTag{Name: name, Version: v}
+		parsed = append(parsed, tag)
+		if v.Prerelease() == "" && (final == nil || v.GreaterThan(final.Version)) {
+			final = tag
+		}
+	}
+
+	for _, tag := range parsed {
+		v := tag.Version
+		if v.Prerelease() != "" && (final == nil || v.GreaterThan(final.Version)) && (prerelease == nil || v.GreaterThan(prerelease.Version)) {
+			prerelease = tag
+		}
+	}
+	return final, prerelease
+}
+
+// SortTags returns the tags that are versions in this scheme and its line,
+// highest first. Tags with equal versions, such as a release and its hotfix,
+// keep their order.
+func (s VersionScheme) SortTags(tags []string) []*ReleaseTag {
+	var parsed []*ReleaseTag
+	for _, name := range tags {
+		v, err := s.ParseTag(name)
+		if err != nil || !s.InLine(v) {
+			continue
+		}
+		parsed = append(parsed, &ReleaseTag{Name: name, Version: v})
+	}
+	sort.SliceStable(parsed, func(i, j int) bool {
+		return parsed[i].Version.GreaterThan(parsed[j].Version)
+	})
+	return parsed
+}
+
 func FormatVersion(v *semver.Version) string {
 	if v == nil {
 		return "(not set)"
@@ -60,10 +296,8 @@ func CreateHotfixVersion(baseVersion *semver.Version, suffix string) (*semver.Ve
 	// Create hotfix version by adding suffix as build metadata
 	// For example: v1.2.3 + "fix1" -> v1.2.3+fix1
 	// Strip any existing metadata to avoid appending (e.g., 1.3.0+a -> 1.3.0+b, not 1.3.0+a+b)
-	coreVersion := fmt.Sprintf("%d.%d.%d", baseVersion.Major(), baseVersion.Minor(), baseVersion.Patch())
-	if baseVersion.Prerelease() != "" {
-		coreVersion = fmt.Sprintf("%s-%s", coreVersion, baseVersion.Prerelease())
-	}
+	// The base's own spelling is kept so zero-padded calendar versions stay padded
+	coreVersion, _, _ := strings.Cut(baseVersion.Original(), "+")
 	hotfixStr := fmt.Sprintf("%s+%s", coreVersion, suffix)
 
 	hotfixVersion, err := semver.NewVersion(hotfixStr)
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Masterminds/semver"
 )
@@ -228,3 +230,209 @@ func TestCreateHotfixVersion(t *testing.T) {
 	}
 }
 
+
+func TestStartPrerelease(t *testing.T) {
+	tests := []struct {
+		current  string
+		bump     BumpType
+		channel  string
+		expected string
+	}{
+		{"1.2.3", BumpPatch, "rc", "1.2.4-rc.1"},
+		{"1.2.3", BumpMinor, "rc", "1.3.0-rc.1"},
+		{"1.2.3", BumpMajor, "beta", "2.0.0-beta.1"},
+		{"0.0.0", BumpMinor, "rc", "0.0.1-rc.1"},
+	}
+
+	for _, tt := range tests {
+		got, err := StartPrerelease(semver.MustParse(tt.current), tt.bump, tt.channel)
+		if err != nil {
+			t.Fatalf("StartPrerelease(%s, %s, %s): unexpected error: %v", tt.current, tt.bump, tt.channel, err)
+		}
+		if got.String() != tt.expected {
+			t.Errorf("StartPrerelease(%s, %s, %s): expected %s, got %s", tt.current, tt.bump, tt.channel, tt.expected, got)
+		}
+	}
+
+	if _, err := StartPrerelease(semver.MustParse("1.2.3"), BumpPatch, "!rc"); err == nil {
+		t.Error("Expected an error for an invalid channel")
+	}
+}
+
+func TestNextPrerelease(t *testing.T) {
+	tests := []struct {
+		current  string
+		expected string
+	}{
+		{"1.3.0-rc.1", "1.3.0-rc.2"},
+		{"1.3.0-rc.9", "1.3.0-rc.10"},
+		{"1.3.0-beta", "1.3.0-beta.1"},
+		{"1.3.0-rc.1+build.5", "1.3.0-rc.2"},
+	}
+
+	for _, tt := range tests {
+		if got := NextPrerelease(semver.MustParse(tt.current)); got.String() != tt.expected {
+			t.Errorf("NextPrerelease(%s): expected %s, got %s", tt.current, tt.expected, got)
+		}
+	}
+}
+
+func TestPromoteVersion(t *testing.T) {
+	if got := PromoteVersion(semver.MustParse("1.3.0-rc.2")); got.String() != "1.3.0" {
+		t.Errorf("Expected 1.3.0, got %s", got)
+	}
+	if got := PrereleaseChannel(semver.MustParse("1.3.0-rc.2")); got != "rc" {
+		t.Errorf("Expected channel rc, got %q", got)
+	}
+}
+
+func TestLatestTags(t *testing.T) {
+	tests := []struct {
+		name           string
+		scheme         VersionScheme
+		tags           []string
+		wantFinal      string
+		wantPrerelease string
+	}{
+		{"no tags", VersionScheme{}, nil, "", ""},
+		{"finals only", VersionScheme{}, []string{"v1.0.0", "v1.2.0", "v1.1.0"}, "v1.2.0", ""},
+		{"rc in flight", VersionScheme{}, []string{"v1.2.0", "v1.3.0-rc.1", "v1.3.0-rc.2"}, "v1.2.0", "v1.3.0-rc.2"},
+		{"rc without final", VersionScheme{}, []string{"v1.0.0-rc.1"}, "", "v1.0.0-rc.1"},
+		{"rc already promoted", VersionScheme{}, []string{"v1.3.0-rc.2", "v1.3.0"}, "v1.3.0", ""},
+		{"non-version tags ignored", VersionScheme{}, []string{"latest", "v1.0.0"}, "v1.0.0", ""},
+		{"tags without v", VersionScheme{}, []string{"1.1.0", "v1.0.0"}, "1.1.0", ""},
+		{"tag pattern", VersionScheme{TagPattern: "api/v{version}"}, []string{"api/v2.1.0", "web/v3.0.0", "v4.0.0"}, "api/v2.1.0", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			final, prerelease := tt.scheme.LatestTags(tt.tags)
+			gotFinal, gotPrerelease := "", ""
+			if final != nil {
+				gotFinal = final.Name
+			}
+			if prerelease != nil {
+				gotPrerelease = prerelease.Name
+			}
+			if gotFinal != tt.wantFinal {
+				t.Errorf("Expected final %q, got %q", tt.wantFinal, gotFinal)
+			}
+			if gotPrerelease != tt.wantPrerelease {
+				t.Errorf("Expected pre-release %q, got %q", tt.wantPrerelease, gotPrerelease)
+			}
+		})
+	}
+}
+
+func TestSortTags(t *testing.T) {
+	tags := VersionScheme{}.SortTags([]string{"v1.2.0", "latest", "v1.10.0", "v1.2.0+fix1", "v2.0.0-rc.1", "v1.9.0"})
+
+	var got []string
+	for _, tag := range tags {
+		got = append(got, tag.Name)
+	}
+	want := "v2.0.0-rc.1,v1.10.0,v1.9.0,v1.2.0,v1.2.0+fix1"
+	if strings.Join(got, ",") != want {
+		t.Errorf("Expected %s, got %s", want, strings.Join(got, ","))
+	}
+}
+
+func TestParseVersionLine(t *testing.T) {
+	tests := []struct {
+		line        string
+		expected    string
+		contains    []string
+		excludes    []string
+		expectError bool
+	}{
+		{line: "1.4", expected: "1.4", contains: []string{"1.4.0", "1.4.8", "v1.4.9-rc.1"}, excludes: []string{"1.5.0", "2.4.0"}},
+		{line: "v1.4.x", expected: "1.4", contains: []string{"1.4.2"}},
+		{line: "1", expected: "1", contains: []string{"1.0.0", "1.9.3"}, excludes: []string{"2.0.0", "0.1.0"}},
+		{line: "1.4.8", expectError: true},
+		{line: "one", expectError: true},
+		{line: "", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			line, err := ParseVersionLine(tt.line)
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if line.String() != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, line)
+			}
+			for _, v := range tt.contains {
+				if !line.Contains(semver.MustParse(v)) {
+					t.Errorf("Expected %s to be on the line", v)
+				}
+			}
+			for _, v := range tt.excludes {
+				if line.Contains(semver.MustParse(v)) {
+					t.Errorf("Expected %s not to be on the line", v)
+				}
+			}
+		})
+	}
+}
+
+func TestVersionSchemeLine(t *testing.T) {
+	line, _ := ParseVersionLine("1.4")
+	scheme := VersionScheme{Line: line}
+
+	final, _ := scheme.LatestTags([]string{"v2.1.0", "v1.4.7", "v1.4.6", "v1.5.0"})
+	if final == nil || final.Name != "v1.4.7" {
+		t.Fatalf("Expected v1.4.7, got %+v", final)
+	}
+
+	next, err := scheme.BumpVersion(final.Version, BumpPatch, time.Now())
+	if err != nil || next.String() != "1.4.8" {
+		t.Errorf("Expected 1.4.8, got %v, %v", next, err)
+	}
+	if _, err := scheme.BumpVersion(final.Version, BumpMinor, time.Now()); err == nil {
+		t.Error("Expected a minor bump to leave the 1.4 line")
+	}
+}
+
+func TestVersionSchemeTags(t *testing.T) {
+	tests := []struct {
+		pattern string
+		tag     string
+		version string
+	}{
+		{"", "v1.2.3", "1.2.3"},
+		{"{version}", "1.2.3", "1.2.3"},
+		{"v{version}", "v1.2.3", "1.2.3"},
+		{"release-{version}", "release-1.2.3", "1.2.3"},
+		{"api/v{version}", "api/v1.2.3-rc.1", "1.2.3-rc.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			scheme := VersionScheme{TagPattern: tt.pattern}
+			v, err := scheme.ParseTag(tt.tag)
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if v.String() != tt.version {
+				t.Errorf("Expected version %s, got %s", tt.version, v)
+			}
+			if got := scheme.FormatVersion(v); got != tt.tag {
+				t.Errorf("Expected %s to be tagged %s, got %s", tt.version, tt.tag, got)
+			}
+		})
+	}
+
+	scheme := VersionScheme{TagPattern: "api/v{version}"}
+	for _, tag := range []string{"web/v1.2.3", "v1.2.3", "api/v", "api/vnext"} {
+		if _, err := scheme.ParseTag(tag); err == nil {
+			t.Errorf("Expected %s not to match %s", tag, scheme.TagPattern)
+		}
+	}
+}